@@ -0,0 +1,201 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package auth implements OAuth 2.1 authorization for MCP servers that use
+// the HTTP transports, as described in the
+// [authorization section] of the MCP spec.
+//
+// An MCP server acts as an OAuth resource server: it verifies the bearer token
+// sent with each request, and advertises the authorization servers that issue
+// its tokens with [ProtectedResourceMetadata].
+//
+// To protect an [http.Handler] such as an mcp.StreamableHTTPHandler, wrap it
+// with [RequireBearerToken]. The information about a verified token is available
+// to the handler, and to MCP request handlers such as tool handlers, with
+// [TokenInfoFromContext].
+//
+// [authorization section]: https://modelcontextprotocol.io/specification/2025-06-18/basic/authorization
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TokenInfo holds information about a verified bearer token.
+type TokenInfo struct {
+	// Subject identifies the principal that the token was issued to,
+	// typically a user.
+	Subject string
+	// ClientID identifies the OAuth client that obtained the token.
+	ClientID string
+	// Scopes are the scopes granted to the token.
+	Scopes []string
+	// Expiration is the time at which the token expires.
+	// It is zero if the token does not expire.
+	Expiration time.Time
+	// Extra holds additional information about the token, such as
+	// the JWT claims or introspection response fields that are not otherwise
+	// represented in TokenInfo.
+	Extra map[string]any
+}
+
+// HasScope reports whether the token was granted the given scope.
+func (ti *TokenInfo) HasScope(scope string) bool {
+	return slices.Contains(ti.Scopes, scope)
+}
+
+// A TokenVerifier checks a bearer token, returning information about it
+// if it is valid.
+// If the token is invalid or expired, a TokenVerifier should return an
+// error wrapping [ErrInvalidToken]. Other errors are treated as failures
+// of the server.
+type TokenVerifier func(ctx context.Context, token string) (*TokenInfo, error)
+
+// ErrInvalidToken is the error that a [TokenVerifier] should return for a token
+// that is expired, revoked, malformed, or otherwise invalid.
+var ErrInvalidToken = errors.New("invalid token")
+
+// RequireBearerTokenOptions are options for [RequireBearerToken].
+type RequireBearerTokenOptions struct {
+	// ResourceMetadataURL is the URL of the server's protected resource metadata.
+	// If set, it is included in the WWW-Authenticate header of responses to
+	// unauthorized requests, so that clients can discover how to obtain a token.
+	// See [ProtectedResourceMetadataURL].
+	ResourceMetadataURL string
+	// Scopes are the scopes that a token must have.
+	// Requests with tokens that lack any of them are rejected with
+	// 403 Forbidden.
+	Scopes []string
+}
+
+// RequireBearerToken returns middleware that verifies the bearer token in the
+// Authorization header of each request with verifier.
+//
+// Requests without a token, or with an invalid one, are rejected with
+// 401 Unauthorized and a WWW-Authenticate header as described in RFC 6750
+// and RFC 9728. Requests with a valid token are passed to the wrapped handler
+// with the token's [TokenInfo] in their context.
+//
+// The verifier is responsible for rejecting expired tokens, and tokens
+// issued for other resources.
+// An mcp.StreamableHTTPHandler binds each session to the Subject of the token
+// that created it, and rejects requests for the session with tokens for other
+// subjects.
+func RequireBearerToken(verifier TokenVerifier, opts *RequireBearerTokenOptions) func(http.Handler) http.Handler {
+	if opts == nil {
+		opts = &RequireBearerTokenOptions{}
+	}
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			info, status, challenge := verify(req, verifier, opts)
+			if status != 0 {
+				if challenge != nil {
+					w.Header().Set("WWW-Authenticate", challenge.String())
+				}
+				http.Error(w, http.StatusText(status), status)
+				return
+			}
+			h.ServeHTTP(w, req.WithContext(ContextWithTokenInfo(req.Context(), info)))
+		})
+	}
+}
+
+// verify verifies the bearer token of req.
+// If the token is acceptable, it returns its info and a zero status.
+// Otherwise it returns the HTTP status to respond with, and the challenge to
+// put in the WWW-Authenticate header, if any.
+func verify(req *http.Request, verifier TokenVerifier, opts *RequireBearerTokenOptions) (*TokenInfo, int, *challenge) {
+	c := &challenge{resourceMetadata: opts.ResourceMetadataURL}
+	authz := req.Header.Get("Authorization")
+	if authz == "" {
+		// RFC 6750, section 3.1: if the request lacks any authentication
+		// information, the error code should not be included.
+		return nil, http.StatusUnauthorized, c
+	}
+	scheme, token, ok := strings.Cut(authz, " ")
+	token = strings.TrimSpace(token)
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		c.err = "invalid_request"
+		c.description = "malformed Authorization header"
+		return nil, http.StatusBadRequest, c
+	}
+	info, err := verifier(req.Context(), token)
+	if errors.Is(err, ErrInvalidToken) {
+		c.err = "invalid_token"
+		c.description = err.Error()
+		return nil, http.StatusUnauthorized, c
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, nil
+	}
+	if info == nil {
+		// Guard against a buggy verifier.
+		return nil, http.StatusInternalServerError, nil
+	}
+	// The verifier has checked the expiration, allowing for clock skew.
+	for _, s := range opts.Scopes {
+		if !info.HasScope(s) {
+			c.err = "insufficient_scope"
+			c.description = fmt.Sprintf("missing scope %q", s)
+			c.scope = strings.Join(opts.Scopes, " ")
+			return nil, http.StatusForbidden, c
+		}
+	}
+	return info, 0, nil
+}
+
+// A challenge is the value of a WWW-Authenticate header for the Bearer scheme.
+type challenge struct {
+	err              string // error code, such as "invalid_token"
+	description      string
+	scope            string // space-separated scopes
+	resourceMetadata string // URL of protected resource metadata
+}
+
+func (c *challenge) String() string {
+	var params []string
+	add := func(name, value string) {
+		if value != "" {
+			params = append(params, fmt.Sprintf("%s=%s", name, quote(value)))
+		}
+	}
+	add("error", c.err)
+	add("error_description", c.description)
+	add("scope", c.scope)
+	add("resource_metadata", c.resourceMetadata)
+	if len(params) == 0 {
+		return "Bearer"
+	}
+	return "Bearer " + strings.Join(params, ", ")
+}
+
+// quote returns s as an HTTP quoted-string.
+func quote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
+
+type tokenInfoKey struct{}
+
+// ContextWithTokenInfo returns a copy of ctx that carries info.
+// [RequireBearerToken] calls it for each request with a valid token.
+func ContextWithTokenInfo(ctx context.Context, info *TokenInfo) context.Context {
+	return context.WithValue(ctx, tokenInfoKey{}, info)
+}
+
+// TokenInfoFromContext returns the information about the bearer token of the
+// request associated with ctx, or nil if there is none.
+//
+// Inside MCP request handlers, such as tool handlers, it returns the
+// information for the HTTP request that carried the MCP request.
+func TokenInfoFromContext(ctx context.Context) *TokenInfo {
+	info, _ := ctx.Value(tokenInfoKey{}).(*TokenInfo)
+	return info
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRequireBearerToken(t *testing.T) {
+	verifier := func(_ context.Context, token string) (*TokenInfo, error) {
+		switch token {
+		case "good":
+			return &TokenInfo{Subject: "alice", Scopes: []string{"read", "write"}}, nil
+		case "readonly":
+			return &TokenInfo{Subject: "bob", Scopes: []string{"read"}}, nil
+		case "skewed":
+			// The verifier allows for clock skew, so the token may appear expired.
+			return &TokenInfo{Subject: "carol", Scopes: []string{"read", "write"}, Expiration: time.Now().Add(-time.Second)}, nil
+		case "broken":
+			return nil, errors.New("database unavailable")
+		default:
+			return nil, fmt.Errorf("%w: unknown token", ErrInvalidToken)
+		}
+	}
+	const metadataURL = "https://example.com/.well-known/oauth-protected-resource/mcp"
+	handler := RequireBearerToken(verifier, &RequireBearerTokenOptions{
+		ResourceMetadataURL: metadataURL,
+		Scopes:              []string{"read", "write"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, TokenInfoFromContext(req.Context()).Subject)
+	}))
+
+	for _, test := range []struct {
+		authz         string
+		wantStatus    int
+		wantChallenge string
+		wantBody      string
+	}{
+		{"", http.StatusUnauthorized, `Bearer resource_metadata="` + metadataURL + `"`, ""},
+		{"Basic Zm9vOmJhcg==", http.StatusBadRequest, `Bearer error="invalid_request", error_description="malformed Authorization header", resource_metadata="` + metadataURL + `"`, ""},
+		{"Bearer unknown", http.StatusUnauthorized, `Bearer error="invalid_token", error_description="invalid token: unknown token", resource_metadata="` + metadataURL + `"`, ""},
+		{"Bearer readonly", http.StatusForbidden, `Bearer error="insufficient_scope", error_description="missing scope \"write\"", scope="read write", resource_metadata="` + metadataURL + `"`, ""},
+		{"Bearer broken", http.StatusInternalServerError, "", ""},
+		{"Bearer good", http.StatusOK, "", "alice"},
+		{"bearer good", http.StatusOK, "", "alice"},
+		{"Bearer skewed", http.StatusOK, "", "carol"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if test.authz != "" {
+			req.Header.Set("Authorization", test.authz)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if got := rec.Code; got != test.wantStatus {
+			t.Errorf("%q: got status %d, want %d", test.authz, got, test.wantStatus)
+		}
+		if got := rec.Header().Get("WWW-Authenticate"); got != test.wantChallenge {
+			t.Errorf("%q: got WWW-Authenticate\n%s\nwant\n%s", test.authz, got, test.wantChallenge)
+		}
+		if test.wantBody != "" {
+			if got := rec.Body.String(); got != test.wantBody {
+				t.Errorf("%q: got body %q, want %q", test.authz, got, test.wantBody)
+			}
+		}
+	}
+}
+
+func TestProtectedResourceMetadata(t *testing.T) {
+	for _, test := range []struct {
+		resource string
+		want     string
+	}{
+		{"https://example.com", "https://example.com/.well-known/oauth-protected-resource"},
+		{"https://example.com/", "https://example.com/.well-known/oauth-protected-resource"},
+		{"https://example.com/mcp", "https://example.com/.well-known/oauth-protected-resource/mcp"},
+		{"http://localhost:8080/a/b?x=1", "http://localhost:8080/.well-known/oauth-protected-resource/a/b"},
+	} {
+		got, err := ProtectedResourceMetadataURL(test.resource)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("ProtectedResourceMetadataURL(%q) = %q, want %q", test.resource, got, test.want)
+		}
+	}
+	if _, err := ProtectedResourceMetadataURL("/mcp"); err == nil {
+		t.Error("relative resource: got nil error")
+	}
+
+	md := &ProtectedResourceMetadata{
+		Resource:               "https://example.com/mcp",
+		AuthorizationServers:   []string{"https://auth.example.com"},
+		ScopesSupported:        []string{"read", "write"},
+		BearerMethodsSupported: []string{"header"},
+	}
+	server := httptest.NewServer(ProtectedResourceMetadataHandler(md))
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("got Access-Control-Allow-Origin %q, want %q", got, "*")
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got ProtectedResourceMetadata
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(md, &got); diff != "" {
+		t.Errorf("metadata mismatch (-want +got):\n%s", diff)
+	}
+}
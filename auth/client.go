@@ -0,0 +1,736 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// This file implements the client side of MCP authorization: an HTTP
+// transport that obtains OAuth access tokens and adds them to requests.
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Token is an OAuth access token, along with the information needed to
+// refresh it.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// Expiry is when the access token expires. It is zero if the
+	// authorization server did not say.
+	Expiry time.Time `json:"expiry"`
+	// Scope is the space-separated list of scopes granted to the token.
+	Scope string `json:"scope,omitempty"`
+	// Issuer identifies the authorization server that issued the token.
+	Issuer string `json:"issuer,omitempty"`
+	// ClientID and ClientSecret are the credentials of the client that
+	// obtained the token, if it registered dynamically. A refresh token can
+	// only be used by the client it was issued to, so they are saved with the
+	// token for use by later processes.
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// expiryDelta is how long before its expiry a token is considered expired,
+// to account for clock skew and request latency.
+const expiryDelta = 10 * time.Second
+
+// valid reports whether t can be used at time now.
+func (t *Token) valid(now time.Time) bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || now.Add(expiryDelta).Before(t.Expiry))
+}
+
+// A TokenStore saves the tokens obtained for MCP servers, so that they can be
+// reused across connections and processes.
+// Tokens are keyed by the resource identifier of the server: its URL.
+// Since tokens include refresh tokens and client credentials, stores should
+// keep them secret.
+//
+// All of a TokenStore's methods must be safe for use by multiple goroutines.
+type TokenStore interface {
+	// Load returns the token for the given resource.
+	// If there is none, Load returns an error wrapping [ErrTokenNotFound].
+	Load(_ context.Context, resource string) (*Token, error)
+	// Store saves the token for the given resource, replacing any other.
+	Store(_ context.Context, resource string, _ *Token) error
+}
+
+// ErrTokenNotFound is the error that [TokenStore.Load] should return if there
+// is no token for a resource.
+var ErrTokenNotFound = errors.New("token not found")
+
+// A MemoryTokenStore is a [TokenStore] backed by memory.
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]Token
+}
+
+// NewMemoryTokenStore creates a [MemoryTokenStore].
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: make(map[string]Token)}
+}
+
+// Load implements [TokenStore.Load].
+func (s *MemoryTokenStore) Load(_ context.Context, resource string) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tok, ok := s.tokens[resource]
+	if !ok {
+		return nil, fmt.Errorf("MemoryTokenStore.Load: %q: %w", resource, ErrTokenNotFound)
+	}
+	return &tok, nil
+}
+
+// Store implements [TokenStore.Store].
+func (s *MemoryTokenStore) Store(_ context.Context, resource string, tok *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[resource] = *tok
+	return nil
+}
+
+// An AuthorizeFunc obtains the user's consent for an authorization request.
+// It directs the user to authURL, typically by opening it in a browser, and
+// waits for the authorization server to redirect back to the client's
+// redirect URL. It returns the query parameters of that redirect.
+type AuthorizeFunc func(ctx context.Context, authURL string) (url.Values, error)
+
+// OAuthConfig configures how a client obtains access tokens.
+// See [NewOAuthTransport].
+type OAuthConfig struct {
+	// Authorize is called to obtain the user's consent. It is required.
+	Authorize AuthorizeFunc
+	// RedirectURL is the URL to which the authorization server redirects
+	// after the user consents. It is required.
+	RedirectURL string
+	// ClientID and ClientSecret are the credentials of a client registered
+	// with the authorization server in advance. If ClientID is empty, the
+	// client registers itself dynamically (RFC 7591) with each authorization
+	// server that supports it.
+	ClientID     string
+	ClientSecret string
+	// ClientName is the name of the client, shown to the user by
+	// authorization servers when it registers dynamically.
+	ClientName string
+	// Scopes are the scopes to request, unless the server asks for others.
+	// If empty, the scopes in the server's protected resource metadata are
+	// requested.
+	Scopes []string
+	// Resource is the resource identifier of the MCP server, usually its URL.
+	// If empty, the URL of each request, without its query, is used.
+	Resource string
+	// TokenStore saves tokens. If nil, a [MemoryTokenStore] is used.
+	TokenStore TokenStore
+	// HTTPClient is used for requests to authorization servers and for metadata
+	// discovery. If nil, [http.DefaultClient] is used.
+	HTTPClient *http.Client
+}
+
+// NewOAuthTransport returns an [http.RoundTripper] that adds an OAuth access
+// token to requests it sends with base, obtaining one if necessary.
+// If base is nil, [http.DefaultTransport] is used.
+//
+// When a server responds with 401 Unauthorized, the transport refreshes its
+// token if it can. Otherwise, it discovers the server's authorization server
+// from its protected resource metadata (RFC 9728) and the authorization
+// server's metadata (RFC 8414), registers a client if needed, and performs an
+// authorization code flow with PKCE, calling config.Authorize to obtain the
+// user's consent. Then it retries the request.
+// It does the same when a server responds with 403 Forbidden because the token
+// lacks a scope, requesting the scopes that the server asked for.
+//
+// Requests with a body can only be retried if their GetBody field is set, as it
+// is for requests created by [http.NewRequest] with common body types.
+func NewOAuthTransport(base http.RoundTripper, config *OAuthConfig) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &oauthTransport{
+		base:    base,
+		config:  *config,
+		clients: make(map[string]*clientRegistration),
+	}
+	if t.config.TokenStore == nil {
+		t.config.TokenStore = NewMemoryTokenStore()
+	}
+	if t.config.HTTPClient == nil {
+		t.config.HTTPClient = http.DefaultClient
+	}
+	return t
+}
+
+type oauthTransport struct {
+	base   http.RoundTripper
+	config OAuthConfig
+
+	// mu serializes authorization, so that concurrent requests that fail
+	// authorization don't each ask the user for consent.
+	mu      sync.Mutex
+	clients map[string]*clientRegistration // keyed by issuer
+}
+
+// A clientRegistration holds the credentials of the client at an
+// authorization server.
+type clientRegistration struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// RoundTrip implements [http.RoundTripper].
+func (t *oauthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	resource := t.config.Resource
+	if resource == "" {
+		resource = resourceFor(req.URL)
+	}
+	tok, err := t.token(ctx, resource)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.send(req, tok)
+	if err != nil {
+		return nil, err
+	}
+	ch := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+	case resp.StatusCode == http.StatusForbidden && ch["error"] == "insufficient_scope":
+	default:
+		return resp, nil
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// We can't retry, so let the caller see the failure.
+		return resp, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	tok, err = t.authorize(ctx, resource, tok, resp.StatusCode, ch)
+	if err != nil {
+		return nil, fmt.Errorf("authorizing %s: %w", resource, err)
+	}
+	req2 := req.Clone(ctx)
+	if req.GetBody != nil {
+		if req2.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return t.send(req2, tok)
+}
+
+// send sends req with the given token, if any.
+func (t *oauthTransport) send(req *http.Request, tok *Token) (*http.Response, error) {
+	if tok != nil {
+		// RoundTrippers must not modify their request.
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// resourceFor returns the resource identifier for the server at u: its URL
+// without query or fragment.
+func resourceFor(u *url.URL) string {
+	r := *u
+	r.RawQuery = ""
+	r.ForceQuery = false
+	r.Fragment = ""
+	r.RawFragment = ""
+	return r.String()
+}
+
+// token returns a usable token for the resource, refreshing the stored one if
+// it has expired. It returns nil if there is none, so that the server can say
+// how to obtain one.
+func (t *oauthTransport) token(ctx context.Context, resource string) (*Token, error) {
+	tok, err := t.load(ctx, resource)
+	if err != nil || tok.valid(time.Now()) {
+		return tok, err
+	}
+	if tok == nil || tok.RefreshToken == "" {
+		return nil, nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// Another request may have refreshed the token while we waited.
+	if cur, err := t.load(ctx, resource); err != nil || cur.valid(time.Now()) {
+		return cur, err
+	}
+	tok, err = t.refresh(ctx, resource, tok)
+	if err != nil {
+		// The refresh token may have been revoked. Try without a token, so
+		// that the server can tell us how to get a new one.
+		return nil, nil
+	}
+	return tok, nil
+}
+
+// load loads the stored token for the resource, or nil if there is none.
+func (t *oauthTransport) load(ctx context.Context, resource string) (*Token, error) {
+	tok, err := t.config.TokenStore.Load(ctx, resource)
+	if errors.Is(err, ErrTokenNotFound) {
+		return nil, nil
+	}
+	return tok, err
+}
+
+// authorize obtains a new token for the resource, after a request with stale
+// failed with the given status and challenge.
+func (t *oauthTransport) authorize(ctx context.Context, resource string, stale *Token, status int, ch map[string]string) (*Token, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// If another request obtained a new token while we waited, use it.
+	cur, err := t.load(ctx, resource)
+	if err != nil {
+		return nil, err
+	}
+	if cur.valid(time.Now()) && (stale == nil || cur.AccessToken != stale.AccessToken) {
+		return cur, nil
+	}
+	if cur != nil && cur.ClientID != "" && cur.Issuer != "" && t.clients[cur.Issuer] == nil {
+		// Reuse the client that an earlier process registered.
+		t.clients[cur.Issuer] = &clientRegistration{ClientID: cur.ClientID, ClientSecret: cur.ClientSecret}
+	}
+	// A token that the server rejected may just need refreshing.
+	if status == http.StatusUnauthorized && cur != nil && cur.RefreshToken != "" {
+		if tok, err := t.refresh(ctx, resource, cur); err == nil {
+			return tok, nil
+		}
+	}
+	return t.authorizeCode(ctx, resource, ch)
+}
+
+// refresh exchanges the refresh token of tok for a new token.
+// t.mu must be held.
+func (t *oauthTransport) refresh(ctx context.Context, resource string, tok *Token) (*Token, error) {
+	_, asm, err := t.discover(ctx, resource, nil)
+	if err != nil {
+		return nil, err
+	}
+	var client *clientRegistration
+	if tok.ClientID != "" && tok.Issuer == asm.Issuer {
+		// The token was obtained by a registered client, perhaps in another
+		// process. Only that client can refresh it.
+		client = &clientRegistration{ClientID: tok.ClientID, ClientSecret: tok.ClientSecret}
+		if t.clients[asm.Issuer] == nil {
+			t.clients[asm.Issuer] = client
+		}
+	} else if client, err = t.client(ctx, asm); err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {tok.RefreshToken},
+		"resource":      {resource},
+	}
+	newTok, err := t.tokenRequest(ctx, asm, client, form)
+	if err != nil {
+		return nil, err
+	}
+	if newTok.RefreshToken == "" {
+		// The server did not rotate the refresh token.
+		newTok.RefreshToken = tok.RefreshToken
+	}
+	t.recordClient(newTok, asm, client)
+	if err := t.config.TokenStore.Store(ctx, resource, newTok); err != nil {
+		return nil, err
+	}
+	return newTok, nil
+}
+
+// authorizeCode performs the authorization code flow with PKCE to obtain a
+// token for the resource.
+func (t *oauthTransport) authorizeCode(ctx context.Context, resource string, ch map[string]string) (*Token, error) {
+	if t.config.Authorize == nil || t.config.RedirectURL == "" {
+		return nil, errors.New("OAuthConfig.Authorize and OAuthConfig.RedirectURL must be set")
+	}
+	prm, asm, err := t.discover(ctx, resource, ch)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(asm.CodeChallengeMethodsSupported, "S256") {
+		return nil, fmt.Errorf("authorization server %s does not support PKCE with S256", asm.Issuer)
+	}
+	client, err := t.client(ctx, asm)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := ch["scope"]
+	if scope == "" {
+		scope = strings.Join(t.config.Scopes, " ")
+	}
+	if scope == "" {
+		scope = strings.Join(prm.ScopesSupported, " ")
+	}
+	verifier := randomString()
+	challenge := sha256.Sum256([]byte(verifier))
+	state := randomString()
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {client.ClientID},
+		"redirect_uri":          {t.config.RedirectURL},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+		"state":                 {state},
+		"resource":              {resource},
+	}
+	if scope != "" {
+		q.Set("scope", scope)
+	}
+	authURL, err := url.Parse(asm.AuthorizationEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	// Preserve any query in the endpoint URL.
+	aq := authURL.Query()
+	for k, v := range q {
+		aq[k] = v
+	}
+	authURL.RawQuery = aq.Encode()
+
+	redirect, err := t.config.Authorize(ctx, authURL.String())
+	if err != nil {
+		return nil, err
+	}
+	if redirect.Get("state") != state {
+		return nil, errors.New("authorization response has wrong state")
+	}
+	if e := redirect.Get("error"); e != "" {
+		return nil, fmt.Errorf("authorization denied: %s: %s", e, redirect.Get("error_description"))
+	}
+	code := redirect.Get("code")
+	if code == "" {
+		return nil, errors.New("authorization response has no code")
+	}
+	tok, err := t.tokenRequest(ctx, asm, client, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {t.config.RedirectURL},
+		"code_verifier": {verifier},
+		"resource":      {resource},
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.recordClient(tok, asm, client)
+	if err := t.config.TokenStore.Store(ctx, resource, tok); err != nil {
+		return nil, err
+	}
+	return tok, nil
+}
+
+// recordClient records in tok the authorization server and client that
+// obtained it, if the client registered dynamically.
+// Preregistered clients are known from the config.
+func (t *oauthTransport) recordClient(tok *Token, asm *AuthorizationServerMetadata, client *clientRegistration) {
+	tok.Issuer = asm.Issuer
+	if t.config.ClientID == "" {
+		tok.ClientID = client.ClientID
+		tok.ClientSecret = client.ClientSecret
+	}
+}
+
+func randomString() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// AuthorizationServerMetadata describes an OAuth authorization server, as
+// defined in [RFC 8414].
+//
+// [RFC 8414]: https://www.rfc-editor.org/rfc/rfc8414.html
+type AuthorizationServerMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	RegistrationEndpoint              string   `json:"registration_endpoint,omitempty"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint,omitempty"`
+	JWKSURI                           string   `json:"jwks_uri,omitempty"`
+	ScopesSupported                   []string `json:"scopes_supported,omitempty"`
+	ResponseTypesSupported            []string `json:"response_types_supported,omitempty"`
+	GrantTypesSupported               []string `json:"grant_types_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+// discover returns the protected resource metadata for the resource, and the
+// metadata of its first authorization server.
+// If the challenge has a resource_metadata parameter, it is used to find the
+// protected resource metadata.
+func (t *oauthTransport) discover(ctx context.Context, resource string, ch map[string]string) (*ProtectedResourceMetadata, *AuthorizationServerMetadata, error) {
+	var urls []string
+	if u := ch["resource_metadata"]; u != "" {
+		urls = append(urls, u)
+	} else {
+		u, err := ProtectedResourceMetadataURL(resource)
+		if err != nil {
+			return nil, nil, err
+		}
+		urls = append(urls, u)
+		// Also try the root of the server.
+		if root, err := url.Parse(u); err == nil && root.Path != ProtectedResourceMetadataPath {
+			root.Path = ProtectedResourceMetadataPath
+			urls = append(urls, root.String())
+		}
+	}
+	var prm ProtectedResourceMetadata
+	if err := t.getJSON(ctx, urls, &prm); err != nil {
+		return nil, nil, fmt.Errorf("getting protected resource metadata: %w", err)
+	}
+	// RFC 9728, section 3.3: the resource must match, to prevent a server
+	// from impersonating another.
+	if strings.TrimSuffix(prm.Resource, "/") != strings.TrimSuffix(resource, "/") {
+		return nil, nil, fmt.Errorf("protected resource metadata is for %q, not %q", prm.Resource, resource)
+	}
+	if len(prm.AuthorizationServers) == 0 {
+		return nil, nil, errors.New("protected resource metadata lists no authorization servers")
+	}
+	issuer := prm.AuthorizationServers[0]
+	asURLs, err := authorizationServerMetadataURLs(issuer)
+	if err != nil {
+		return nil, nil, err
+	}
+	var asm AuthorizationServerMetadata
+	if err := t.getJSON(ctx, asURLs, &asm); err != nil {
+		return nil, nil, fmt.Errorf("getting authorization server metadata: %w", err)
+	}
+	if asm.Issuer != issuer {
+		return nil, nil, fmt.Errorf("authorization server metadata has issuer %q, want %q", asm.Issuer, issuer)
+	}
+	if asm.AuthorizationEndpoint == "" || asm.TokenEndpoint == "" {
+		return nil, nil, errors.New("authorization server metadata lacks endpoints")
+	}
+	return &prm, &asm, nil
+}
+
+// authorizationServerMetadataURLs returns the URLs at which the metadata for
+// the issuer may be found, in order of preference: the RFC 8414 location,
+// followed by the OpenID Connect Discovery locations.
+func authorizationServerMetadataURLs(issuer string) ([]string, error) {
+	u, err := url.Parse(issuer)
+	if err != nil {
+		return nil, err
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	at := func(p string) string {
+		v := *u
+		v.Path = p
+		return v.String()
+	}
+	return []string{
+		at("/.well-known/oauth-authorization-server" + path),
+		at("/.well-known/openid-configuration" + path),
+		at(path + "/.well-known/openid-configuration"),
+	}, nil
+}
+
+// getJSON unmarshals into v the JSON from the first of the URLs that serves
+// it successfully.
+func (t *oauthTransport) getJSON(ctx context.Context, urls []string, v any) error {
+	var errs []error
+	for _, u := range urls {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := t.config.HTTPClient.Do(req)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			errs = append(errs, fmt.Errorf("%s: %s", u, resp.Status))
+			continue
+		}
+		if err := json.Unmarshal(body, v); err != nil {
+			return fmt.Errorf("%s: %w", u, err)
+		}
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+// client returns the client credentials to use with the authorization server,
+// registering a client if necessary.
+func (t *oauthTransport) client(ctx context.Context, asm *AuthorizationServerMetadata) (*clientRegistration, error) {
+	if t.config.ClientID != "" {
+		return &clientRegistration{ClientID: t.config.ClientID, ClientSecret: t.config.ClientSecret}, nil
+	}
+	if c := t.clients[asm.Issuer]; c != nil {
+		return c, nil
+	}
+	if asm.RegistrationEndpoint == "" {
+		return nil, fmt.Errorf("no client ID, and authorization server %s does not support dynamic client registration", asm.Issuer)
+	}
+	name := t.config.ClientName
+	if name == "" {
+		name = "MCP client"
+	}
+	// Register a public client (RFC 7591).
+	data, err := json.Marshal(map[string]any{
+		"client_name":                name,
+		"redirect_uris":              []string{t.config.RedirectURL},
+		"grant_types":                []string{"authorization_code", "refresh_token"},
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": "none",
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, asm.RegistrationEndpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	var c clientRegistration
+	if err := t.do(req, http.StatusCreated, &c); err != nil {
+		return nil, fmt.Errorf("registering client: %w", err)
+	}
+	if c.ClientID == "" {
+		return nil, errors.New("registering client: no client ID in response")
+	}
+	t.clients[asm.Issuer] = &c
+	return &c, nil
+}
+
+// tokenRequest makes a request to the token endpoint with the given form.
+func (t *oauthTransport) tokenRequest(ctx context.Context, asm *AuthorizationServerMetadata, client *clientRegistration, form url.Values) (*Token, error) {
+	if client.ClientSecret == "" {
+		form.Set("client_id", client.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, asm.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if client.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(client.ClientID), url.QueryEscape(client.ClientSecret))
+	}
+	var resp struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+		Scope        string `json:"scope"`
+	}
+	now := time.Now()
+	if err := t.do(req, http.StatusOK, &resp); err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return nil, errors.New("token request: no access token in response")
+	}
+	if resp.TokenType != "" && !strings.EqualFold(resp.TokenType, "Bearer") {
+		return nil, fmt.Errorf("token request: unsupported token type %q", resp.TokenType)
+	}
+	tok := &Token{
+		AccessToken:  resp.AccessToken,
+		TokenType:    resp.TokenType,
+		RefreshToken: resp.RefreshToken,
+		Scope:        resp.Scope,
+	}
+	if resp.ExpiresIn > 0 {
+		tok.Expiry = now.Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
+
+// do sends req, and unmarshals the JSON response into v if the response has
+// the given status. Otherwise it returns an error, including the OAuth error
+// in the response, if any.
+func (t *oauthTransport) do(req *http.Request, status int, v any) error {
+	resp, err := t.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != status {
+		var oerr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &oerr) == nil && oerr.Error != "" {
+			return fmt.Errorf("%s: %s: %s", resp.Status, oerr.Error, oerr.Description)
+		}
+		return errors.New(resp.Status)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// parseChallenge returns the parameters of the Bearer challenge in a
+// WWW-Authenticate header, or nil if there is none.
+// For example, for
+//
+//	Bearer error="invalid_token", resource_metadata="https://example.com/.well-known/oauth-protected-resource"
+//
+// it returns a map with keys "error" and "resource_metadata".
+func parseChallenge(header string) map[string]string {
+	// Find the Bearer scheme. We don't support multiple challenges in one header.
+	s := strings.TrimSpace(header)
+	scheme, rest, _ := strings.Cut(s, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return nil
+	}
+	params := make(map[string]string)
+	for {
+		rest = strings.TrimLeft(rest, " ,")
+		if rest == "" {
+			return params
+		}
+		name, after, ok := strings.Cut(rest, "=")
+		if !ok {
+			return params
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		after = strings.TrimLeft(after, " ")
+		var value string
+		if strings.HasPrefix(after, `"`) {
+			// quoted-string
+			var b strings.Builder
+			i := 1
+			for ; i < len(after) && after[i] != '"'; i++ {
+				if after[i] == '\\' && i+1 < len(after) {
+					i++
+				}
+				b.WriteByte(after[i])
+			}
+			value = b.String()
+			rest = after[min(i+1, len(after)):]
+		} else {
+			value, rest, _ = strings.Cut(after, ",")
+			value = strings.TrimSpace(value)
+		}
+		params[name] = value
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modelcontextprotocol/go-sdk/internal/oauthtest"
+)
+
+// newProtectedServer starts a server whose /mcp endpoint requires tokens from
+// as. Requests with an X-Admin header additionally require the "admin" scope.
+// The endpoint echoes the token's subject and the request body.
+func newProtectedServer(t *testing.T, as *oauthtest.AuthServer) (resource string) {
+	t.Helper()
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	resource = server.URL + "/mcp"
+
+	keys, err := ParseKeySet(as.JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewJWTVerifier(keys, &JWTVerifierOptions{Issuer: as.URL, Audience: resource})
+	metadataURL, err := ProtectedResourceMetadataURL(resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	echo := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		fmt.Fprintf(w, "%s %s", TokenInfoFromContext(req.Context()).Subject, body)
+	})
+	user := RequireBearerToken(verifier, &RequireBearerTokenOptions{ResourceMetadataURL: metadataURL})(echo)
+	admin := RequireBearerToken(verifier, &RequireBearerTokenOptions{ResourceMetadataURL: metadataURL, Scopes: []string{"admin"}})(echo)
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Admin") != "" {
+			admin.ServeHTTP(w, req)
+		} else {
+			user.ServeHTTP(w, req)
+		}
+	})
+	mux.Handle(strings.TrimPrefix(metadataURL, server.URL), ProtectedResourceMetadataHandler(&ProtectedResourceMetadata{
+		Resource:             resource,
+		AuthorizationServers: []string{as.URL},
+		ScopesSupported:      []string{"read"},
+	}))
+	return resource
+}
+
+func TestOAuthTransport(t *testing.T) {
+	as := oauthtest.NewAuthServer()
+	defer as.Close()
+	resource := newProtectedServer(t, as)
+
+	store := NewMemoryTokenStore()
+	client := &http.Client{Transport: NewOAuthTransport(nil, &OAuthConfig{
+		Authorize:   as.Authorize,
+		RedirectURL: "http://localhost/callback",
+		TokenStore:  store,
+	})}
+	ctx := context.Background()
+
+	post := func(body string, admin bool) {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, resource, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if admin {
+			req.Header.Set("X-Admin", "1")
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		got, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%q: got status %s, body %q", body, resp.Status, got)
+		}
+		if want := "user " + body; string(got) != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	checkStats := func(want oauthtest.Stats) {
+		t.Helper()
+		if diff := cmp.Diff(want, as.Stats()); diff != "" {
+			t.Errorf("auth server stats mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	// The first request registers a client and obtains authorization, then is
+	// retried with its body.
+	post("hello", false)
+	checkStats(oauthtest.Stats{Registrations: 1, Authorizations: 1, CodeExchanges: 1})
+	tok, err := store.Load(ctx, resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Scope != "read" {
+		t.Errorf("got scope %q, want the scope from the resource metadata", tok.Scope)
+	}
+
+	// Later requests reuse the token.
+	post("again", false)
+	checkStats(oauthtest.Stats{Registrations: 1, Authorizations: 1, CodeExchanges: 1})
+
+	// Tokens that are about to expire are refreshed.
+	as.TokenLifetime = expiryDelta / 2
+	expired := *tok
+	expired.Expiry = time.Now()
+	store.Store(ctx, resource, &expired)
+	post("refresh", false)
+	checkStats(oauthtest.Stats{Registrations: 1, Authorizations: 1, CodeExchanges: 1, Refreshes: 1})
+
+	// A new transport with the same store, as after a restart, refreshes with
+	// the registered client that obtained the token.
+	client = &http.Client{Transport: NewOAuthTransport(nil, &OAuthConfig{
+		Authorize:   as.Authorize,
+		RedirectURL: "http://localhost/callback",
+		TokenStore:  store,
+	})}
+	tok, err = store.Load(ctx, resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired = *tok
+	expired.Expiry = time.Now()
+	store.Store(ctx, resource, &expired)
+	post("restarted", false)
+	checkStats(oauthtest.Stats{Registrations: 1, Authorizations: 1, CodeExchanges: 1, Refreshes: 2})
+	as.TokenLifetime = time.Hour
+
+	// If the refresh token is revoked, the user is asked again.
+	as.RevokeRefreshTokens()
+	post("revoked", false)
+	checkStats(oauthtest.Stats{Registrations: 1, Authorizations: 2, CodeExchanges: 2, Refreshes: 2})
+
+	// If the server needs more scopes, the client asks for them.
+	post("admin", true)
+	checkStats(oauthtest.Stats{Registrations: 1, Authorizations: 3, CodeExchanges: 3, Refreshes: 2})
+	if tok, _ := store.Load(ctx, resource); tok.Scope != "admin" {
+		t.Errorf("after step-up: got scope %q, want %q", tok.Scope, "admin")
+	}
+}
+
+func TestOAuthTransportPreregistered(t *testing.T) {
+	as := oauthtest.NewAuthServer()
+	defer as.Close()
+	resource := newProtectedServer(t, as)
+	const redirect = "http://localhost/callback"
+	as.RegisterClient("client1", "secret", redirect)
+
+	client := &http.Client{Transport: NewOAuthTransport(nil, &OAuthConfig{
+		Authorize:    as.Authorize,
+		RedirectURL:  redirect,
+		ClientID:     "client1",
+		ClientSecret: "secret",
+	})}
+	resp, err := client.Get(resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %s", resp.Status)
+	}
+	if diff := cmp.Diff(oauthtest.Stats{Authorizations: 1, CodeExchanges: 1}, as.Stats()); diff != "" {
+		t.Errorf("auth server stats mismatch (-want +got):\n%s", diff)
+	}
+
+	// With the wrong secret, authorization fails.
+	client = &http.Client{Transport: NewOAuthTransport(nil, &OAuthConfig{
+		Authorize:    as.Authorize,
+		RedirectURL:  redirect,
+		ClientID:     "client1",
+		ClientSecret: "wrong",
+	})}
+	if _, err := client.Get(resource); err == nil || !strings.Contains(err.Error(), "invalid_client") {
+		t.Errorf("wrong secret: got %v, want invalid_client error", err)
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	for _, test := range []struct {
+		header string
+		want   map[string]string
+	}{
+		{"", nil},
+		{"Basic realm=\"x\"", nil},
+		{"Bearer", map[string]string{}},
+		{
+			`Bearer error="invalid_token", error_description="say \"hi\"", resource_metadata="https://example.com/.well-known/oauth-protected-resource"`,
+			map[string]string{
+				"error":             "invalid_token",
+				"error_description": `say "hi"`,
+				"resource_metadata": "https://example.com/.well-known/oauth-protected-resource",
+			},
+		},
+		{`bearer Scope="a b",error=insufficient_scope`, map[string]string{"scope": "a b", "error": "insufficient_scope"}},
+	} {
+		got := parseChallenge(test.header)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%q: mismatch (-want +got):\n%s", test.header, diff)
+		}
+	}
+
+	// parseChallenge understands the challenges we generate.
+	c := &challenge{err: "insufficient_scope", scope: "a b", resourceMetadata: "https://x"}
+	want := map[string]string{"error": "insufficient_scope", "scope": "a b", "resource_metadata": "https://x"}
+	if diff := cmp.Diff(want, parseChallenge(c.String())); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// This file implements verification of opaque tokens with OAuth token
+// introspection (RFC 7662).
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// IntrospectionResponse is the response of an OAuth token introspection
+// endpoint, as defined in [RFC 7662].
+//
+// [RFC 7662]: https://www.rfc-editor.org/rfc/rfc7662.html#section-2.2
+type IntrospectionResponse struct {
+	// Active reports whether the token is valid.
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"` // space-separated
+	ClientID  string   `json:"client_id,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"` // seconds since the Unix epoch
+	IssuedAt  int64    `json:"iat,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  []string `json:"aud,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	JWTID     string   `json:"jti,omitempty"`
+	// Extra holds the members of the response not represented by other fields.
+	Extra map[string]any `json:"-"`
+}
+
+// UnmarshalJSON handles the "aud" member, which may be a single string,
+// and collects unknown members in Extra.
+func (r *IntrospectionResponse) UnmarshalJSON(data []byte) error {
+	type response IntrospectionResponse // avoid recursion
+	var v struct {
+		response
+		Audience stringList `json:"aud,omitempty"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	var extra map[string]any
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return err
+	}
+	for _, name := range []string{"active", "scope", "client_id", "username", "token_type", "exp", "iat", "nbf", "sub", "aud", "iss", "jti"} {
+		delete(extra, name)
+	}
+	*r = IntrospectionResponse(v.response)
+	r.Audience = v.Audience
+	if len(extra) > 0 {
+		r.Extra = extra
+	}
+	return nil
+}
+
+// An IntrospectFunc asks an authorization server about a token.
+// See [IntrospectionEndpoint] for an implementation that uses RFC 7662.
+type IntrospectFunc func(ctx context.Context, token string) (*IntrospectionResponse, error)
+
+// IntrospectionVerifierOptions are options for [NewIntrospectionVerifier].
+type IntrospectionVerifierOptions struct {
+	// Issuer, if set, is the required value of the "iss" member of the
+	// introspection response.
+	Issuer string
+	// Audience is a value that the "aud" member of the introspection
+	// response must contain, typically the URL of the MCP server.
+	// It must not be empty.
+	Audience string
+	// Leeway is the allowed clock skew when checking the "exp" and "nbf" members.
+	Leeway time.Duration
+}
+
+// NewIntrospectionVerifier returns a [TokenVerifier] that verifies tokens by
+// calling introspect.
+// A token is valid if it is active, unexpired, has the right audience and,
+// if configured in opts, has the right issuer.
+//
+// NewIntrospectionVerifier panics if opts is nil or opts.Audience is empty.
+func NewIntrospectionVerifier(introspect IntrospectFunc, opts *IntrospectionVerifierOptions) TokenVerifier {
+	if opts == nil || opts.Audience == "" {
+		panic("auth.NewIntrospectionVerifier: an audience is required")
+	}
+	o := *opts
+	return func(ctx context.Context, token string) (*TokenInfo, error) {
+		r, err := introspect(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		if !r.Active {
+			return nil, fmt.Errorf("%w: token is not active", ErrInvalidToken)
+		}
+		var exp, nbf time.Time
+		if r.ExpiresAt != 0 {
+			exp = time.Unix(r.ExpiresAt, 0)
+		}
+		if r.NotBefore != 0 {
+			nbf = time.Unix(r.NotBefore, 0)
+		}
+		if err := checkClaims(r.Issuer, r.Audience, exp, nbf, o.Issuer, o.Audience, o.Leeway, time.Now()); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+		return &TokenInfo{
+			Subject:    r.Subject,
+			ClientID:   r.ClientID,
+			Scopes:     strings.Fields(r.Scope),
+			Expiration: exp,
+			Extra:      r.Extra,
+		}, nil
+	}
+}
+
+// IntrospectionEndpointOptions are options for [IntrospectionEndpoint].
+type IntrospectionEndpointOptions struct {
+	// ClientID and ClientSecret are the credentials with which the resource
+	// server authenticates to the introspection endpoint, using HTTP Basic
+	// authentication. If ClientID is empty, no credentials are sent.
+	ClientID     string
+	ClientSecret string
+	// HTTPClient is the client used to make requests.
+	// If nil, [http.DefaultClient] is used.
+	HTTPClient *http.Client
+}
+
+// IntrospectionEndpoint returns an [IntrospectFunc] that calls the RFC 7662
+// introspection endpoint at the given URL.
+func IntrospectionEndpoint(endpoint string, opts *IntrospectionEndpointOptions) IntrospectFunc {
+	if opts == nil {
+		opts = &IntrospectionEndpointOptions{}
+	}
+	o := *opts
+	if o.HTTPClient == nil {
+		o.HTTPClient = http.DefaultClient
+	}
+	return func(ctx context.Context, token string) (*IntrospectionResponse, error) {
+		form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		if o.ClientID != "" {
+			req.SetBasicAuth(url.QueryEscape(o.ClientID), url.QueryEscape(o.ClientSecret))
+		}
+		resp, err := o.HTTPClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("introspecting token: %w", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return nil, fmt.Errorf("introspecting token: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("introspecting token: %s: %s", resp.Status, body)
+		}
+		var r IntrospectionResponse
+		if err := json.Unmarshal(body, &r); err != nil {
+			return nil, fmt.Errorf("introspecting token: %w", err)
+		}
+		return &r, nil
+	}
+}
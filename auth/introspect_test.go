@@ -0,0 +1,84 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIntrospectionVerifier(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	responses := map[string]map[string]any{
+		"good": {
+			"active":    true,
+			"sub":       "alice",
+			"client_id": "client1",
+			"scope":     "read write",
+			"exp":       exp,
+			"aud":       "https://example.com/mcp",
+			"tenant":    "acme",
+		},
+		"revoked":      {"active": false},
+		"expired":      {"active": true, "exp": time.Now().Add(-time.Hour).Unix()},
+		"wrong aud":    {"active": true, "aud": []string{"https://other.example.com"}},
+		"wrong issuer": {"active": true, "aud": "https://example.com/mcp", "iss": "https://evil.example.com"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if user, pass, ok := req.BasicAuth(); !ok || user != "rs" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		resp, ok := responses[req.PostFormValue("token")]
+		if !ok {
+			resp = map[string]any{"active": false}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	verify := NewIntrospectionVerifier(
+		IntrospectionEndpoint(server.URL, &IntrospectionEndpointOptions{ClientID: "rs", ClientSecret: "secret"}),
+		&IntrospectionVerifierOptions{Audience: "https://example.com/mcp"})
+	ctx := context.Background()
+
+	info, err := verify(ctx, "good")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &TokenInfo{
+		Subject:    "alice",
+		ClientID:   "client1",
+		Scopes:     []string{"read", "write"},
+		Expiration: time.Unix(exp, 0),
+		Extra:      map[string]any{"tenant": "acme"},
+	}
+	if diff := cmp.Diff(want, info); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, token := range []string{"revoked", "expired", "wrong aud", "unknown"} {
+		if _, err := verify(ctx, token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: got %v, want ErrInvalidToken", token, err)
+		}
+	}
+	// The issuer isn't checked unless configured.
+	if _, err := verify(ctx, "wrong issuer"); err != nil {
+		t.Errorf("wrong issuer, unchecked: %v", err)
+	}
+
+	// Failures to reach the endpoint are not token errors.
+	bad := NewIntrospectionVerifier(IntrospectionEndpoint(server.URL, nil), &IntrospectionVerifierOptions{Audience: "https://example.com/mcp"})
+	if _, err := bad(ctx, "good"); err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Errorf("unauthenticated introspection: got %v, want non-token error", err)
+	}
+}
@@ -0,0 +1,423 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// This file implements verification of JWT access tokens (RFC 9068) with keys
+// from a JSON Web Key Set (RFC 7517).
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // for crypto.SHA256
+	_ "crypto/sha512" // for crypto.SHA384 and crypto.SHA512
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// A KeySet is a set of public keys for verifying JWT signatures.
+type KeySet struct {
+	keys []*jwk
+}
+
+// A jwk is a parsed JSON Web Key.
+type jwk struct {
+	kid string
+	alg string // if non-empty, the only algorithm the key may be used with
+	key crypto.PublicKey
+}
+
+// jsonWebKey is the JSON form of a public JSON Web Key (RFC 7517, RFC 7518
+// and RFC 8037).
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC and OKP
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseKeySet parses a JSON Web Key Set, as served at an authorization server's
+// jwks_uri.
+// It supports RSA, EC (P-256, P-384 and P-521) and Ed25519 keys.
+// Keys of other types, and keys whose "use" is not "sig", are ignored.
+func ParseKeySet(data []byte) (*KeySet, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parsing JWKS: %w", err)
+	}
+	ks := &KeySet{}
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("parsing JWKS: key %d (kid %q): %w", i, k.Kid, err)
+		}
+		if key == nil {
+			continue
+		}
+		ks.keys = append(ks.keys, &jwk{kid: k.Kid, alg: k.Alg, key: key})
+	}
+	if len(ks.keys) == 0 {
+		return nil, errors.New("parsing JWKS: no usable keys")
+	}
+	return ks, nil
+}
+
+// publicKey returns the key described by k, or nil if its type is not supported.
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("e: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("bad RSA exponent")
+		}
+		if n.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA key too small (%d bits)", n.BitLen())
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		var ecdhCurve ecdh.Curve
+		switch k.Crv {
+		case "P-256":
+			curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		x, err := decodeFixed(k.X, size)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeFixed(k.Y, size)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		// Check that the point is on the curve.
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeFixed(k.X, ed25519.PublicKeySize)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func decodeFixed(s string, size int) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != size {
+		return nil, fmt.Errorf("got %d bytes, want %d", len(b), size)
+	}
+	return b, nil
+}
+
+// JWTVerifierOptions are options for [NewJWTVerifier].
+type JWTVerifierOptions struct {
+	// Issuer is the required value of the "iss" claim: the issuer identifier
+	// of the authorization server.
+	Issuer string
+	// Audience is a value that the "aud" claim must contain, typically the
+	// URL of the MCP server. Checking the audience prevents tokens issued for
+	// other resources from being accepted, as the MCP spec requires.
+	// It must not be empty.
+	Audience string
+	// Leeway is the allowed clock skew when checking the "exp" and "nbf" claims.
+	Leeway time.Duration
+}
+
+// NewJWTVerifier returns a [TokenVerifier] for JWT access tokens signed by a
+// key in keys.
+//
+// The verifier checks the token's signature, its audience, its expiration
+// and not-before times, and, if configured in opts, its issuer.
+// The token must have an "exp" claim.
+// Scopes are read from the "scope" claim (a space-separated string, as in
+// RFC 9068) or the "scp" claim (a string or array of strings).
+//
+// NewJWTVerifier panics if opts is nil or opts.Audience is empty.
+func NewJWTVerifier(keys *KeySet, opts *JWTVerifierOptions) TokenVerifier {
+	if opts == nil || opts.Audience == "" {
+		panic("auth.NewJWTVerifier: an audience is required")
+	}
+	o := *opts
+	return func(_ context.Context, token string) (*TokenInfo, error) {
+		info, err := verifyJWT(keys, &o, token, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+		return info, nil
+	}
+}
+
+// A jwtHeader is the JOSE header of a JWT.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// jwtClaims are the claims of a JWT access token that the verifier uses.
+type jwtClaims struct {
+	Issuer    string     `json:"iss"`
+	Subject   string     `json:"sub"`
+	Audience  stringList `json:"aud"`
+	ExpiresAt *float64   `json:"exp"`
+	NotBefore *float64   `json:"nbf"`
+	Scope     string     `json:"scope"`
+	Scp       stringList `json:"scp"`
+	ClientID  string     `json:"client_id"`
+	Azp       string     `json:"azp"`
+}
+
+// verifyJWT verifies token at time now.
+func verifyJWT(keys *KeySet, opts *JWTVerifierOptions, token string, now time.Time) (*TokenInfo, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed JWT")
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("header: %v", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("signature: %v", err)
+	}
+	if err := keys.verify(&header, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	// The signature is good: decode the claims.
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("claims: %v", err)
+	}
+	var extra map[string]any
+	if err := decodeSegment(parts[1], &extra); err != nil {
+		return nil, fmt.Errorf("claims: %v", err)
+	}
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("missing exp claim")
+	}
+	exp := numericDate(*claims.ExpiresAt)
+	var nbf time.Time
+	if claims.NotBefore != nil {
+		nbf = numericDate(*claims.NotBefore)
+	}
+	if err := checkClaims(claims.Issuer, claims.Audience, exp, nbf, opts.Issuer, opts.Audience, opts.Leeway, now); err != nil {
+		return nil, err
+	}
+
+	info := &TokenInfo{
+		Subject:    claims.Subject,
+		ClientID:   claims.ClientID,
+		Expiration: exp,
+		Extra:      extra,
+	}
+	if info.ClientID == "" {
+		info.ClientID = claims.Azp
+	}
+	if claims.Scope != "" {
+		info.Scopes = strings.Fields(claims.Scope)
+	} else {
+		info.Scopes = claims.Scp
+	}
+	for _, name := range []string{"iss", "sub", "aud", "exp", "nbf", "scope", "scp", "client_id", "azp"} {
+		delete(extra, name)
+	}
+	return info, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// numericDate converts a JWT NumericDate to a time.
+func numericDate(secs float64) time.Time {
+	return time.Unix(0, int64(secs*float64(time.Second)))
+}
+
+// verify checks the signature sig of the signed data using the key identified
+// by header.
+func (ks *KeySet) verify(header *jwtHeader, signed, sig []byte) error {
+	var candidates []*jwk
+	for _, k := range ks.keys {
+		if header.Kid == "" || k.kid == header.Kid {
+			candidates = append(candidates, k)
+		}
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("no key with ID %q", header.Kid)
+	}
+	var err error
+	for _, k := range candidates {
+		if err = k.verify(header.Alg, signed, sig); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// verify checks the signature sig of the signed data using algorithm alg.
+// It returns an error if alg is not appropriate for the key.
+func (k *jwk) verify(alg string, signed, sig []byte) error {
+	if k.alg != "" && k.alg != alg {
+		return fmt.Errorf("key %q cannot be used with algorithm %q", k.kid, alg)
+	}
+	hashes := map[string]crypto.Hash{"256": crypto.SHA256, "384": crypto.SHA384, "512": crypto.SHA512}
+	hashFor := func(prefix string) (crypto.Hash, []byte, bool) {
+		h, ok := hashes[strings.TrimPrefix(alg, prefix)]
+		if !ok || !strings.HasPrefix(alg, prefix) {
+			return 0, nil, false
+		}
+		hh := h.New()
+		hh.Write(signed)
+		return h, hh.Sum(nil), true
+	}
+	badSig := errors.New("bad signature")
+
+	switch key := k.key.(type) {
+	case *rsa.PublicKey:
+		if h, digest, ok := hashFor("RS"); ok {
+			if rsa.VerifyPKCS1v15(key, h, digest, sig) != nil {
+				return badSig
+			}
+			return nil
+		}
+		if h, digest, ok := hashFor("PS"); ok {
+			if rsa.VerifyPSS(key, h, digest, sig, nil) != nil {
+				return badSig
+			}
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		want := map[int]string{32: "ES256", 48: "ES384", 66: "ES512"}[size]
+		if alg == want {
+			_, digest, _ := hashFor("ES")
+			if len(sig) != 2*size {
+				return badSig
+			}
+			r := new(big.Int).SetBytes(sig[:size])
+			s := new(big.Int).SetBytes(sig[size:])
+			if !ecdsa.Verify(key, digest, r, s) {
+				return badSig
+			}
+			return nil
+		}
+	case ed25519.PublicKey:
+		if alg == "EdDSA" {
+			if !ed25519.Verify(key, signed, sig) {
+				return badSig
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported algorithm %q for key %q", alg, k.kid)
+}
+
+// checkClaims checks the issuer, audience, expiration and not-before time
+// of a token at time now.
+// A zero exp or nbf is not checked. An empty wantIssuer or wantAudience
+// is not checked.
+func checkClaims(iss string, aud []string, exp, nbf time.Time, wantIssuer, wantAudience string, leeway time.Duration, now time.Time) error {
+	if wantIssuer != "" && iss != wantIssuer {
+		return fmt.Errorf("issuer %q, want %q", iss, wantIssuer)
+	}
+	if wantAudience != "" && !slices.Contains(aud, wantAudience) {
+		return fmt.Errorf("audience %q does not contain %q", aud, wantAudience)
+	}
+	if !exp.IsZero() && !now.Before(exp.Add(leeway)) {
+		return errors.New("token expired")
+	}
+	if !nbf.IsZero() && now.Add(leeway).Before(nbf) {
+		return errors.New("token not yet valid")
+	}
+	return nil
+}
+
+// A stringList is a list of strings that may be represented in JSON
+// as a single string, like the "aud" claim.
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = stringList{s}
+		return nil
+	}
+	var ss []string
+	if err := json.Unmarshal(data, &ss); err != nil {
+		return err
+	}
+	*l = ss
+	return nil
+}
@@ -0,0 +1,207 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modelcontextprotocol/go-sdk/internal/oauthtest"
+)
+
+func TestJWTVerifier(t *testing.T) {
+	issuer := oauthtest.NewIssuer("https://auth.example.com")
+	keys, err := ParseKeySet(issuer.JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify := NewJWTVerifier(keys, &JWTVerifierOptions{
+		Issuer:   issuer.URL,
+		Audience: "https://example.com/mcp",
+	})
+	now := time.Now()
+	exp := now.Add(time.Hour).Unix()
+
+	info, err := verify(context.Background(), issuer.Token(map[string]any{
+		"sub":       "alice",
+		"aud":       "https://example.com/mcp",
+		"scope":     "read write",
+		"client_id": "client1",
+		"exp":       exp,
+		"tenant":    "acme",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &TokenInfo{
+		Subject:    "alice",
+		ClientID:   "client1",
+		Scopes:     []string{"read", "write"},
+		Expiration: time.Unix(exp, 0),
+		Extra:      map[string]any{"tenant": "acme"},
+	}
+	if diff := cmp.Diff(want, info); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Audiences may be arrays, and scopes may be in "scp".
+	info, err = verify(context.Background(), issuer.Token(map[string]any{
+		"aud": []string{"other", "https://example.com/mcp"},
+		"scp": []string{"read"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"read"}, info.Scopes); diff != "" {
+		t.Errorf("scopes mismatch (-want +got):\n%s", diff)
+	}
+
+	// The authorized party stands in for a missing client_id.
+	info, err = verify(context.Background(), issuer.Token(map[string]any{
+		"aud": "https://example.com/mcp",
+		"azp": "client2",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ClientID != "client2" {
+		t.Errorf("got client ID %q, want %q", info.ClientID, "client2")
+	}
+	if _, ok := info.Extra["azp"]; ok {
+		t.Errorf("Extra has azp: %v", info.Extra)
+	}
+
+	good := issuer.Token(map[string]any{"aud": "https://example.com/mcp"})
+	parts := strings.Split(good, ".")
+	noneHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+
+	for _, test := range []struct {
+		name  string
+		token string
+		want  string // substring of error
+	}{
+		{"expired", issuer.Token(map[string]any{"aud": "https://example.com/mcp", "exp": now.Add(-time.Minute).Unix()}), "expired"},
+		{"not yet valid", issuer.Token(map[string]any{"aud": "https://example.com/mcp", "nbf": now.Add(time.Hour).Unix()}), "not yet valid"},
+		{"wrong issuer", issuer.Token(map[string]any{"aud": "https://example.com/mcp", "iss": "https://evil.example.com"}), "issuer"},
+		{"wrong audience", issuer.Token(map[string]any{"aud": "https://other.example.com"}), "audience"},
+		{"no exp", issuer.Token(map[string]any{"aud": "https://example.com/mcp", "exp": nil}), "missing exp"},
+		{"tampered", parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"aud":"https://example.com/mcp","exp":9999999999,"sub":"mallory"}`)) + "." + parts[2], "bad signature"},
+		{"alg none", noneHeader + "." + parts[1] + ".", "algorithm"},
+		{"other key", oauthtest.NewIssuer(issuer.URL).Token(map[string]any{"aud": "https://example.com/mcp"}), "bad signature"},
+		{"malformed", "not.a.jwt.at.all", "malformed"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := verify(context.Background(), test.token)
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("got %v, want ErrInvalidToken", err)
+			}
+			if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got %q, want it to contain %q", err, test.want)
+			}
+		})
+	}
+
+	// Leeway allows for clock skew.
+	lenient := NewJWTVerifier(keys, &JWTVerifierOptions{Audience: "https://example.com/mcp", Leeway: time.Minute})
+	if _, err := lenient(context.Background(), issuer.Token(map[string]any{"aud": "https://example.com/mcp", "exp": now.Add(-10 * time.Second).Unix()})); err != nil {
+		t.Errorf("recently expired token with leeway: %v", err)
+	}
+
+	// The audience must be checked.
+	defer func() {
+		if recover() == nil {
+			t.Error("NewJWTVerifier without an audience did not panic")
+		}
+	}()
+	NewJWTVerifier(keys, &JWTVerifierOptions{Issuer: issuer.URL})
+}
+
+func TestJWTVerifierKeyTypes(t *testing.T) {
+	enc := base64.RawURLEncoding.EncodeToString
+	sign := func(header map[string]any, signer func([]byte) []byte) string {
+		h, _ := json.Marshal(header)
+		c, _ := json.Marshal(map[string]any{"sub": "alice", "aud": "https://example.com/mcp", "exp": time.Now().Add(time.Hour).Unix()})
+		signed := enc(h) + "." + enc(c)
+		return signed + "." + enc(signer([]byte(signed)))
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, _ := json.Marshal(map[string]any{"keys": []any{
+		map[string]string{"kty": "RSA", "kid": "rsa", "n": enc(rsaKey.N.Bytes()), "e": enc(big.NewInt(int64(rsaKey.E)).Bytes())},
+		map[string]string{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": enc(edPub)},
+		map[string]string{"kty": "oct", "kid": "hmac", "k": enc([]byte("secret"))}, // ignored
+	}})
+	keys, err := ParseKeySet(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify := NewJWTVerifier(keys, &JWTVerifierOptions{Audience: "https://example.com/mcp"})
+
+	rs256 := func(b []byte) []byte {
+		h := sha256.Sum256(b)
+		sig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, h[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+	eddsa := func(b []byte) []byte { return ed25519.Sign(edPriv, b) }
+
+	for _, test := range []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"RS256", sign(map[string]any{"alg": "RS256", "kid": "rsa"}, rs256), false},
+		{"EdDSA", sign(map[string]any{"alg": "EdDSA", "kid": "ed"}, eddsa), false},
+		{"no kid", sign(map[string]any{"alg": "EdDSA"}, eddsa), false},
+		{"alg mismatch", sign(map[string]any{"alg": "EdDSA", "kid": "rsa"}, eddsa), true},
+		{"HS256", sign(map[string]any{"alg": "HS256", "kid": "hmac"}, eddsa), true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			info, err := verify(context.Background(), test.token)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("got error %v, want error: %t", err, test.wantErr)
+			}
+			if err == nil && info.Subject != "alice" {
+				t.Errorf("got subject %q, want %q", info.Subject, "alice")
+			}
+		})
+	}
+}
+
+func TestParseKeySetErrors(t *testing.T) {
+	for _, jwks := range []string{
+		`not json`,
+		`{"keys":[]}`,
+		`{"keys":[{"kty":"EC","crv":"P-256","x":"AAAA","y":"AAAA"}]}`,
+		`{"keys":[{"kty":"EC","crv":"P-256","x":"` + strings.Repeat("A", 43) + `","y":"` + strings.Repeat("A", 43) + `"}]}`, // not on curve
+		`{"keys":[{"kty":"RSA","n":"AQAB","e":"AQAB"}]}`,                                                                    // too small
+		`{"keys":[{"kty":"OKP","crv":"X25519","x":"AAAA"}]}`,
+	} {
+		if _, err := ParseKeySet([]byte(jwks)); err == nil {
+			t.Errorf("%s: got nil error", jwks)
+		}
+	}
+}
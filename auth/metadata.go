@@ -0,0 +1,88 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProtectedResourceMetadataPath is the well-known path prefix for protected
+// resource metadata, defined in RFC 9728.
+const ProtectedResourceMetadataPath = "/.well-known/oauth-protected-resource"
+
+// ProtectedResourceMetadata describes an OAuth protected resource, such as an
+// MCP server, as defined in [RFC 9728].
+//
+// [RFC 9728]: https://www.rfc-editor.org/rfc/rfc9728.html
+type ProtectedResourceMetadata struct {
+	// Resource is the resource identifier: the URL of the MCP server.
+	Resource string `json:"resource"`
+	// AuthorizationServers are the issuer identifiers of the authorization
+	// servers that can issue tokens for the resource.
+	AuthorizationServers []string `json:"authorization_servers,omitempty"`
+	// JWKSURI is the URL of the resource's JSON Web Key Set.
+	JWKSURI string `json:"jwks_uri,omitempty"`
+	// ScopesSupported are the scopes used to access the resource.
+	ScopesSupported []string `json:"scopes_supported,omitempty"`
+	// BearerMethodsSupported are the ways a bearer token can be sent to
+	// the resource. MCP servers only support "header".
+	BearerMethodsSupported []string `json:"bearer_methods_supported,omitempty"`
+	// ResourceName is a human-readable name for the resource.
+	ResourceName string `json:"resource_name,omitempty"`
+	// ResourceDocumentation is the URL of documentation for the resource.
+	ResourceDocumentation string `json:"resource_documentation,omitempty"`
+}
+
+// ProtectedResourceMetadataURL returns the URL at which the metadata for the
+// given resource is served, according to RFC 9728, section 3.1.
+// For example, the metadata for https://example.com/mcp is at
+// https://example.com/.well-known/oauth-protected-resource/mcp.
+func ProtectedResourceMetadataURL(resource string) (string, error) {
+	u, err := url.Parse(resource)
+	if err != nil {
+		return "", err
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return "", fmt.Errorf("resource %q is not an absolute URL", resource)
+	}
+	u.Path = ProtectedResourceMetadataPath + strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String(), nil
+}
+
+// ProtectedResourceMetadataHandler returns an [http.Handler] that serves md as
+// JSON. Serve it at the URL returned by [ProtectedResourceMetadataURL].
+//
+// The handler allows cross-origin requests, so that browser-based clients can
+// discover the metadata.
+func ProtectedResourceMetadataHandler(md *ProtectedResourceMetadata) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		switch req.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodOptions:
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		default:
+			w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+			http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+			return
+		}
+		data, err := json.Marshal(md)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+}
@@ -0,0 +1,173 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// The jsonschemagen command generates Go types from JSON schemas.
+//
+// Usage:
+//
+//	jsonschemagen [flags] SCHEMA.json
+//	jsonschemagen [flags] -mcp COMMAND [ARG...]
+//
+// In the first form, jsonschemagen reads a JSON schema from a file and writes
+// a Go type for it, along with the types it needs.
+//
+// In the second form, jsonschemagen runs an MCP server with the given command,
+// communicating with it over stdin and stdout, and lists its tools. For each
+// tool, it writes types for the tool's input and output schemas, and a function
+// that calls the tool with those types.
+//
+// See the [codegen] package for how schemas are translated into Go.
+//
+// The flags are:
+//
+//	-o FILE
+//	    Write the output to FILE instead of standard output.
+//	-pkg NAME
+//	    Use NAME as the package name (default "main").
+//	-type NAME
+//	    Use NAME as the name of the type for SCHEMA.json. The default is
+//	    the schema's title if it is a single word, or else the file name.
+//	-mcp
+//	    Generate code for the tools of an MCP server.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/jsonschema/codegen"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var (
+	output   = flag.String("o", "", "output file (default standard output)")
+	pkgName  = flag.String("pkg", "main", "package name")
+	typeName = flag.String("type", "", "name of the type for the schema file")
+	useMCP   = flag.Bool("mcp", false, "generate code for the tools of the MCP server run by the arguments")
+)
+
+const header = "Code generated by jsonschemagen. DO NOT EDIT."
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("jsonschemagen: ")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: jsonschemagen [flags] SCHEMA.json\n")
+		fmt.Fprintf(os.Stderr, "       jsonschemagen [flags] -mcp COMMAND [ARG...]\n")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	flag.Parse()
+
+	g := codegen.NewGenerator(&codegen.Options{PackageName: *pkgName, Header: header})
+	var err error
+	switch {
+	case *useMCP && flag.NArg() > 0:
+		err = addTools(context.Background(), g, exec.Command(flag.Arg(0), flag.Args()[1:]...))
+	case !*useMCP && flag.NArg() == 1:
+		err = addSchemaFile(g, flag.Arg(0), *typeName)
+	default:
+		flag.Usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := g.Source()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = os.WriteFile(*output, src, 0o644)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// addSchemaFile adds a type for the schema in the given file.
+func addSchemaFile(g *codegen.Generator, filename, name string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var s jsonschema.Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	if name == "" && !strings.ContainsAny(s.Title, " \t\n") {
+		// A title that is a single word is a good name.
+		name = s.Title
+	}
+	if codegen.GoName(name) == "" {
+		name = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+	if _, err := g.Add(name, &s); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	return nil
+}
+
+// addTools adds types and functions for calling the tools of the MCP server
+// run by cmd.
+func addTools(ctx context.Context, g *codegen.Generator, cmd *exec.Cmd) error {
+	client := mcp.NewClient(&mcp.Implementation{Name: "jsonschemagen"}, nil)
+	cs, err := client.Connect(ctx, mcp.NewCommandTransport(cmd))
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", cmd.Path, err)
+	}
+	defer cs.Close()
+	for tool, err := range cs.Tools(ctx, nil) {
+		if err != nil {
+			return fmt.Errorf("listing tools: %w", err)
+		}
+		if err := addTool(g, tool); err != nil {
+			return fmt.Errorf("tool %q: %w", tool.Name, err)
+		}
+	}
+	return nil
+}
+
+// addTool adds the types for tool's schemas, and a function that calls it.
+func addTool(g *codegen.Generator, tool *mcp.Tool) error {
+	name := codegen.GoName(tool.Name)
+	if name == "" {
+		return errors.New("cannot derive a Go name")
+	}
+	in, err := g.Add(name+"Args", tool.InputSchema)
+	if err != nil {
+		return fmt.Errorf("input schema: %w", err)
+	}
+	out := "any"
+	if tool.OutputSchema != nil {
+		out, err = g.Add(name+"Result", tool.OutputSchema)
+		if err != nil {
+			return fmt.Errorf("output schema: %w", err)
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Call%s calls the %q tool.\n", name, tool.Name)
+	if tool.Description != "" {
+		b.WriteString("//\n")
+		for _, line := range strings.Split(strings.TrimSpace(tool.Description), "\n") {
+			b.WriteString(strings.TrimRight("// "+line, " \t\r") + "\n")
+		}
+	}
+	fmt.Fprintf(&b, `func Call%[1]s(ctx context.Context, cs *mcp.ClientSession, args %[2]s) (*mcp.CallToolResultFor[%[3]s], error) {
+	return mcp.CallToolFor[%[2]s, %[3]s](ctx, cs, &mcp.CallToolParamsFor[%[2]s]{Name: %[4]q, Arguments: args})
+}
+`, name, in, out, tool.Name)
+	g.AddDecl(b.String(), "context", "github.com/modelcontextprotocol/go-sdk/mcp")
+	return nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/jsonschema/codegen"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestAddTool(t *testing.T) {
+	g := codegen.NewGenerator(&codegen.Options{PackageName: "weather"})
+	tool := &mcp.Tool{
+		Name:        "get_forecast",
+		Description: "Get the forecast for a city.",
+		InputSchema: &jsonschema.Schema{
+			Type:       "object",
+			Properties: map[string]*jsonschema.Schema{"city": {Type: "string"}},
+			Required:   []string{"city"},
+		},
+		OutputSchema: &jsonschema.Schema{
+			Type:       "object",
+			Properties: map[string]*jsonschema.Schema{"high": {Type: "number"}},
+		},
+	}
+	if err := addTool(g, tool); err != nil {
+		t.Fatal(err)
+	}
+	src, err := g.Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"type GetForecastArgs struct",
+		"type GetForecastResult struct",
+		"// CallGetForecast calls the \"get_forecast\" tool.\n//\n// Get the forecast for a city.\n",
+		"func CallGetForecast(ctx context.Context, cs *mcp.ClientSession, args GetForecastArgs) (*mcp.CallToolResultFor[GetForecastResult], error) {",
+		`&mcp.CallToolParamsFor[GetForecastArgs]{Name: "get_forecast", Arguments: args}`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, src)
+		}
+	}
+}
@@ -29,6 +29,12 @@ func NetListener(ctx context.Context, network, address string, options NetListen
 	return &netListener{net: ln}, nil
 }
 
+// NewNetListener returns a Listener that accepts connections from ln.
+// Closing the Listener closes ln.
+func NewNetListener(ln net.Listener) Listener {
+	return &netListener{net: ln}
+}
+
 // netListener is the implementation of Listener for connections made using the net package.
 type netListener struct {
 	net net.Listener
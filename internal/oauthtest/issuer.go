@@ -0,0 +1,88 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package oauthtest provides a local OAuth token issuer for tests.
+package oauthtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// An Issuer issues JWT access tokens signed with an ES256 key.
+type Issuer struct {
+	// URL is the issuer identifier, used as the "iss" claim.
+	URL string
+	// KeyID identifies the signing key.
+	KeyID string
+
+	key *ecdsa.PrivateKey
+}
+
+// NewIssuer returns an Issuer with the given issuer identifier and a new
+// signing key.
+func NewIssuer(url string) *Issuer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return &Issuer{URL: url, KeyID: "test-key", key: key}
+}
+
+// JWKS returns the JSON Web Key Set containing the issuer's public key.
+func (is *Issuer) JWKS() []byte {
+	enc := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	key := map[string]string{
+		"kty": "EC",
+		"crv": "P-256",
+		"kid": is.KeyID,
+		"use": "sig",
+		"alg": "ES256",
+		"x":   enc(is.key.X.FillBytes(make([]byte, 32))),
+		"y":   enc(is.key.Y.FillBytes(make([]byte, 32))),
+	}
+	data, err := json.Marshal(map[string]any{"keys": []any{key}})
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// Token returns a signed JWT with the given claims.
+// Unless they are present in claims, it adds an "iss" claim with the
+// issuer's URL, and an "exp" claim for an hour from now.
+func (is *Issuer) Token(claims map[string]any) string {
+	all := map[string]any{
+		"iss": is.URL,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range claims {
+		all[k] = v
+	}
+	return is.sign(map[string]any{"alg": "ES256", "typ": "at+jwt", "kid": is.KeyID}, all)
+}
+
+func (is *Issuer) sign(header, claims map[string]any) string {
+	seg := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			panic(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := seg(header) + "." + seg(claims)
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, is.key, digest[:])
+	if err != nil {
+		panic(fmt.Sprintf("signing token: %v", err))
+	}
+	sig := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
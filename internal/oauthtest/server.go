@@ -0,0 +1,305 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package oauthtest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// An AuthServer is an in-process OAuth authorization server that supports
+// metadata discovery, dynamic client registration, and the authorization code
+// flow with PKCE and refresh tokens. It issues JWT access tokens signed by its
+// [Issuer].
+//
+// Authorization requests are approved without user interaction: see
+// [AuthServer.Authorize].
+type AuthServer struct {
+	*Issuer
+	// TokenLifetime is the lifetime of the access tokens the server issues.
+	TokenLifetime time.Duration
+	// Subject is the "sub" claim of the tokens the server issues.
+	Subject string
+
+	server *httptest.Server
+
+	mu       sync.Mutex
+	clients  map[string]*client // keyed by client ID
+	codes    map[string]*grant  // keyed by authorization code
+	refreshs map[string]*grant  // keyed by refresh token
+	stats    Stats
+}
+
+// Stats counts the requests an [AuthServer] has handled successfully.
+type Stats struct {
+	Registrations  int
+	Authorizations int // authorization requests approved
+	CodeExchanges  int
+	Refreshes      int
+}
+
+type client struct {
+	secret       string
+	redirectURIs []string
+}
+
+// A grant is what the user approved.
+type grant struct {
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	scope         string
+	resource      string
+}
+
+// NewAuthServer starts an AuthServer. Call Close when done.
+func NewAuthServer() *AuthServer {
+	s := &AuthServer{
+		TokenLifetime: time.Hour,
+		Subject:       "user",
+		clients:       make(map[string]*client),
+		codes:         make(map[string]*grant),
+		refreshs:      make(map[string]*grant),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/oauth-authorization-server", s.handleMetadata)
+	mux.HandleFunc("/register", s.handleRegister)
+	mux.HandleFunc("/authorize", s.handleAuthorize)
+	mux.HandleFunc("/token", s.handleToken)
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(s.JWKS())
+	})
+	s.server = httptest.NewServer(mux)
+	s.Issuer = NewIssuer(s.server.URL)
+	return s
+}
+
+// Close shuts down the server.
+func (s *AuthServer) Close() { s.server.Close() }
+
+// Stats returns the server's request counts.
+func (s *AuthServer) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// RegisterClient registers a confidential client, as if it had been
+// registered in advance.
+func (s *AuthServer) RegisterClient(id, secret, redirectURI string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[id] = &client{secret: secret, redirectURIs: []string{redirectURI}}
+}
+
+// RevokeRefreshTokens makes all outstanding refresh tokens invalid.
+func (s *AuthServer) RevokeRefreshTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.refreshs)
+}
+
+// Authorize is an auth.AuthorizeFunc that plays the role of a user who
+// approves every authorization request. It sends the request to the server,
+// and returns the query parameters of the redirect.
+func (s *AuthServer) Authorize(ctx context.Context, authURL string) (url.Values, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, authURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := noRedirect.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		return nil, fmt.Errorf("authorization request: %s", resp.Status)
+	}
+	loc, err := resp.Location()
+	if err != nil {
+		return nil, err
+	}
+	return loc.Query(), nil
+}
+
+func (s *AuthServer) handleMetadata(w http.ResponseWriter, _ *http.Request) {
+	u := s.server.URL
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                u,
+		"authorization_endpoint":                u + "/authorize",
+		"token_endpoint":                        u + "/token",
+		"registration_endpoint":                 u + "/register",
+		"jwks_uri":                              u + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"none", "client_secret_basic"},
+	})
+}
+
+func (s *AuthServer) handleRegister(w http.ResponseWriter, req *http.Request) {
+	var r struct {
+		RedirectURIs []string `json:"redirect_uris"`
+		AuthMethod   string   `json:"token_endpoint_auth_method"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&r); err != nil || len(r.RedirectURIs) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid_client_metadata")
+		return
+	}
+	c := &client{redirectURIs: r.RedirectURIs}
+	if r.AuthMethod != "none" {
+		c.secret = randText()
+	}
+	id := randText()
+	s.mu.Lock()
+	s.clients[id] = c
+	s.stats.Registrations++
+	s.mu.Unlock()
+	resp := map[string]any{"client_id": id, "redirect_uris": r.RedirectURIs}
+	if c.secret != "" {
+		resp["client_secret"] = c.secret
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// handleAuthorize approves the request if it is valid, redirecting with a code.
+func (s *AuthServer) handleAuthorize(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.clients[q.Get("client_id")]
+	redirectURI := q.Get("redirect_uri")
+	if c == nil || !slices.Contains(c.redirectURIs, redirectURI) {
+		// Per RFC 6749, don't redirect to an unverified URI.
+		http.Error(w, "invalid client or redirect URI", http.StatusBadRequest)
+		return
+	}
+	redirect := func(params url.Values) {
+		params.Set("state", q.Get("state"))
+		u, _ := url.Parse(redirectURI)
+		u.RawQuery = params.Encode()
+		http.Redirect(w, req, u.String(), http.StatusFound)
+	}
+	if q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		redirect(url.Values{"error": {"invalid_request"}})
+		return
+	}
+	code := randText()
+	s.codes[code] = &grant{
+		clientID:      q.Get("client_id"),
+		redirectURI:   redirectURI,
+		codeChallenge: q.Get("code_challenge"),
+		scope:         q.Get("scope"),
+		resource:      q.Get("resource"),
+	}
+	s.stats.Authorizations++
+	redirect(url.Values{"code": {code}})
+}
+
+func (s *AuthServer) handleToken(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	clientID, secret, hasBasic := req.BasicAuth()
+	if hasBasic {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID = req.PostForm.Get("client_id")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.clients[clientID]
+	if c == nil || c.secret != secret {
+		writeError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	var g *grant
+	switch req.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code := req.PostForm.Get("code")
+		g = s.codes[code]
+		delete(s.codes, code) // codes are single-use
+		if g == nil || g.clientID != clientID || g.redirectURI != req.PostForm.Get("redirect_uri") {
+			writeError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		sum := sha256.Sum256([]byte(req.PostForm.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != g.codeChallenge {
+			writeError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		s.stats.CodeExchanges++
+	case "refresh_token":
+		rt := req.PostForm.Get("refresh_token")
+		g = s.refreshs[rt]
+		delete(s.refreshs, rt) // refresh tokens are rotated
+		if g == nil || g.clientID != clientID {
+			writeError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		s.stats.Refreshes++
+	default:
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	refresh := randText()
+	s.refreshs[refresh] = g
+	claims := map[string]any{
+		"sub":       s.Subject,
+		"client_id": clientID,
+		"exp":       time.Now().Add(s.TokenLifetime).Unix(),
+	}
+	if g.scope != "" {
+		claims["scope"] = g.scope
+	}
+	if g.resource != "" {
+		claims["aud"] = g.resource
+	}
+	resp := map[string]any{
+		"access_token":  s.Token(claims),
+		"token_type":    "Bearer",
+		"expires_in":    int(s.TokenLifetime.Seconds()),
+		"refresh_token": refresh,
+	}
+	if g.scope != "" {
+		resp["scope"] = g.scope
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+func randText() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
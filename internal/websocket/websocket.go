@@ -0,0 +1,615 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package websocket implements the parts of the WebSocket protocol
+// (RFC 6455) that are needed to carry MCP messages: the opening handshake
+// for clients and servers, unfragmented and fragmented data messages,
+// pings and pongs, and the closing handshake.
+// Extensions, such as compression, are not supported.
+package websocket
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// Status codes for closing a connection.
+// See https://www.rfc-editor.org/rfc/rfc6455#section-7.4.1.
+const (
+	StatusNormalClosure   = 1000
+	StatusGoingAway       = 1001
+	StatusProtocolError   = 1002
+	StatusUnsupportedData = 1003
+	StatusNoStatus        = 1005 // never sent: no status code was received
+	StatusInvalidPayload  = 1007
+	StatusPolicyViolation = 1008
+	StatusMessageTooBig   = 1009
+	StatusInternalError   = 1011
+)
+
+// A MessageType is the type of a data message.
+type MessageType int
+
+const (
+	TextMessage   MessageType = opText
+	BinaryMessage MessageType = opBinary
+)
+
+// Frame opcodes.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// A CloseError is returned by [Conn.ReadMessage] when the peer closes the
+// connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket closed with status %d", e.Code)
+	}
+	return fmt.Sprintf("websocket closed with status %d: %s", e.Code, e.Reason)
+}
+
+// ErrClosed is returned when writing to a connection after a close frame
+// was sent, and when reading from a connection that was closed locally.
+var ErrClosed = errors.New("websocket: connection closed")
+
+// errKeepAlive is the error that ends a connection whose peer does not
+// respond to pings.
+var errKeepAlive = errors.New("websocket: no response to ping")
+
+// closeTimeout is how long Close waits for the peer to acknowledge the close.
+var closeTimeout = time.Second
+
+// A Conn is a WebSocket connection.
+//
+// One goroutine may read from a Conn while others write to it.
+type Conn struct {
+	rwc      io.ReadWriteCloser
+	br       *bufio.Reader
+	client   bool // if true, frames are sent masked and received unmasked
+	protocol string
+
+	readLimit int64 // maximum message size; no limit if zero
+	pongs     atomic.Int64
+
+	writeMu   sync.Mutex
+	closeSent bool // guarded by writeMu
+
+	closeReceived     chan struct{} // closed when a close frame was received
+	closeReceivedOnce sync.Once
+
+	done      chan struct{} // closed when rwc is closed
+	closeOnce sync.Once
+	closeErr  error // the reason the connection was closed, if not a close frame; set before done is closed
+}
+
+func newConn(rwc io.ReadWriteCloser, br *bufio.Reader, client bool, protocol string) *Conn {
+	return &Conn{
+		rwc:           rwc,
+		br:            br,
+		client:        client,
+		protocol:      protocol,
+		closeReceived: make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Subprotocol returns the subprotocol negotiated in the opening handshake,
+// or the empty string if there is none.
+func (c *Conn) Subprotocol() string { return c.protocol }
+
+// SetReadLimit sets the maximum size of a message that can be read.
+// [Conn.ReadMessage] fails on a larger message, and closes the connection
+// with [StatusMessageTooBig]. If n is zero, there is no limit.
+func (c *Conn) SetReadLimit(n int64) { c.readLimit = n }
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// acceptKey computes the value of the Sec-WebSocket-Accept header for the
+// given Sec-WebSocket-Key.
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// hasToken reports whether the comma-separated header contains token,
+// ignoring case.
+func hasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsUpgradeRequest reports whether r asks to upgrade to a WebSocket.
+func IsUpgradeRequest(r *http.Request) bool {
+	return r.Method == http.MethodGet &&
+		hasToken(r.Header, "Connection", "upgrade") &&
+		hasToken(r.Header, "Upgrade", "websocket")
+}
+
+// Upgrade performs the server side of the opening handshake, and returns the
+// connection.
+// The subprotocol is the first of the client's that appears in protocols.
+// If protocols is non-empty and the client offers none of them, the
+// handshake fails.
+// If the handshake fails, Upgrade writes an HTTP error response and returns
+// an error.
+func Upgrade(w http.ResponseWriter, r *http.Request, protocols []string) (*Conn, error) {
+	fail := func(code int, msg string) (*Conn, error) {
+		http.Error(w, msg, code)
+		return nil, fmt.Errorf("websocket: %s", msg)
+	}
+	if !IsUpgradeRequest(r) {
+		return fail(http.StatusBadRequest, "not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return fail(http.StatusUpgradeRequired, "unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if k, err := base64.StdEncoding.DecodeString(key); err != nil || len(k) != 16 {
+		return fail(http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+	var protocol string
+	if len(protocols) > 0 {
+		for _, v := range r.Header.Values("Sec-WebSocket-Protocol") {
+			for _, p := range strings.Split(v, ",") {
+				if p = strings.TrimSpace(p); protocol == "" && slices.Contains(protocols, p) {
+					protocol = p
+				}
+			}
+		}
+		if protocol == "" {
+			return fail(http.StatusBadRequest, fmt.Sprintf("subprotocol must be one of %s", strings.Join(protocols, ", ")))
+		}
+	}
+
+	netConn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return fail(http.StatusInternalServerError, fmt.Sprintf("cannot hijack connection: %v", err))
+	}
+	// Clear any deadlines set by the http.Server: the connection is long-lived.
+	netConn.SetDeadline(time.Time{})
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n"
+	if protocol != "" {
+		resp += "Sec-WebSocket-Protocol: " + protocol + "\r\n"
+	}
+	resp += "\r\n"
+	if _, err := brw.WriteString(resp); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if err := brw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return newConn(netConn, brw.Reader, false, protocol), nil
+}
+
+// Dial performs the client side of the opening handshake with the WebSocket
+// server at rawURL, whose scheme may be "ws", "wss", "http" or "https", and
+// returns the connection. It makes the request with client, which must not
+// have a timeout that would end the connection.
+// The header, if non-nil, holds additional request headers.
+// If protocols is non-empty, the server must choose one of them.
+func Dial(ctx context.Context, client *http.Client, rawURL string, header http.Header, protocols []string) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("websocket: unsupported scheme %q", u.Scheme)
+	}
+	var nonce [16]byte
+	rand.Read(nonce[:])
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if header != nil {
+		req.Header = header.Clone()
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if len(protocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(protocols, ", "))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("websocket: handshake failed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, errors.New("websocket: response body is not writable")
+	}
+	fail := func(msg string) (*Conn, error) {
+		rwc.Close()
+		return nil, fmt.Errorf("websocket: handshake failed: %s", msg)
+	}
+	if !hasToken(resp.Header, "Upgrade", "websocket") || !hasToken(resp.Header, "Connection", "upgrade") {
+		return fail("missing Upgrade or Connection header")
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return fail("invalid Sec-WebSocket-Accept")
+	}
+	protocol := resp.Header.Get("Sec-WebSocket-Protocol")
+	if protocol != "" && !slices.Contains(protocols, protocol) {
+		return fail(fmt.Sprintf("server chose unrequested subprotocol %q", protocol))
+	}
+	if len(protocols) > 0 && protocol == "" {
+		return fail(fmt.Sprintf("server chose none of the subprotocols %s", strings.Join(protocols, ", ")))
+	}
+	return newConn(rwc, bufio.NewReader(rwc), true, protocol), nil
+}
+
+// A protocolError is a violation of the protocol by the peer. The connection
+// is closed with its code.
+type protocolError struct {
+	code int
+	msg  string
+}
+
+func (e *protocolError) Error() string { return "websocket: " + e.msg }
+
+// ReadMessage reads the next data message. It replies to pings while
+// waiting for one.
+//
+// If the peer closes the connection, ReadMessage acknowledges the close
+// and returns a [*CloseError]. If the peer violates the protocol,
+// ReadMessage closes the connection with the appropriate status code.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	typ, data, err := c.readMessage()
+	if err != nil {
+		var (
+			perr *protocolError
+			cerr *CloseError
+		)
+		switch {
+		case errors.As(err, &perr):
+			// There is no point in waiting for a reply from a broken peer.
+			c.Abort(perr.code, perr.msg)
+		case errors.As(err, &cerr):
+		default:
+			select {
+			case <-c.done:
+				// The read failed because the connection was closed.
+				err = cmp.Or(c.closeErr, ErrClosed)
+			default:
+			}
+		}
+	}
+	return typ, data, err
+}
+
+func (c *Conn) readMessage() (MessageType, []byte, error) {
+	var (
+		typ MessageType
+		buf []byte
+	)
+	for {
+		limit := int64(-1)
+		if c.readLimit > 0 {
+			limit = c.readLimit - int64(len(buf))
+		}
+		fin, op, payload, err := c.readFrame(limit)
+		if err != nil {
+			return 0, nil, err
+		}
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil && err != ErrClosed {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			c.pongs.Add(1)
+			continue
+		case opClose:
+			return 0, nil, c.receivedClose(payload)
+		case opText, opBinary:
+			if typ != 0 {
+				return 0, nil, &protocolError{StatusProtocolError, "new message before the end of a fragmented one"}
+			}
+			typ = MessageType(op)
+			buf = payload
+		case opContinuation:
+			if typ == 0 {
+				return 0, nil, &protocolError{StatusProtocolError, "continuation frame without a message"}
+			}
+			buf = append(buf, payload...)
+		default:
+			return 0, nil, &protocolError{StatusProtocolError, fmt.Sprintf("unknown opcode %d", op)}
+		}
+		if fin {
+			if typ == TextMessage && !utf8.Valid(buf) {
+				return 0, nil, &protocolError{StatusInvalidPayload, "text message is not valid UTF-8"}
+			}
+			return typ, buf, nil
+		}
+	}
+}
+
+// receivedClose handles the payload of a close frame, replying to it if we
+// have not already sent one. It returns the resulting error.
+func (c *Conn) receivedClose(payload []byte) error {
+	c.closeReceivedOnce.Do(func() { close(c.closeReceived) })
+	cerr := &CloseError{Code: StatusNoStatus}
+	switch {
+	case len(payload) == 1:
+		return &protocolError{StatusProtocolError, "invalid close frame"}
+	case len(payload) >= 2:
+		cerr.Code = int(binary.BigEndian.Uint16(payload))
+		cerr.Reason = string(payload[2:])
+		if !validCloseCode(cerr.Code) || !utf8.ValidString(cerr.Reason) {
+			return &protocolError{StatusProtocolError, "invalid close frame"}
+		}
+	}
+	// Echo the status code, as recommended by RFC 6455 section 5.5.1.
+	code := cerr.Code
+	if code == StatusNoStatus {
+		code = StatusNormalClosure
+	}
+	c.writeClose(code, "")
+	if !c.client {
+		// The server closes the TCP connection first.
+		c.closeConn(nil)
+	}
+	return cerr
+}
+
+// validCloseCode reports whether code may appear in a close frame.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// readFrame reads a frame whose payload is at most limit bytes, if limit
+// is non-negative.
+func (c *Conn) readFrame(limit int64) (fin bool, op byte, payload []byte, err error) {
+	var h [8]byte
+	if _, err := io.ReadFull(c.br, h[:2]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = h[0]&0x80 != 0
+	if h[0]&0x70 != 0 {
+		return false, 0, nil, &protocolError{StatusProtocolError, "reserved bits set"}
+	}
+	op = h[0] & 0x0f
+	masked := h[1]&0x80 != 0
+	if masked == c.client {
+		return false, 0, nil, &protocolError{StatusProtocolError, "wrong masking"}
+	}
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		if _, err := io.ReadFull(c.br, h[:2]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(h[:2]))
+	case 127:
+		if _, err := io.ReadFull(c.br, h[:8]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(h[:8])
+		if n>>63 != 0 {
+			return false, 0, nil, &protocolError{StatusProtocolError, "invalid payload length"}
+		}
+	}
+	if op >= opClose && (!fin || n > 125) {
+		return false, 0, nil, &protocolError{StatusProtocolError, "invalid control frame"}
+	}
+	if limit >= 0 && n > uint64(limit) {
+		return false, 0, nil, &protocolError{StatusMessageTooBig, "message too big"}
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		maskBytes(mask, payload)
+	}
+	return fin, op, payload, nil
+}
+
+func maskBytes(mask [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= mask[i%4]
+	}
+}
+
+// WriteMessage writes a data message in a single frame.
+func (c *Conn) WriteMessage(typ MessageType, data []byte) error {
+	return c.writeFrame(byte(typ), data)
+}
+
+// Ping sends a ping.
+func (c *Conn) Ping() error {
+	return c.writeFrame(opPing, nil)
+}
+
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return ErrClosed
+	}
+	if op == opClose {
+		c.closeSent = true
+	}
+	return c.writeFrameLocked(op, payload)
+}
+
+func (c *Conn) writeFrameLocked(op byte, payload []byte) error {
+	buf := make([]byte, 0, 14+len(payload))
+	buf = append(buf, 0x80|op) // FIN
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, maskBit|byte(n))
+	case n <= 0xffff:
+		buf = append(buf, maskBit|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, maskBit|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+	start := len(buf)
+	var mask [4]byte
+	if c.client {
+		rand.Read(mask[:])
+		buf = append(buf, mask[:]...)
+		start += 4
+	}
+	buf = append(buf, payload...)
+	if c.client {
+		maskBytes(mask, buf[start:])
+	}
+	_, err := c.rwc.Write(buf)
+	return err
+}
+
+// writeClose sends a close frame, unless one was already sent.
+func (c *Conn) writeClose(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	// Control frames are limited to 125 bytes.
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	payload = append(payload, reason...)
+	return c.writeFrame(opClose, payload)
+}
+
+// Close performs the closing handshake with the given status code and
+// reason, and closes the underlying connection. It waits briefly for the
+// peer to acknowledge the close, which requires that another goroutine be
+// calling ReadMessage.
+// Close may be called more than once; calls after the first have no effect.
+func (c *Conn) Close(code int, reason string) error {
+	switch err := c.writeClose(code, reason); err {
+	case nil:
+		select {
+		case <-c.closeReceived:
+		case <-c.done:
+		case <-time.After(closeTimeout):
+		}
+	case ErrClosed:
+		// A close frame was already sent, either by another call to Close,
+		// which will close the connection, or in reply to the peer's.
+		select {
+		case <-c.closeReceived:
+		case <-c.done:
+		}
+	}
+	return c.closeConn(nil)
+}
+
+// Abort sends a close frame with the given status code and reason, and
+// closes the underlying connection without waiting for the peer to
+// acknowledge the close. Unlike Close, it may be called by the goroutine
+// that reads from the connection.
+func (c *Conn) Abort(code int, reason string) error {
+	c.writeClose(code, reason)
+	return c.closeConn(nil)
+}
+
+// closeConn closes the underlying connection, recording err as the reason.
+func (c *Conn) closeConn(err error) error {
+	var cerr error
+	c.closeOnce.Do(func() {
+		c.closeErr = err
+		close(c.done)
+		cerr = c.rwc.Close()
+	})
+	return cerr
+}
+
+// KeepAlive sends a ping every interval until the connection is closed.
+// If the peer has not replied to a ping by the time of the next one,
+// KeepAlive closes the connection, and pending and future reads fail.
+func (c *Conn) KeepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	pongs := c.pongs.Load()
+	waiting := false
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if waiting && c.pongs.Load() == pongs {
+				c.closeConn(errKeepAlive)
+				return
+			}
+			pongs = c.pongs.Load()
+			if err := c.Ping(); err != nil {
+				return
+			}
+			waiting = true
+		}
+	}
+}
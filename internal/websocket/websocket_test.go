@@ -0,0 +1,197 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoServer starts a server that echoes the messages it receives, and
+// returns its URL. The errors from the server's reads are sent on errc.
+func echoServer(t *testing.T, protocols []string, errc chan<- error) string {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r, protocols)
+		if err != nil {
+			return
+		}
+		c.SetReadLimit(1 << 10)
+		for {
+			typ, data, err := c.ReadMessage()
+			if err != nil {
+				if errc != nil {
+					errc <- err
+				}
+				return
+			}
+			if err := c.WriteMessage(typ, data); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+func TestEcho(t *testing.T) {
+	errc := make(chan error, 1)
+	url := echoServer(t, []string{"mcp"}, errc)
+	c, err := Dial(context.Background(), http.DefaultClient, url, nil, []string{"other", "mcp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Subprotocol(); got != "mcp" {
+		t.Errorf("got subprotocol %q, want mcp", got)
+	}
+	for _, test := range []struct {
+		typ  MessageType
+		data string
+	}{
+		{TextMessage, "hello"},
+		{BinaryMessage, "\x00\x01"},
+		{TextMessage, strings.Repeat("x", 300)}, // 16-bit length
+	} {
+		if err := c.WriteMessage(test.typ, []byte(test.data)); err != nil {
+			t.Fatal(err)
+		}
+		typ, data, err := c.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typ != test.typ || string(data) != test.data {
+			t.Errorf("got (%d, %q), want (%d, %q)", typ, data, test.typ, test.data)
+		}
+	}
+
+	// A ping is answered while the server waits for a message.
+	if err := c.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WriteMessage(TextMessage, []byte("after ping")); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.ReadMessage(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.pongs.Load(); got != 1 {
+		t.Errorf("got %d pongs, want 1", got)
+	}
+
+	// Closing performs the handshake, which the client's reader completes.
+	readErr := make(chan error, 1)
+	go func() {
+		_, _, err := c.ReadMessage()
+		readErr <- err
+	}()
+	if err := c.Close(StatusGoingAway, "bye"); err != nil {
+		t.Fatal(err)
+	}
+	var cerr *CloseError
+	if err := <-errc; !errors.As(err, &cerr) || cerr.Code != StatusGoingAway || cerr.Reason != "bye" {
+		t.Errorf("server got %v, want close 1001 with reason bye", err)
+	}
+	if err := <-readErr; !errors.As(err, &cerr) || cerr.Code != StatusGoingAway {
+		t.Errorf("client got %v, want echoed close 1001", err)
+	}
+	if err := c.WriteMessage(TextMessage, []byte("x")); err != ErrClosed {
+		t.Errorf("write after close: got %v, want ErrClosed", err)
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	errc := make(chan error, 1)
+	url := echoServer(t, nil, errc)
+	c, err := Dial(context.Background(), http.DefaultClient, url, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(StatusNormalClosure, "")
+	if err := c.WriteMessage(TextMessage, make([]byte, 2<<10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err == nil || !strings.Contains(err.Error(), "too big") {
+		t.Errorf("server got %v, want error about size", err)
+	}
+	_, _, err = c.ReadMessage()
+	var cerr *CloseError
+	if !errors.As(err, &cerr) || cerr.Code != StatusMessageTooBig {
+		t.Errorf("client got %v, want close 1009", err)
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	url := echoServer(t, nil, nil)
+	c, err := Dial(context.Background(), http.DefaultClient, url, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(StatusNormalClosure, "")
+	if err := c.WriteMessage(TextMessage, []byte{0xff}); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = c.ReadMessage()
+	var cerr *CloseError
+	if !errors.As(err, &cerr) || cerr.Code != StatusInvalidPayload {
+		t.Errorf("got %v, want close 1007", err)
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	// The server never reads, so it never replies to pings.
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		<-release
+		c.closeConn(nil)
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	c, err := Dial(context.Background(), http.DefaultClient, ts.URL, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go c.KeepAlive(10 * time.Millisecond)
+	if _, _, err := c.ReadMessage(); err != errKeepAlive {
+		t.Errorf("got %v, want %v", err, errKeepAlive)
+	}
+}
+
+func TestHandshakeErrors(t *testing.T) {
+	url := echoServer(t, []string{"mcp"}, nil)
+	ctx := context.Background()
+	if _, err := Dial(ctx, http.DefaultClient, url, nil, []string{"other"}); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("wrong subprotocol: got %v, want 400 error", err)
+	}
+	if _, err := Dial(ctx, http.DefaultClient, "ftp://example.com", nil, nil); err == nil {
+		t.Error("bad scheme: got nil error")
+	}
+
+	// A plain GET is not upgraded.
+	resp, err := http.Get("http" + strings.TrimPrefix(url, "ws"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("plain GET: got status %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestAcceptKey(t *testing.T) {
+	// The example from RFC 6455 section 1.3.
+	if got, want := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// This file implements bundling and dereferencing of resolved schemas.
+
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"path"
+	"reflect"
+	"strings"
+)
+
+// Bundle returns a single schema that is equivalent to the resolved schema,
+// but does not refer to any other schema document.
+//
+// Each remote schema that the resolved schema refers to, directly or
+// indirectly, is copied into the "$defs" of the result (or "definitions", if the
+// root schema uses that keyword or is a draft 7 schema), under a name derived
+// from its URI. Every reference in the result is rewritten as a JSON Pointer
+// fragment, like "#/$defs/address/properties/street", and identifiers
+// ("$id" other than the root's, "$anchor" and "$dynamicAnchor") are removed,
+// so the result is a plain tree that can be marshaled and later resolved
+// without a [Loader].
+//
+// If the resolved schema has no remote references, Bundle returns a copy of it.
+// Bundle does not support dynamic or recursive references in a schema with
+// remote references.
+func (r *Resolved) Bundle() (*Schema, error) {
+	docs, err := r.remoteDocuments()
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return r.root.clone(), nil
+	}
+	rootDialect := r.resolvedInfos[r.root].dialect
+
+	// Choose the keyword and the names for the remote documents.
+	root := r.root.clone()
+	defs := &root.Defs
+	keyword := "$defs"
+	if root.Definitions != nil || (root.Defs == nil && rootDialect == draft7) {
+		defs = &root.Definitions
+		keyword = "definitions"
+	}
+	if *defs == nil {
+		*defs = map[string]*Schema{}
+	}
+	// prefixes maps document roots to the JSON Pointers of their copies.
+	prefixes := map[*Schema]string{r.root: ""}
+	// copies maps each original schema to its copy.
+	copies := map[*Schema]*Schema{}
+	addCopies(copies, r.root, root)
+	for _, doc := range docs {
+		info := r.resolvedInfos[doc]
+		if info.dialect != rootDialect {
+			return nil, fmt.Errorf("jsonschema: cannot bundle %s, whose version is %s, into a schema of version %s", info.uri, info.dialect, rootDialect)
+		}
+		name := bundleName(info.uri.Path, *defs)
+		c := doc.clone()
+		c.Schema = ""
+		(*defs)[name] = c
+		prefixes[doc] = "/" + keyword + "/" + escapeJSONPointerSegment(name)
+		addCopies(copies, doc, c)
+	}
+
+	// Rewrite references and remove identifiers.
+	for orig, c := range copies {
+		info := r.resolvedInfos[orig]
+		if c.Ref != "" {
+			target := info.resolvedRef
+			tinfo := r.resolvedInfos[target]
+			c.Ref = "#" + prefixes[tinfo.root] + pointerPath(tinfo.path)
+		}
+		if c != root {
+			c.ID = ""
+		}
+		c.Anchor = ""
+		c.DynamicAnchor = ""
+	}
+	return root, nil
+}
+
+// remoteDocuments returns the roots of the documents other than the resolved
+// schema's that it refers to, directly or indirectly, in the order they are
+// encountered.
+func (r *Resolved) remoteDocuments() ([]*Schema, error) {
+	var docs []*Schema
+	seen := map[*Schema]bool{r.root: true}
+	hasDynamic := false
+	for i := -1; i < len(docs); i++ {
+		doc := r.root
+		if i >= 0 {
+			doc = docs[i]
+		}
+		for s := range doc.all() {
+			info := r.resolvedInfos[s]
+			if (s.DynamicRef != "" && info.dialect == draft202012) || (s.RecursiveRef != "" && info.dialect == draft201909) {
+				hasDynamic = true
+			}
+			if s.Ref != "" {
+				if d := r.resolvedInfos[info.resolvedRef].root; !seen[d] {
+					seen[d] = true
+					docs = append(docs, d)
+				}
+			}
+		}
+	}
+	if hasDynamic && len(docs) > 0 {
+		return nil, errors.New("jsonschema: cannot bundle a schema with remote references and dynamic references")
+	}
+	return docs, nil
+}
+
+// addCopies adds the schemas under orig to m, mapped to the corresponding
+// schemas under c, which must be a copy of orig.
+func addCopies(m map[*Schema]*Schema, orig, c *Schema) {
+	next, stop := iter.Pull(c.all())
+	defer stop()
+	for s := range orig.all() {
+		cs, ok := next()
+		assert(ok, "copy has fewer schemas")
+		m[s] = cs
+	}
+}
+
+// bundleName returns a name for a document at the given URI path that is not
+// a key of defs.
+func bundleName(p string, defs map[string]*Schema) string {
+	base := strings.TrimSuffix(path.Base(p), path.Ext(p))
+	if base == "" || base == "." || base == "/" {
+		base = "schema"
+	}
+	name := base
+	for i := 2; defs[name] != nil; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name
+}
+
+// pointerPath converts a path stored in a resolvedInfo to a JSON Pointer.
+func pointerPath(p string) string {
+	if p == "root" {
+		return ""
+	}
+	return p
+}
+
+// Dereference returns a single schema that is equivalent to the resolved
+// schema, but has no references: each reference is replaced by a copy of the
+// schema it refers to.
+// It is meant for clients that do not understand "$ref".
+//
+// A schema with siblings of "$ref" (other than annotations) becomes an "allOf"
+// of the referenced schema and the siblings, except in draft 7, where "$ref"
+// overrides its siblings. "$defs" and identifiers other than the root's "$id"
+// are removed from the result.
+//
+// Dereference returns an error if the schema is recursive, since a recursive
+// schema cannot be expanded into a finite tree, if it has dynamic
+// references that depend on the dynamic scope, or if it refers to a schema of
+// a different version of JSON Schema.
+func (r *Resolved) Dereference() (*Schema, error) {
+	s, err := r.dereference(r.root, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.ID = r.root.ID
+	s.Schema = r.root.Schema
+	return s.clone(), nil
+}
+
+// dereference returns a copy of s with its references replaced.
+// The stack holds the schemas being dereferenced, to detect recursion.
+func (r *Resolved) dereference(s *Schema, stack []*Schema) (*Schema, error) {
+	info := r.resolvedInfos[s]
+	if d := r.resolvedInfos[r.root].dialect; info.dialect != d {
+		return nil, fmt.Errorf("jsonschema: %s: cannot dereference a schema of version %s into one of version %s", r.schemaString(s), info.dialect, d)
+	}
+	var target *Schema
+	keyword := ""
+	switch {
+	case s.Ref != "":
+		target, keyword = info.resolvedRef, "$ref"
+	case s.DynamicRef != "" && info.dialect == draft202012:
+		if info.dynamicRefAnchor != "" {
+			return nil, fmt.Errorf("jsonschema: %s: cannot dereference $dynamicRef to a dynamic anchor", r.schemaString(s))
+		}
+		target, keyword = info.resolvedDynamicRef, "$dynamicRef"
+	case s.RecursiveRef != "" && info.dialect == draft201909:
+		target, keyword = info.resolvedRecursiveRef, "$recursiveRef"
+		if target.RecursiveAnchor {
+			return nil, fmt.Errorf("jsonschema: %s: cannot dereference $recursiveRef to a schema with $recursiveAnchor", r.schemaString(s))
+		}
+	}
+
+	c := *s
+	c.Ref, c.DynamicRef, c.RecursiveRef = "", "", ""
+	c.ID, c.Schema, c.Anchor, c.DynamicAnchor = "", "", "", ""
+	c.RecursiveAnchor = false
+	c.Defs, c.Definitions = nil, nil
+
+	var ref *Schema
+	if target != nil {
+		for _, t := range stack {
+			if t == target {
+				return nil, fmt.Errorf("jsonschema: %s: cannot dereference a recursive schema (%s refers to %s)",
+					r.schemaString(r.root), r.schemaString(s), r.schemaString(target))
+			}
+		}
+		var err error
+		ref, err = r.dereference(target, append(stack, target))
+		if err != nil {
+			return nil, err
+		}
+		if info.dialect == draft7 && keyword == "$ref" {
+			// In draft 7, $ref overrides all other keywords.
+			return ref, nil
+		}
+	}
+
+	v := reflect.ValueOf(&c).Elem()
+	for _, fi := range schemaFieldInfos {
+		fv := v.FieldByIndex(fi.sf.Index)
+		switch fi.sf.Type {
+		case schemaType:
+			if fv.IsNil() {
+				continue
+			}
+			d, err := r.dereference(fv.Interface().(*Schema), stack)
+			if err != nil {
+				return nil, err
+			}
+			fv.Set(reflect.ValueOf(d))
+
+		case schemaSliceType:
+			ss := fv.Interface().([]*Schema)
+			if ss == nil {
+				continue
+			}
+			ds := make([]*Schema, len(ss))
+			for i, s := range ss {
+				d, err := r.dereference(s, stack)
+				if err != nil {
+					return nil, err
+				}
+				ds[i] = d
+			}
+			fv.Set(reflect.ValueOf(ds))
+
+		case schemaMapType:
+			m := fv.Interface().(map[string]*Schema)
+			if m == nil {
+				continue
+			}
+			dm := make(map[string]*Schema, len(m))
+			for k, s := range m {
+				d, err := r.dereference(s, stack)
+				if err != nil {
+					return nil, err
+				}
+				dm[k] = d
+			}
+			fv.Set(reflect.ValueOf(dm))
+		}
+	}
+
+	if ref == nil {
+		return &c, nil
+	}
+	if onlyAnnotations(&c) {
+		// Only annotations remain alongside the reference.
+		// Keep the referring schema's annotations, which take precedence.
+		ref = ref.clone()
+		mergeAnnotations(ref, &c)
+		return ref, nil
+	}
+	c.AllOf = append([]*Schema{ref}, c.AllOf...)
+	return &c, nil
+}
+
+// onlyAnnotations reports whether s has no keywords other than the
+// annotations that mergeAnnotations copies.
+func onlyAnnotations(s *Schema) bool {
+	t := *s
+	t.Title, t.Description, t.Comment = "", "", ""
+	t.Default, t.Examples = nil, nil
+	t.Deprecated, t.ReadOnly, t.WriteOnly = false, false, false
+	return reflect.ValueOf(t).IsZero()
+}
+
+// mergeAnnotations sets the annotations of dst to those of src, where src has them.
+func mergeAnnotations(dst, src *Schema) {
+	if src.Title != "" {
+		dst.Title = src.Title
+	}
+	if src.Description != "" {
+		dst.Description = src.Description
+	}
+	if src.Default != nil {
+		dst.Default = src.Default
+	}
+	if src.Examples != nil {
+		dst.Examples = src.Examples
+	}
+	if src.Comment != "" {
+		dst.Comment = src.Comment
+	}
+	dst.Deprecated = dst.Deprecated || src.Deprecated
+	dst.ReadOnly = dst.ReadOnly || src.ReadOnly
+	dst.WriteOnly = dst.WriteOnly || src.WriteOnly
+}
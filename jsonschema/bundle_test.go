@@ -0,0 +1,189 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// bundleLoader loads schemas from a map of JSON texts, keyed by URI.
+func bundleLoader(docs map[string]string) Loader {
+	return func(uri *url.URL) (*Schema, error) {
+		text, ok := docs[uri.String()]
+		if !ok {
+			return nil, fmt.Errorf("%s not found", uri)
+		}
+		var s Schema
+		if err := json.Unmarshal([]byte(text), &s); err != nil {
+			return nil, err
+		}
+		return &s, nil
+	}
+}
+
+func TestBundle(t *testing.T) {
+	loader := bundleLoader(map[string]string{
+		"https://example.com/address.json": `{
+			"type": "object",
+			"properties": {
+				"street": {"type": "string"},
+				"country": {"$ref": "country.json"}
+			}
+		}`,
+		"https://example.com/country.json":       `{"$anchor": "c", "enum": ["US", "FR"]}`,
+		"https://example.com/other/address.json": `{"$defs": {"zip": {"$id": "zip", "pattern": "^[0-9]+$"}}}`,
+	})
+	root := &Schema{
+		ID:   "https://example.com/person.json",
+		Type: "object",
+		Properties: map[string]*Schema{
+			"home":    {Ref: "address.json"},
+			"street":  {Ref: "address.json#/properties/street"},
+			"country": {Ref: "country.json#c"},
+			"zip":     {Ref: "other/address.json#/$defs/zip"},
+			"self":    {Ref: "#/properties/home"},
+		},
+		Defs: map[string]*Schema{"address": {Type: "string"}},
+	}
+	rs, err := root.Resolve(&ResolveOptions{Loader: loader})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := rs.Bundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Schema{
+		ID:   "https://example.com/person.json",
+		Type: "object",
+		Properties: map[string]*Schema{
+			"home":    {Ref: "#/$defs/address-2"},
+			"street":  {Ref: "#/$defs/address-2/properties/street"},
+			"country": {Ref: "#/$defs/country"},
+			"zip":     {Ref: "#/$defs/address-3/$defs/zip"},
+			"self":    {Ref: "#/properties/home"},
+		},
+		Defs: map[string]*Schema{
+			"address": {Type: "string"},
+			"address-2": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"street":  {Type: "string"},
+					"country": {Ref: "#/$defs/country"},
+				},
+			},
+			"country": {Enum: []any{"US", "FR"}},
+			"address-3": {
+				Defs: map[string]*Schema{"zip": {Pattern: "^[0-9]+$"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	// The original is unchanged.
+	if root.Properties["home"].Ref != "address.json" {
+		t.Error("Bundle modified the original schema")
+	}
+
+	// The bundle validates like the original, without a loader.
+	brs, err := got.Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, inst := range []map[string]any{
+		{"home": map[string]any{"street": "Main", "country": "FR"}},
+		{"home": map[string]any{"country": "UK"}},
+		{"street": 1},
+		{"self": map[string]any{"street": 1}},
+	} {
+		err1 := rs.Validate(inst)
+		err2 := brs.Validate(inst)
+		if (err1 == nil) != (err2 == nil) {
+			t.Errorf("%v: original: %v; bundle: %v", inst, err1, err2)
+		}
+	}
+}
+
+func TestDereference(t *testing.T) {
+	loader := bundleLoader(map[string]string{
+		"https://example.com/name.json": `{"type": "string", "minLength": 1}`,
+	})
+	root := &Schema{
+		ID:   "https://example.com/person.json",
+		Type: "object",
+		Properties: map[string]*Schema{
+			"name":     {Ref: "name.json", Description: "the name"},
+			"nickname": {Ref: "#/$defs/nick", MaxLength: Ptr(10)},
+		},
+		Defs: map[string]*Schema{"nick": {Ref: "name.json"}},
+	}
+	rs, err := root.Resolve(&ResolveOptions{Loader: loader})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := rs.Dereference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Schema{
+		ID:   "https://example.com/person.json",
+		Type: "object",
+		Properties: map[string]*Schema{
+			"name": {Type: "string", MinLength: Ptr(1), Description: "the name"},
+			"nickname": {
+				AllOf:     []*Schema{{Type: "string", MinLength: Ptr(1)}},
+				MaxLength: Ptr(10),
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Draft 7 refs override their siblings.
+	root = &Schema{
+		Schema:      draft7URI,
+		Properties:  map[string]*Schema{"a": {Ref: "#/definitions/a", Type: "integer"}},
+		Definitions: map[string]*Schema{"a": {Type: "string"}},
+	}
+	rs, err = root.Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = rs.Dereference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = &Schema{
+		Schema:     draft7URI,
+		Properties: map[string]*Schema{"a": {Type: "string"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("draft 7: mismatch (-want +got):\n%s", diff)
+	}
+
+	// Recursive schemas cannot be dereferenced.
+	type node struct {
+		Next *node `json:"next"`
+	}
+	s, err := For[node]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err = s.Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs.Dereference(); err == nil || !strings.Contains(err.Error(), "recursive") {
+		t.Errorf("recursive: got %v, want error about recursion", err)
+	}
+}
@@ -0,0 +1,732 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package codegen generates Go type declarations from JSON schemas.
+//
+// Create a [Generator], add schemas to it with [Generator.Add], and call
+// [Generator.Source] to obtain a formatted Go source file.
+//
+// Each added schema becomes a named Go type, as do the schemas it refers to with
+// "$ref", the objects with properties that it contains, and the string enums that
+// it contains. The other schemas are translated as follows:
+//   - "string", "integer", "number" and "boolean" become string, int64, float64
+//     and bool.
+//   - "array" becomes a slice of the type for "items".
+//   - An object without properties becomes a map from string to the type for
+//     "additionalProperties".
+//   - A schema that also allows null, either with a "type" list or with an
+//     "anyOf" or "oneOf" of two schemas, one of them {"type": "null"},
+//     becomes a pointer, unless the type can already be nil.
+//   - Other schemas, including most unions, become any.
+//
+// An object with properties becomes a struct with one field per property, in
+// property-name order. Properties that are not required are marked "omitempty",
+// and are pointers if their type is a struct. The properties of all the schemas
+// in "allOf" are merged. If the object's "additionalProperties" is a schema
+// other than false, the struct has an AdditionalProperties map field for the
+// other properties, with MarshalJSON and UnmarshalJSON methods that encode it
+// inline.
+//
+// Only references within the same schema are supported. They must be JSON
+// pointers, like "#/$defs/Point".
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"maps"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+// Options configure a [Generator].
+type Options struct {
+	// PackageName is the name of the package of the generated file.
+	// If empty, it is "main".
+	PackageName string
+	// Header, if non-empty, is a comment placed at the top of the generated file,
+	// like "Code generated by mytool. DO NOT EDIT."
+	Header string
+}
+
+// A Generator accumulates Go declarations for JSON schemas.
+type Generator struct {
+	opts    Options
+	imports map[string]bool
+	decls   []string
+	names   map[string]bool               // declared Go identifiers
+	named   map[*jsonschema.Schema]string // schemas with a declared type
+	structs map[string]bool               // declared types that are structs
+	pending map[string]bool               // struct types whose fields are being generated
+	nilable map[string]bool               // declared types whose values can be nil
+	root    *jsonschema.Schema            // the schema passed to Add, for references
+}
+
+// NewGenerator returns a new [Generator].
+// A nil opts is equivalent to an empty Options.
+func NewGenerator(opts *Options) *Generator {
+	g := &Generator{
+		imports: map[string]bool{},
+		names:   map[string]bool{},
+		named:   map[*jsonschema.Schema]string{},
+		structs: map[string]bool{},
+		pending: map[string]bool{},
+		nilable: map[string]bool{},
+	}
+	if opts != nil {
+		g.opts = *opts
+	}
+	if g.opts.PackageName == "" {
+		g.opts.PackageName = "main"
+	}
+	return g
+}
+
+// Add declares a type for s, along with the types that it needs.
+// The type is named name, or a variant of it if that name is already used.
+// Add returns the name of the type.
+func (g *Generator) Add(name string, s *jsonschema.Schema) (string, error) {
+	if s == nil {
+		s = &jsonschema.Schema{}
+	}
+	name = GoName(name)
+	if name == "" {
+		return "", errors.New("empty type name")
+	}
+	g.root = s
+	defer func() { g.root = nil }()
+	if s.Ref != "" {
+		t, err := g.lookup(s.Ref)
+		if err != nil {
+			return "", err
+		}
+		s = t
+	}
+	if n, ok := g.named[s]; ok {
+		return n, nil
+	}
+	return g.declare(name, s)
+}
+
+// AddDecl adds the Go source for one or more top-level declarations to the
+// generated file, along with the paths of the packages that they import.
+func (g *Generator) AddDecl(src string, imports ...string) {
+	g.decls = append(g.decls, src)
+	for _, imp := range imports {
+		g.imports[imp] = true
+	}
+}
+
+// Source returns the formatted Go source for the declarations added so far.
+func (g *Generator) Source() ([]byte, error) {
+	var buf bytes.Buffer
+	if g.opts.Header != "" {
+		writeComment(&buf, g.opts.Header)
+		buf.WriteString("\n")
+	}
+	fmt.Fprintf(&buf, "package %s\n\n", g.opts.PackageName)
+	if len(g.imports) > 0 {
+		buf.WriteString("import (\n")
+		// Separate the standard library from other packages, as goimports does.
+		var std, other []string
+		for _, imp := range slices.Sorted(maps.Keys(g.imports)) {
+			if first, _, _ := strings.Cut(imp, "/"); strings.Contains(first, ".") {
+				other = append(other, imp)
+			} else {
+				std = append(std, imp)
+			}
+		}
+		for i, imps := range [][]string{std, other} {
+			if i > 0 && len(std) > 0 && len(other) > 0 {
+				buf.WriteString("\n")
+			}
+			for _, imp := range imps {
+				fmt.Fprintf(&buf, "\t%q\n", imp)
+			}
+		}
+		buf.WriteString(")\n\n")
+	}
+	for _, d := range g.decls {
+		buf.WriteString(d)
+		buf.WriteString("\n")
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+// declare adds a declaration of a type for s, named name or a variant of it.
+func (g *Generator) declare(name string, s *jsonschema.Schema) (string, error) {
+	name = g.uniqueName(name)
+	g.named[s] = name
+	// Reserve a place for the declaration, so that it precedes the declarations
+	// of the types it uses.
+	i := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	var b strings.Builder
+	writeDoc(&b, s)
+	switch {
+	case g.isStruct(s):
+		g.structs[name] = true
+		g.pending[name] = true
+		body, methods, err := g.structType(name, s)
+		delete(g.pending, name)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "type %s %s\n", name, body)
+		b.WriteString(methods)
+
+	case isStringEnum(s):
+		fmt.Fprintf(&b, "type %s string\n\nconst (\n", name)
+		for _, v := range s.Enum {
+			c := g.uniqueName(name + GoName(v.(string)))
+			fmt.Fprintf(&b, "\t%s %s = %q\n", c, name, v)
+		}
+		b.WriteString(")\n")
+
+	default:
+		types, _ := splitNull(s)
+		t, err := g.unnamedType(s, types, name)
+		if err != nil {
+			return "", err
+		}
+		if g.isNilable(t) {
+			g.nilable[name] = true
+		}
+		fmt.Fprintf(&b, "type %s %s\n", name, t)
+	}
+	g.decls[i] = b.String()
+	return name, nil
+}
+
+// goType returns a Go type expression for s.
+// If s needs a new named type, its name is derived from hint.
+func (g *Generator) goType(s *jsonschema.Schema, hint string) (string, error) {
+	if s == nil {
+		return "any", nil
+	}
+	if s.Ref != "" {
+		t, err := g.lookup(s.Ref)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := g.named[t]; !ok {
+			if _, err := g.declare(refName(s.Ref, hint), t); err != nil {
+				return "", err
+			}
+		}
+		s = t
+	}
+	if other := nullUnion(s); other != nil {
+		t, err := g.goType(other, hint)
+		if err != nil {
+			return "", err
+		}
+		return g.nullable(t), nil
+	}
+
+	types, nullable := splitNull(s)
+	var (
+		t   string
+		err error
+	)
+	if name, ok := g.named[s]; ok {
+		t = name
+	} else if g.isStruct(s) || isStringEnum(s) {
+		t, err = g.declare(hint, s)
+	} else {
+		t, err = g.unnamedType(s, types, hint)
+	}
+	if err != nil {
+		return "", err
+	}
+	if nullable {
+		t = g.nullable(t)
+	}
+	return t, nil
+}
+
+// unnamedType returns a type expression for s, which is neither a struct nor
+// a string enum. Types are the types of s, other than "null".
+func (g *Generator) unnamedType(s *jsonschema.Schema, types []string, hint string) (string, error) {
+	if s.Enum != nil {
+		return valuesType(s.Enum), nil
+	}
+	if s.Const != nil {
+		return valuesType([]any{*s.Const}), nil
+	}
+	if len(types) == 0 {
+		switch {
+		case s.Properties != nil || s.AdditionalProperties != nil:
+			types = []string{"object"}
+		case s.Items != nil:
+			types = []string{"array"}
+		case len(s.AllOf) == 1:
+			return g.goType(s.AllOf[0], hint)
+		}
+	}
+	if len(types) != 1 {
+		return "any", nil
+	}
+	switch types[0] {
+	case "string":
+		return "string", nil
+	case "integer":
+		return "int64", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		elem, err := g.goType(s.Items, hint+"Item")
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	case "object":
+		ap := s.AdditionalProperties
+		if isFalse(ap) {
+			ap = nil
+		}
+		elem, err := g.goType(ap, hint+"Value")
+		if err != nil {
+			return "", err
+		}
+		return "map[string]" + elem, nil
+	default:
+		return "any", nil
+	}
+}
+
+// structType returns a struct type expression for s, whose type is named name.
+// If s allows additional properties, structType also returns the declarations
+// of the methods that marshal them.
+func (g *Generator) structType(name string, s *jsonschema.Schema) (body, methods string, err error) {
+	props := map[string]*jsonschema.Schema{}
+	required := map[string]bool{}
+	if err := g.collectProperties(s, props, required); err != nil {
+		return "", "", err
+	}
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	fieldNames := map[string]bool{}
+	uniqueField := func(field string) string {
+		base := field
+		for i := 2; fieldNames[field]; i++ {
+			field = base + strconv.Itoa(i)
+		}
+		fieldNames[field] = true
+		return field
+	}
+	for _, prop := range slices.Sorted(maps.Keys(props)) {
+		ps := props[prop]
+		field := GoName(prop)
+		if field == "" {
+			field = "Field"
+		}
+		field = uniqueField(field)
+
+		t, err := g.goType(ps, name+field)
+		if err != nil {
+			return "", "", fmt.Errorf("property %q: %w", prop, err)
+		}
+		tag := prop
+		if !required[prop] {
+			tag += ",omitempty"
+		}
+		// Optional structs are pointers, so they can be omitted; so are fields
+		// of the types being declared, which would otherwise be invalid.
+		if g.structs[t] && (!required[prop] || g.pending[t]) {
+			t = "*" + t
+		}
+		if ps != nil {
+			writeDoc(&b, ps)
+		}
+		fmt.Fprintf(&b, "%s %s `json:%q`\n", field, t, tag)
+	}
+	if ap := s.AdditionalProperties; ap != nil && !isFalse(ap) {
+		field := uniqueField("AdditionalProperties")
+		elem, err := g.goType(ap, name+"Value")
+		if err != nil {
+			return "", "", fmt.Errorf("additionalProperties: %w", err)
+		}
+		fmt.Fprintf(&b, "// %s holds the properties not listed above.\n", field)
+		fmt.Fprintf(&b, "%s map[string]%s `json:\"-\"`\n", field, elem)
+		methods = additionalPropertiesMethods(name, field, elem, slices.Sorted(maps.Keys(props)))
+		g.imports["encoding/json"] = true
+	}
+	b.WriteString("}")
+	return b.String(), methods, nil
+}
+
+// additionalPropertiesMethods returns the declarations of MarshalJSON and
+// UnmarshalJSON methods for the struct type name, which hold the properties
+// other than props in a map field of type map[string]elem.
+func additionalPropertiesMethods(name, field, elem string, props []string) string {
+	var known strings.Builder
+	for i, p := range props {
+		if i > 0 {
+			known.WriteString(", ")
+		}
+		fmt.Fprintf(&known, "%q", p)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+// MarshalJSON encodes x as a JSON object holding its fields and the
+// entries of x.%[2]s.
+func (x %[1]s) MarshalJSON() ([]byte, error) {
+	type plain %[1]s
+	data, err := json.Marshal(plain(x))
+	if err != nil || len(x.%[2]s) == 0 {
+		return data, err
+	}
+	extra, err := json.Marshal(x.%[2]s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 2 {
+		return extra, nil
+	}
+	return append(append(data[:len(data)-1], ','), extra[1:]...), nil
+}
+
+// UnmarshalJSON decodes a JSON object into x, storing the properties without
+// a field in x.%[2]s.
+func (x *%[1]s) UnmarshalJSON(data []byte) error {
+	type plain %[1]s
+	if err := json.Unmarshal(data, (*plain)(x)); err != nil {
+		return err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	x.%[2]s = nil
+	for k, v := range all {
+`, name, field)
+	if len(props) > 0 {
+		fmt.Fprintf(&b, "\t\tswitch k {\n\t\tcase %s:\n\t\t\tcontinue\n\t\t}\n", known.String())
+	}
+	fmt.Fprintf(&b, `		var e %[2]s
+		if err := json.Unmarshal(v, &e); err != nil {
+			return err
+		}
+		if x.%[1]s == nil {
+			x.%[1]s = map[string]%[2]s{}
+		}
+		x.%[1]s[k] = e
+	}
+	return nil
+}
+`, field, elem)
+	return b.String()
+}
+
+// collectProperties adds the properties of s, and of the schemas in its "allOf",
+// to props and required.
+func (g *Generator) collectProperties(s *jsonschema.Schema, props map[string]*jsonschema.Schema, required map[string]bool) error {
+	for name, ps := range s.Properties {
+		props[name] = ps
+	}
+	for _, r := range s.Required {
+		required[r] = true
+	}
+	for _, sub := range s.AllOf {
+		sub, err := g.deref(sub)
+		if err != nil {
+			return err
+		}
+		if err := g.collectProperties(sub, props, required); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isStruct reports whether s is translated to a struct.
+func (g *Generator) isStruct(s *jsonschema.Schema) bool {
+	if len(s.Properties) > 0 {
+		return true
+	}
+	for _, sub := range s.AllOf {
+		if sub, err := g.deref(sub); err == nil && g.isStruct(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// deref returns the schema that s refers to, or s if it has no reference.
+func (g *Generator) deref(s *jsonschema.Schema) (*jsonschema.Schema, error) {
+	if s == nil || s.Ref == "" {
+		return s, nil
+	}
+	return g.lookup(s.Ref)
+}
+
+// lookup returns the schema that ref refers to within the root schema.
+func (g *Generator) lookup(ref string) (*jsonschema.Schema, error) {
+	frag, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("reference %q: only references within the schema are supported", ref)
+	}
+	frag, err := url.PathUnescape(frag)
+	if err != nil {
+		return nil, fmt.Errorf("reference %q: %w", ref, err)
+	}
+	if frag != "" && frag[0] != '/' {
+		return nil, fmt.Errorf("reference %q: only JSON pointers are supported", ref)
+	}
+	s := g.root
+	segs := strings.Split(frag, "/")[1:]
+	for len(segs) > 0 {
+		v, n := schemaField(s, unescapePointer(segs[0]))
+		segs = segs[1:]
+		switch v.Kind() {
+		case reflect.Map:
+			if len(segs) > 0 {
+				v = v.MapIndex(reflect.ValueOf(unescapePointer(segs[0])))
+				segs = segs[1:]
+			}
+		case reflect.Slice:
+			if len(segs) > 0 {
+				if i, err := strconv.Atoi(segs[0]); err == nil && i >= 0 && i < v.Len() {
+					v = v.Index(i)
+				} else {
+					v = reflect.Value{}
+				}
+				segs = segs[1:]
+			}
+		}
+		next, _ := valueInterface(v).(*jsonschema.Schema)
+		if n == "" || next == nil {
+			return nil, fmt.Errorf("reference %q: no schema at that location", ref)
+		}
+		s = next
+	}
+	return s, nil
+}
+
+// schemaField returns the field of s with the given JSON name, and that name,
+// or the zero Value and "" if there is none.
+func schemaField(s *jsonschema.Schema, name string) (reflect.Value, string) {
+	v := reflect.ValueOf(s).Elem()
+	for i := range v.NumField() {
+		tag, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if tag == name {
+			return v.Field(i), name
+		}
+	}
+	return reflect.Value{}, ""
+}
+
+func valueInterface(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+func unescapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+}
+
+// refName returns a type name for the schema that ref refers to.
+func refName(ref, hint string) string {
+	i := strings.LastIndexByte(ref, '/')
+	if i < 0 {
+		return hint
+	}
+	if name := GoName(unescapePointer(ref[i+1:])); name != "" && !unicode.IsDigit(rune(ref[i+1])) {
+		return name
+	}
+	return hint
+}
+
+func (g *Generator) uniqueName(name string) string {
+	if name == "" {
+		name = "T"
+	}
+	n := name
+	for i := 2; g.names[n]; i++ {
+		n = name + strconv.Itoa(i)
+	}
+	g.names[n] = true
+	return n
+}
+
+// isNilable reports whether the type expression t denotes a type whose values
+// can be nil.
+func (g *Generator) isNilable(t string) bool {
+	return t == "any" || g.nilable[t] ||
+		strings.HasPrefix(t, "*") || strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "map[")
+}
+
+// nullable returns a type that can represent both the values of type t and null.
+func (g *Generator) nullable(t string) string {
+	if g.isNilable(t) {
+		return t
+	}
+	return "*" + t
+}
+
+// splitNull returns the types of s other than "null", and whether s allows null.
+func splitNull(s *jsonschema.Schema) (types []string, null bool) {
+	if s.Type != "" {
+		types = []string{s.Type}
+	} else {
+		types = s.Types
+	}
+	if i := slices.Index(types, "null"); i >= 0 && len(types) > 1 {
+		return slices.Delete(slices.Clone(types), i, i+1), true
+	}
+	return types, false
+}
+
+// nullUnion returns X if s is an "anyOf" or "oneOf" of {"type": "null"} and X,
+// and nil otherwise.
+func nullUnion(s *jsonschema.Schema) *jsonschema.Schema {
+	for _, ss := range [][]*jsonschema.Schema{s.AnyOf, s.OneOf} {
+		if len(ss) != 2 {
+			continue
+		}
+		for i, sub := range ss {
+			if sub != nil && sub.Type == "null" {
+				return ss[1-i]
+			}
+		}
+	}
+	return nil
+}
+
+func isStringEnum(s *jsonschema.Schema) bool {
+	if len(s.Enum) == 0 {
+		return false
+	}
+	for _, v := range s.Enum {
+		if _, ok := v.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isFalse reports whether s is the schema that matches nothing.
+func isFalse(s *jsonschema.Schema) bool {
+	return s != nil && s.Not != nil && reflect.ValueOf(*s.Not).IsZero()
+}
+
+// valuesType returns the Go type that can hold all the given JSON values.
+func valuesType(vals []any) string {
+	t := ""
+	for _, v := range vals {
+		var vt string
+		switch v := v.(type) {
+		case string:
+			vt = "string"
+		case bool:
+			vt = "bool"
+		case float64:
+			if v == float64(int64(v)) {
+				vt = "int64"
+			} else {
+				vt = "float64"
+			}
+		case int, int64, int32:
+			vt = "int64"
+		case json.Number:
+			if _, err := v.Int64(); err == nil {
+				vt = "int64"
+			} else {
+				vt = "float64"
+			}
+		default:
+			return "any"
+		}
+		switch {
+		case t == "" || t == vt:
+			t = vt
+		case t == "int64" && vt == "float64", t == "float64" && vt == "int64":
+			t = "float64"
+		default:
+			return "any"
+		}
+	}
+	if t == "" {
+		return "any"
+	}
+	return t
+}
+
+// writeDoc writes a doc comment for a declaration of s.
+func writeDoc(b *strings.Builder, s *jsonschema.Schema) {
+	doc := s.Description
+	if doc == "" {
+		doc = s.Title
+	}
+	if s.Deprecated {
+		if doc != "" {
+			doc += "\n\n"
+		}
+		doc += "Deprecated: this is marked deprecated in the schema."
+	}
+	if doc != "" {
+		writeComment(b, doc)
+	}
+}
+
+type stringWriter interface {
+	WriteString(string) (int, error)
+}
+
+func writeComment(w stringWriter, text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		w.WriteString(strings.TrimRight("// "+line, " \t\r") + "\n")
+	}
+}
+
+// initialisms are words that Go style writes in upper case.
+var initialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "MCP": true, "SQL": true, "TCP": true, "TLS": true, "UDP": true,
+	"UI": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// GoName returns an exported Go identifier for s.
+// Runs of characters other than letters and digits separate words, and each word
+// is capitalized, so "user_id" and "user-id" both become "UserID".
+// If s has no letters or digits, GoName returns the empty string.
+func GoName(s string) string {
+	var b strings.Builder
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if u := strings.ToUpper(w); initialisms[u] {
+			b.WriteString(u)
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	name := b.String()
+	if name != "" && !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package codegen
+
+import (
+	"encoding/json"
+	"flag"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+var update = flag.Bool("update", false, "if set, update golden files")
+
+func TestGenerate(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "order.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s jsonschema.Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	g := NewGenerator(&Options{PackageName: "pizza", Header: "Code generated by test. DO NOT EDIT."})
+	name, err := g.Add("order", &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Order" {
+		t.Errorf("got name %q, want Order", name)
+	}
+	src, err := g.Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	typeCheck(t, src)
+
+	golden := filepath.Join("testdata", "order.golden")
+	if *update {
+		if err := os.WriteFile(golden, src, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), string(src)); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestGenerateRecursive(t *testing.T) {
+	// The required properties of mutually recursive types must be pointers.
+	const schema = `{
+		"properties": {"b": {"$ref": "#/$defs/B"}},
+		"required": ["b"],
+		"$defs": {
+			"B": {"properties": {"a": {"$ref": "#"}}, "required": ["a"]}
+		}
+	}`
+	var s jsonschema.Schema
+	if err := json.Unmarshal([]byte(schema), &s); err != nil {
+		t.Fatal(err)
+	}
+	g := NewGenerator(nil)
+	if _, err := g.Add("A", &s); err != nil {
+		t.Fatal(err)
+	}
+	src, err := g.Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	typeCheck(t, src)
+}
+
+// typeCheck reports an error if src is not a valid Go file.
+func typeCheck(t *testing.T, src []byte) {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "gen.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("p", fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("%v\n%s", err, src)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"name", "Name"},
+		{"user_id", "UserID"},
+		{"user-id", "UserID"},
+		{"apiURL", "ApiURL"},
+		{"http status", "HTTPStatus"},
+		{"3d", "X3d"},
+		{"*", ""},
+		{"émigré", "Émigré"},
+	} {
+		if got := GoName(test.in); got != test.want {
+			t.Errorf("GoName(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, s := range []*jsonschema.Schema{
+		{Ref: "other.json#/$defs/x"},
+		{Properties: map[string]*jsonschema.Schema{"p": {Ref: "#/$defs/missing"}}},
+		{Properties: map[string]*jsonschema.Schema{"p": {Ref: "#anchor"}}},
+	} {
+		g := NewGenerator(nil)
+		if _, err := g.Add("T", s); err == nil {
+			t.Errorf("%s: got nil error", s)
+		}
+	}
+}
@@ -0,0 +1,509 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// This file compares schemas for compatibility.
+
+package jsonschema
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// A Direction describes the ways in which a change to a schema breaks
+// compatibility. It is a set of the flags Backward and Forward.
+type Direction int
+
+const (
+	// Backward means that an instance valid under the old schema may be
+	// invalid under the new one: the new schema is stricter.
+	// For a tool's input schema, such a change breaks existing clients.
+	Backward Direction = 1 << iota
+	// Forward means that an instance valid under the new schema may be
+	// invalid under the old one: the new schema is looser.
+	// For a tool's output schema, such a change breaks existing clients.
+	Forward
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Backward:
+		return "backward"
+	case Forward:
+		return "forward"
+	case Backward | Forward:
+		return "backward and forward"
+	default:
+		return fmt.Sprintf("Direction(%d)", int(d))
+	}
+}
+
+// A Change is a difference between two schemas that breaks compatibility.
+type Change struct {
+	// Location is a JSON Pointer to the changed keyword, from the root
+	// of the schemas. For example, "/properties/name/type".
+	Location string
+	// Breaks describes how the change breaks compatibility.
+	Breaks Direction
+	// Message describes the change.
+	Message string
+}
+
+func (c *Change) String() string {
+	loc := c.Location
+	if loc == "" {
+		loc = "root"
+	}
+	return fmt.Sprintf("%s: %s (breaks %s compatibility)", loc, c.Message, c.Breaks)
+}
+
+// Compare compares an old version of a schema with a new one, and returns
+// the changes that break compatibility, ordered by location.
+// Changes that affect only annotations, like "description", are not reported.
+//
+// The comparison is structural: it compares the schemas keyword by keyword,
+// without resolving them. It is conservative: it may report a change that
+// does not, in fact, affect which instances are valid. In particular:
+//   - References are compared by value. Definitions in "$defs" are compared
+//     by name.
+//   - Subschemas of "allOf", "anyOf" and "oneOf" are compared by position.
+//   - A property in the old schema that is missing from the new one is compared
+//     with the new schema's "additionalProperties", and vice versa.
+//     Pattern properties are not considered.
+//   - Any change to "if", "then" or "else" breaks compatibility in both
+//     directions.
+//
+// To test that a new version of a tool's input schema accepts everything the old
+// one did, check that no change has the Backward flag:
+//
+//	for _, c := range jsonschema.Compare(old, new) {
+//		if c.Breaks&jsonschema.Backward != 0 {
+//			t.Error(c)
+//		}
+//	}
+func Compare(old, new *Schema) []*Change {
+	var c comparer
+	c.compare(old, new, "")
+	slices.SortStableFunc(c.changes, func(a, b *Change) int {
+		return strings.Compare(a.Location, b.Location)
+	})
+	return c.changes
+}
+
+// A comparer accumulates the changes found by compare.
+type comparer struct {
+	changes []*Change
+	// If true, Backward and Forward are swapped. This is used for
+	// the subschemas of "not", whose changes have the opposite effect.
+	flip bool
+}
+
+func (c *comparer) add(loc string, d Direction, format string, args ...any) {
+	if c.flip {
+		d = (d&Backward)<<1 | (d&Forward)>>1
+	}
+	c.changes = append(c.changes, &Change{Location: loc, Breaks: d, Message: fmt.Sprintf(format, args...)})
+}
+
+// compare compares old and new, two subschemas at loc.
+// A nil schema validates everything.
+func (c *comparer) compare(old, new *Schema, loc string) {
+	if old == nil {
+		old = &Schema{}
+	}
+	if new == nil {
+		new = &Schema{}
+	}
+	switch oldFalse, newFalse := rejectsAll(old), rejectsAll(new); {
+	case oldFalse && newFalse:
+		return
+	case newFalse:
+		c.add(loc, Backward, "schema rejects every value")
+		return
+	case oldFalse:
+		c.add(loc, Forward, "schema no longer rejects every value")
+		return
+	}
+
+	c.compareTypes(old, new, loc)
+	c.compareValues(old.Enum, new.Enum, loc+"/enum")
+	if old.Const != nil || new.Const != nil {
+		c.compareConst(old.Const, new.Const, loc+"/const")
+	}
+
+	// Numbers.
+	if old.MultipleOf != nil || new.MultipleOf != nil {
+		c.compareMultipleOf(old.MultipleOf, new.MultipleOf, loc+"/multipleOf")
+	}
+	compareBound(c, old.Minimum, new.Minimum, loc, "minimum", true)
+	compareBound(c, old.ExclusiveMinimum, new.ExclusiveMinimum, loc, "exclusiveMinimum", true)
+	compareBound(c, old.Maximum, new.Maximum, loc, "maximum", false)
+	compareBound(c, old.ExclusiveMaximum, new.ExclusiveMaximum, loc, "exclusiveMaximum", false)
+
+	// Strings.
+	compareBound(c, old.MinLength, new.MinLength, loc, "minLength", true)
+	compareBound(c, old.MaxLength, new.MaxLength, loc, "maxLength", false)
+	c.compareString(old.Pattern, new.Pattern, loc, "pattern")
+	c.compareString(old.Format, new.Format, loc, "format")
+
+	// Arrays.
+	c.compareSchemaSlices(old.PrefixItems, new.PrefixItems, loc+"/prefixItems")
+	c.compareSchemaSlices(old.ItemsArray, new.ItemsArray, loc+"/items")
+	c.compareSubschemas(old.Items, new.Items, loc+"/items")
+	c.compareSubschemas(old.AdditionalItems, new.AdditionalItems, loc+"/additionalItems")
+	c.compareSubschemas(old.UnevaluatedItems, new.UnevaluatedItems, loc+"/unevaluatedItems")
+	c.compareSubschemas(old.Contains, new.Contains, loc+"/contains")
+	compareBound(c, old.MinItems, new.MinItems, loc, "minItems", true)
+	compareBound(c, old.MaxItems, new.MaxItems, loc, "maxItems", false)
+	compareBound(c, old.MinContains, new.MinContains, loc, "minContains", true)
+	compareBound(c, old.MaxContains, new.MaxContains, loc, "maxContains", false)
+	if old.UniqueItems != new.UniqueItems {
+		if new.UniqueItems {
+			c.add(loc+"/uniqueItems", Backward, "items must now be unique")
+		} else {
+			c.add(loc+"/uniqueItems", Forward, "items need no longer be unique")
+		}
+	}
+
+	// Objects.
+	compareBound(c, old.MinProperties, new.MinProperties, loc, "minProperties", true)
+	compareBound(c, old.MaxProperties, new.MaxProperties, loc, "maxProperties", false)
+	c.compareRequired(old.Required, new.Required, loc+"/required", "property %q is now required", "property %q is no longer required")
+	c.compareProperties(old, new, loc)
+	c.compareSubschemaMaps(old.PatternProperties, new.PatternProperties, loc+"/patternProperties")
+	c.compareSubschemas(old.AdditionalProperties, new.AdditionalProperties, loc+"/additionalProperties")
+	c.compareSubschemas(old.PropertyNames, new.PropertyNames, loc+"/propertyNames")
+	c.compareSubschemas(old.UnevaluatedProperties, new.UnevaluatedProperties, loc+"/unevaluatedProperties")
+	c.compareDependentRequired(old.DependentRequired, new.DependentRequired, loc+"/dependentRequired")
+	c.compareSubschemaMaps(old.DependentSchemas, new.DependentSchemas, loc+"/dependentSchemas")
+	c.compareDependentRequired(old.DependencyStrings, new.DependencyStrings, loc+"/dependencies")
+	c.compareSubschemaMaps(old.DependencySchemas, new.DependencySchemas, loc+"/dependencies")
+
+	// Logic.
+	c.compareAllOf(old.AllOf, new.AllOf, loc+"/allOf")
+	c.compareAnyOf(old.AnyOf, new.AnyOf, loc+"/anyOf")
+	c.compareOneOf(old.OneOf, new.OneOf, loc+"/oneOf")
+	c.compareNot(old.Not, new.Not, loc+"/not")
+	if !reflect.DeepEqual([]*Schema{old.If, old.Then, old.Else}, []*Schema{new.If, new.Then, new.Else}) {
+		c.add(loc+"/if", Backward|Forward, "conditional changed")
+	}
+
+	// References.
+	c.compareString(old.Ref, new.Ref, loc, "$ref")
+	c.compareString(old.DynamicRef, new.DynamicRef, loc, "$dynamicRef")
+	c.compareString(old.RecursiveRef, new.RecursiveRef, loc, "$recursiveRef")
+	c.compareDefs(old.Defs, new.Defs, loc+"/$defs")
+	c.compareDefs(old.Definitions, new.Definitions, loc+"/definitions")
+}
+
+// acceptsAll reports whether s is a schema that validates every instance,
+// ignoring annotations.
+func acceptsAll(s *Schema) bool {
+	if s == nil {
+		return true
+	}
+	// Clear everything but the validation keywords.
+	t := *s
+	t.ID, t.Schema, t.Comment, t.Anchor, t.DynamicAnchor = "", "", "", "", ""
+	t.Defs, t.Definitions, t.Vocabulary, t.Extra = nil, nil, nil, nil
+	t.RecursiveAnchor = false
+	t.Title, t.Description, t.Default, t.Examples = "", "", nil, nil
+	t.Deprecated, t.ReadOnly, t.WriteOnly = false, false, false
+	t.ContentEncoding, t.ContentMediaType, t.ContentSchema = "", "", nil
+	return reflect.ValueOf(t).IsZero()
+}
+
+// rejectsAll reports whether s is a schema that validates no instance, like
+// the one that false unmarshals into.
+func rejectsAll(s *Schema) bool {
+	return s != nil && s.Not != nil && acceptsAll(s.Not)
+}
+
+// typeSet returns the types that s allows, or nil if it allows any type.
+func typeSet(s *Schema) []string {
+	if s.Type != "" {
+		return []string{s.Type}
+	}
+	return s.Types
+}
+
+// allowsType reports whether a schema with the given types allows instances
+// of type t.
+func allowsType(types []string, t string) bool {
+	return types == nil || slices.Contains(types, t) || (t == "integer" && slices.Contains(types, "number"))
+}
+
+func (c *comparer) compareTypes(old, new *Schema, loc string) {
+	oldTypes, newTypes := typeSet(old), typeSet(new)
+	if oldTypes == nil && newTypes == nil {
+		return
+	}
+	loc += "/type"
+	if newTypes != nil && oldTypes == nil {
+		c.add(loc, Backward, "type restricted to %s", strings.Join(newTypes, ", "))
+		return
+	}
+	if oldTypes != nil && newTypes == nil {
+		c.add(loc, Forward, "type restriction to %s removed", strings.Join(oldTypes, ", "))
+		return
+	}
+	for _, t := range oldTypes {
+		if !allowsType(newTypes, t) {
+			c.add(loc, Backward, "type %s no longer allowed", t)
+		}
+	}
+	for _, t := range newTypes {
+		if !allowsType(oldTypes, t) {
+			c.add(loc, Forward, "type %s now allowed", t)
+		}
+	}
+}
+
+// compareValues compares the values of an "enum" keyword.
+func (c *comparer) compareValues(old, new []any, loc string) {
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		c.add(loc, Backward, "enum added")
+	case new == nil:
+		c.add(loc, Forward, "enum removed")
+	default:
+		for _, v := range old {
+			if !slices.ContainsFunc(new, func(w any) bool { return Equal(v, w) }) {
+				c.add(loc, Backward, "value %v removed", v)
+			}
+		}
+		for _, v := range new {
+			if !slices.ContainsFunc(old, func(w any) bool { return Equal(v, w) }) {
+				c.add(loc, Forward, "value %v added", v)
+			}
+		}
+	}
+}
+
+func (c *comparer) compareConst(old, new *any, loc string) {
+	switch {
+	case old == nil:
+		c.add(loc, Backward, "const added")
+	case new == nil:
+		c.add(loc, Forward, "const removed")
+	case !Equal(*old, *new):
+		c.add(loc, Backward|Forward, "const changed from %v to %v", *old, *new)
+	}
+}
+
+func (c *comparer) compareMultipleOf(old, new *float64, loc string) {
+	// isMultiple reports whether x is a multiple of y.
+	isMultiple := func(x, y float64) bool {
+		_, f := math.Modf(x / y)
+		return f == 0
+	}
+	switch {
+	case old == nil:
+		c.add(loc, Backward, "multipleOf %v added", *new)
+	case new == nil:
+		c.add(loc, Forward, "multipleOf %v removed", *old)
+	case *old == *new:
+	case isMultiple(*new, *old):
+		c.add(loc, Backward, "multipleOf changed from %v to %v", *old, *new)
+	case isMultiple(*old, *new):
+		c.add(loc, Forward, "multipleOf changed from %v to %v", *old, *new)
+	default:
+		c.add(loc, Backward|Forward, "multipleOf changed from %v to %v", *old, *new)
+	}
+}
+
+// compareBound compares the values of a keyword that sets a lower or upper
+// bound.
+func compareBound[T int | float64](c *comparer, old, new *T, loc, keyword string, lower bool) {
+	loc += "/" + keyword
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		c.add(loc, Backward, "%s %v added", keyword, *new)
+	case new == nil:
+		c.add(loc, Forward, "%s %v removed", keyword, *old)
+	case *old == *new:
+	case (*new > *old) == lower:
+		c.add(loc, Backward, "%s tightened from %v to %v", keyword, *old, *new)
+	default:
+		c.add(loc, Forward, "%s loosened from %v to %v", keyword, *old, *new)
+	}
+}
+
+// compareString compares the values of a keyword with a string value,
+// which constrains instances if it is non-empty.
+func (c *comparer) compareString(old, new, loc, keyword string) {
+	loc += "/" + keyword
+	switch {
+	case old == new:
+	case old == "":
+		c.add(loc, Backward, "%s %q added", keyword, new)
+	case new == "":
+		c.add(loc, Forward, "%s %q removed", keyword, old)
+	default:
+		c.add(loc, Backward|Forward, "%s changed from %q to %q", keyword, old, new)
+	}
+}
+
+// compareSubschemas compares two optional subschemas, either of which
+// validates everything if it is nil.
+func (c *comparer) compareSubschemas(old, new *Schema, loc string) {
+	if old != nil || new != nil {
+		c.compare(old, new, loc)
+	}
+}
+
+// compareSchemaSlices compares subschemas by position. A missing subschema
+// validates everything.
+func (c *comparer) compareSchemaSlices(old, new []*Schema, loc string) {
+	for i := range max(len(old), len(new)) {
+		c.compare(at(old, i), at(new, i), fmt.Sprintf("%s/%d", loc, i))
+	}
+}
+
+// at returns s[i], or nil if i is out of range.
+func at(s []*Schema, i int) *Schema {
+	if i < len(s) {
+		return s[i]
+	}
+	return nil
+}
+
+// compareSubschemaMaps compares subschemas by key. A missing subschema
+// validates everything.
+func (c *comparer) compareSubschemaMaps(old, new map[string]*Schema, loc string) {
+	for _, k := range unionKeys(old, new) {
+		c.compare(old[k], new[k], loc+"/"+escapeJSONPointerSegment(k))
+	}
+}
+
+// compareDefs compares the definitions that are present in both old and new.
+// Added and removed definitions matter only if they are referred to, and
+// changed references are reported where they occur.
+func (c *comparer) compareDefs(old, new map[string]*Schema, loc string) {
+	for _, k := range unionKeys(old, new) {
+		if o, n := old[k], new[k]; o != nil && n != nil {
+			c.compare(o, n, loc+"/"+escapeJSONPointerSegment(k))
+		}
+	}
+}
+
+func unionKeys[V any](m1, m2 map[string]V) []string {
+	keys := slices.Collect(maps.Keys(m1))
+	for k := range m2 {
+		if _, ok := m1[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func (c *comparer) compareRequired(old, new []string, loc, addedFormat, removedFormat string) {
+	for _, p := range new {
+		if !slices.Contains(old, p) {
+			c.add(loc, Backward, addedFormat, p)
+		}
+	}
+	for _, p := range old {
+		if !slices.Contains(new, p) {
+			c.add(loc, Forward, removedFormat, p)
+		}
+	}
+}
+
+func (c *comparer) compareDependentRequired(old, new map[string][]string, loc string) {
+	for _, k := range unionKeys(old, new) {
+		c.compareRequired(old[k], new[k], loc+"/"+escapeJSONPointerSegment(k),
+			"property %q is now required with "+fmt.Sprintf("%q", k),
+			"property %q is no longer required with "+fmt.Sprintf("%q", k))
+	}
+}
+
+// compareProperties compares the "properties" keywords of old and new.
+// A property that is in only one of them is compared with the other's
+// "additionalProperties".
+func (c *comparer) compareProperties(old, new *Schema, loc string) {
+	for _, name := range unionKeys(old.Properties, new.Properties) {
+		ploc := loc + "/properties/" + escapeJSONPointerSegment(name)
+		o, inOld := old.Properties[name]
+		n, inNew := new.Properties[name]
+		switch {
+		case inOld && inNew:
+			c.compare(o, n, ploc)
+		case inOld:
+			if rejectsAll(new.AdditionalProperties) && !rejectsAll(o) {
+				c.add(ploc, Backward, "property %q removed, and additional properties are not allowed", name)
+			} else {
+				c.compare(o, new.AdditionalProperties, ploc)
+			}
+		default:
+			if rejectsAll(old.AdditionalProperties) && !rejectsAll(n) {
+				c.add(ploc, Forward, "property %q added, and additional properties were not allowed", name)
+			} else {
+				c.compare(old.AdditionalProperties, n, ploc)
+			}
+		}
+	}
+}
+
+func (c *comparer) compareAllOf(old, new []*Schema, loc string) {
+	// Every subschema must hold, so a missing one validates everything.
+	c.compareSchemaSlices(old, new, loc)
+}
+
+func (c *comparer) compareAnyOf(old, new []*Schema, loc string) {
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		c.add(loc, Backward, "anyOf added")
+	case new == nil:
+		c.add(loc, Forward, "anyOf removed")
+	default:
+		for i := range min(len(old), len(new)) {
+			c.compare(old[i], new[i], fmt.Sprintf("%s/%d", loc, i))
+		}
+		// Fewer alternatives allow fewer instances.
+		if len(new) < len(old) {
+			c.add(loc, Backward, "%d alternatives removed", len(old)-len(new))
+		} else if len(new) > len(old) {
+			c.add(loc, Forward, "%d alternatives added", len(new)-len(old))
+		}
+	}
+}
+
+func (c *comparer) compareOneOf(old, new []*Schema, loc string) {
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		c.add(loc, Backward, "oneOf added")
+	case new == nil:
+		c.add(loc, Forward, "oneOf removed")
+	case len(old) != len(new):
+		// Adding an alternative can make an instance match more than one.
+		c.add(loc, Backward|Forward, "number of alternatives changed from %d to %d", len(old), len(new))
+	default:
+		c.compareSchemaSlices(old, new, loc)
+	}
+}
+
+func (c *comparer) compareNot(old, new *Schema, loc string) {
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		c.add(loc, Backward, "not added")
+	case new == nil:
+		c.add(loc, Forward, "not removed")
+	default:
+		// Loosening the subschema of "not" tightens the schema, and vice versa.
+		c.flip = !c.flip
+		c.compare(old, new, loc)
+		c.flip = !c.flip
+	}
+}
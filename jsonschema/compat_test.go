@@ -0,0 +1,131 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompare(t *testing.T) {
+	const (
+		B  = Backward
+		F  = Forward
+		BF = Backward | Forward
+	)
+	person := func() *Schema {
+		return &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"name":  {Type: "string", MaxLength: Ptr(100)},
+				"age":   {Type: "integer", Minimum: Ptr(0.0)},
+				"color": {Type: "string", Enum: []any{"red", "green"}},
+			},
+			Required:             []string{"name"},
+			AdditionalProperties: falseSchema(),
+		}
+	}
+	for _, tt := range []struct {
+		name   string
+		modify func(*Schema)
+		want   []*Change
+	}{
+		{
+			"annotations",
+			func(s *Schema) {
+				s.Description = "a person"
+				s.Properties["name"].Title = "Name"
+			},
+			nil,
+		},
+		{
+			"new required property",
+			func(s *Schema) { s.Required = append(s.Required, "age") },
+			[]*Change{{"/required", B, `property "age" is now required`}},
+		},
+		{
+			"narrowed type",
+			func(s *Schema) { s.Properties["age"].Types = []string{"string", "null"}; s.Properties["age"].Type = "" },
+			[]*Change{
+				{"/properties/age/type", B, "type integer no longer allowed"},
+				{"/properties/age/type", F, "type string now allowed"},
+				{"/properties/age/type", F, "type null now allowed"},
+			},
+		},
+		{
+			"integer to number",
+			func(s *Schema) { s.Properties["age"].Type = "number" },
+			[]*Change{{"/properties/age/type", F, "type number now allowed"}},
+		},
+		{
+			"enum",
+			func(s *Schema) { s.Properties["color"].Enum = []any{"red", "blue"} },
+			[]*Change{
+				{"/properties/color/enum", B, "value green removed"},
+				{"/properties/color/enum", F, "value blue added"},
+			},
+		},
+		{
+			"removed property",
+			func(s *Schema) { delete(s.Properties, "color") },
+			[]*Change{{"/properties/color", B, `property "color" removed, and additional properties are not allowed`}},
+		},
+		{
+			"added property",
+			func(s *Schema) { s.Properties["email"] = &Schema{Type: "string"} },
+			[]*Change{{"/properties/email", F, `property "email" added, and additional properties were not allowed`}},
+		},
+		{
+			"additional properties allowed",
+			func(s *Schema) { s.AdditionalProperties = nil },
+			[]*Change{{"/additionalProperties", F, "schema no longer rejects every value"}},
+		},
+		{
+			"bounds",
+			func(s *Schema) {
+				s.Properties["name"].MaxLength = Ptr(50)
+				s.Properties["name"].MinLength = Ptr(1)
+				s.Properties["age"].Minimum = Ptr(-1.0)
+			},
+			[]*Change{
+				{"/properties/age/minimum", F, "minimum loosened from 0 to -1"},
+				{"/properties/name/maxLength", B, "maxLength tightened from 100 to 50"},
+				{"/properties/name/minLength", B, "minLength 1 added"},
+			},
+		},
+		{
+			"pattern",
+			func(s *Schema) { s.Properties["name"].Pattern = "^[a-z]+$" },
+			[]*Change{{"/properties/name/pattern", B, `pattern "^[a-z]+$" added`}},
+		},
+		{
+			"not",
+			func(s *Schema) { s.Not = &Schema{Required: []string{"age", "color"}} },
+			[]*Change{{"/not", B, "not added"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := person()
+			tt.modify(s)
+			got := Compare(person(), s)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// The subschema of "not" reverses the direction of changes.
+	old := &Schema{Not: &Schema{Required: []string{"a"}}}
+	new := &Schema{Not: &Schema{Required: []string{"a", "b"}}}
+	want := []*Change{{"/not/required", F, `property "b" is now required`}}
+	if diff := cmp.Diff(want, Compare(old, new)); diff != "" {
+		t.Errorf("not: mismatch (-want +got):\n%s", diff)
+	}
+	// Identical schemas are compatible.
+	if got := Compare(person(), person()); got != nil {
+		t.Errorf("identical: got %v, want nil", got)
+	}
+}
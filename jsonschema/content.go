@@ -0,0 +1,57 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// This file implements the content vocabulary.
+// See https://json-schema.org/draft/2020-12/draft-bhutton-json-schema-validation-01#section-8.
+
+package jsonschema
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+)
+
+// decodeContent decodes s according to the value of a "contentEncoding" keyword.
+// It reports whether the encoding is known; unknown encodings are ignored.
+// Encoding names are case-insensitive, as in RFC 2045.
+func decodeContent(s, encoding string) (_ []byte, known bool, err error) {
+	switch strings.ToLower(encoding) {
+	case "7bit", "8bit", "binary":
+		return []byte(s), true, nil
+	case "base64":
+		// RFC 2045 allows line breaks in base64 content.
+		b, err := base64.StdEncoding.DecodeString(stripLineBreaks(s))
+		return b, true, err
+	case "base64url":
+		b, err := base64.URLEncoding.DecodeString(stripLineBreaks(s))
+		return b, true, err
+	case "base32":
+		b, err := base32.StdEncoding.DecodeString(stripLineBreaks(s))
+		return b, true, err
+	case "quoted-printable":
+		b, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(s)))
+		return b, true, err
+	default:
+		return nil, false, nil
+	}
+}
+
+func stripLineBreaks(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// isJSONMediaType reports whether the value of a "contentMediaType" keyword
+// denotes JSON: either application/json, or a type with the +json suffix
+// of RFC 6839.
+func isJSONMediaType(mediaType string) bool {
+	mt, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeContent(t *testing.T) {
+	for _, test := range []struct {
+		encoding string
+		in       string
+		want     string // if empty, decoding fails
+	}{
+		{"base64", "eyJhIjoxfQ==", `{"a":1}`},
+		{"BASE64", "eyJh\r\nIjoxfQ==", `{"a":1}`},
+		{"base64", "eyJhIjoxfQ", ""},
+		{"base64url", "Pz8-", "??>"},
+		{"base32", "MZXW6===", "foo"},
+		{"base32", "mzxw6", ""},
+		{"quoted-printable", "caf=C3=A9", "café"},
+		{"8bit", "abc", "abc"},
+	} {
+		got, known, err := decodeContent(test.in, test.encoding)
+		if !known {
+			t.Errorf("%s: unknown", test.encoding)
+			continue
+		}
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("%s %q: got %q, want error", test.encoding, test.in, got)
+			}
+		} else if err != nil {
+			t.Errorf("%s %q: %v", test.encoding, test.in, err)
+		} else if string(got) != test.want {
+			t.Errorf("%s %q: got %q, want %q", test.encoding, test.in, got, test.want)
+		}
+	}
+	if _, known, _ := decodeContent("abc", "uuencode"); known {
+		t.Error("uuencode: got known, want unknown")
+	}
+}
+
+func TestValidateContent(t *testing.T) {
+	schema := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"config": {
+				Type:             "string",
+				ContentMediaType: "application/json",
+				ContentSchema: &Schema{
+					Type:     "object",
+					Required: []string{"name"},
+				},
+			},
+			"file": {
+				Type:             "string",
+				ContentEncoding:  "base64",
+				ContentMediaType: "image/png",
+			},
+			"doc": {
+				Type:             "string",
+				ContentEncoding:  "base64",
+				ContentMediaType: "application/vnd.api+json; charset=utf-8",
+			},
+		},
+	}
+	valid := map[string]any{
+		"config": `{"name":"x"}`,
+		"file":   "iVBORw0KGgo=",
+		"doc":    "e30=", // {}
+	}
+
+	// By default, the content keywords are annotations.
+	rs, err := schema.Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Validate(map[string]any{"config": "{", "file": "!"}); err != nil {
+		t.Errorf("without ValidateContent: %v", err)
+	}
+
+	rs, err = schema.Resolve(&ResolveOptions{ValidateContent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Validate(valid); err != nil {
+		t.Errorf("valid instance: %v", err)
+	}
+	for _, test := range []struct {
+		instance    map[string]any
+		wantKeyword string
+	}{
+		{map[string]any{"config": "{"}, "/properties/config/contentMediaType"},
+		{map[string]any{"config": `{"nam":"x"}`}, "/properties/config/contentSchema/required"},
+		{map[string]any{"file": "not base64!"}, "/properties/file/contentEncoding"},
+		{map[string]any{"doc": "e30"}, "/properties/doc/contentEncoding"},
+		{map[string]any{"doc": "bm90IGpzb24="}, "/properties/doc/contentMediaType"},
+	} {
+		err := rs.Validate(test.instance)
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%v: got %v, want a *ValidationError", test.instance, err)
+			continue
+		}
+		var locs []string
+		for _, u := range verr.Errors() {
+			locs = append(locs, u.KeywordLocation)
+		}
+		if len(locs) != 1 || locs[0] != test.wantKeyword {
+			t.Errorf("%v: got failures at %s, want %s", test.instance, strings.Join(locs, ", "), test.wantKeyword)
+		}
+	}
+
+	// A document that is JSON null is validated, and reported, as null.
+	err = rs.Validate(map[string]any{"config": "null"})
+	if err == nil || !strings.Contains(err.Error(), "null has type") {
+		t.Errorf("null document: got %v, want a type error for null", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// This file describes the versions of JSON Schema that can be validated.
+
+package jsonschema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A dialect is a version of JSON Schema.
+// The zero value is the latest version, draft 2020-12.
+type dialect int
+
+const (
+	draft202012 dialect = iota
+	draft201909
+	draft7
+)
+
+// The URIs of the meta-schemas of the dialects, as they usually appear
+// in the "$schema" keyword.
+const (
+	draft202012URI = "https://json-schema.org/draft/2020-12/schema"
+	draft201909URI = "https://json-schema.org/draft/2019-09/schema"
+	draft7URI      = "http://json-schema.org/draft-07/schema#"
+)
+
+func (d dialect) String() string {
+	switch d {
+	case draft202012:
+		return draft202012URI
+	case draft201909:
+		return draft201909URI
+	case draft7:
+		return draft7URI
+	default:
+		return fmt.Sprintf("dialect(%d)", int(d))
+	}
+}
+
+// dialectFor returns the dialect whose meta-schema has the given URI, and
+// reports whether there is one.
+// The URI may have either the "http" or "https" scheme, and may end in an empty
+// fragment.
+func dialectFor(uri string) (dialect, bool) {
+	u := strings.TrimSuffix(uri, "#")
+	u, ok := strings.CutPrefix(u, "https://")
+	if !ok {
+		u, ok = strings.CutPrefix(u, "http://")
+	}
+	if !ok {
+		return 0, false
+	}
+	switch u {
+	case "json-schema.org/draft/2020-12/schema":
+		return draft202012, true
+	case "json-schema.org/draft/2019-09/schema":
+		return draft201909, true
+	case "json-schema.org/draft-07/schema":
+		return draft7, true
+	default:
+		return 0, false
+	}
+}
+
+// itemSchemas returns the schemas that apply to the items of an array in
+// dialect d, along with their keywords.
+// The prefix schemas apply to the items at the same indexes, and the rest
+// schema, if not nil, applies to the remaining items.
+func itemSchemas(s *Schema, d dialect) (prefix []*Schema, prefixKeyword string, rest *Schema, restKeyword string) {
+	if d == draft202012 {
+		return s.PrefixItems, "prefixItems", s.Items, "items"
+	}
+	// In earlier drafts, the array form of "items" is like "prefixItems",
+	// and "additionalItems" applies only along with it.
+	if s.ItemsArray != nil {
+		return s.ItemsArray, "items", s.AdditionalItems, "additionalItems"
+	}
+	return nil, "", s.Items, "items"
+}
@@ -6,13 +6,17 @@
 Package jsonschema is an implementation of the [JSON Schema specification],
 a JSON-based format for describing the structure of JSON data.
 The package can be used to read schemas for code generation, and to validate
-data using the draft 2020-12 specification. Validation with other drafts
+data using the draft 2020-12 specification. Drafts 2019-09 and 7 are also
+supported, for schemas that name them with "$schema" or when
+[ResolveOptions.DefaultDialect] is set. Validation with other drafts
 or custom meta-schemas is not supported.
 
 Construct a [Schema] as you would any Go struct (for example, by writing
 a struct literal), or unmarshal a JSON schema into a [Schema] in the usual
 way (with [encoding/json], for instance). It can then be used for code
-generation or other purposes without further processing.
+generation or other purposes without further processing. The
+[github.com/modelcontextprotocol/go-sdk/jsonschema/codegen] package generates
+Go types from schemas.
 You can also infer a schema from a Go struct.
 
 # Resolution
@@ -21,8 +25,17 @@ A Schema can refer to other schemas, both inside and outside itself. These
 references must be resolved before a schema can be used for validation.
 Call [Schema.Resolve] to obtain a resolved schema (called a [Resolved]).
 If the schema has external references, pass a [ResolveOptions] with a [Loader]
-to load them. To validate default values in a schema, set
-[ResolveOptions.ValidateDefaults] to true.
+to load them. [FSLoader] loads schemas from files, including embedded ones,
+[HTTPLoader] fetches them over the network, and [PrefixLoader] combines
+loaders. The draft 2020-12 meta-schemas are built in. To validate default values in a schema, set
+[ResolveOptions.ValidateDefaults] to true. To check strings against their
+"format", set [ResolveOptions.ValidateFormats] to true. To check encoded
+content, set [ResolveOptions.ValidateContent] to true.
+
+A Resolved can also produce a self-contained version of its schema:
+[Resolved.Bundle] copies the remote schemas it refers to into "$defs", and
+[Resolved.Dereference] replaces every reference with the schema it refers to,
+for consumers that do not understand "$ref".
 
 # Validation
 
@@ -46,6 +59,11 @@ or as a value of this type:
 		Scores []int  `json:"scores"`
 	}
 
+If the value is invalid, the error is a [*ValidationError] that describes
+every failure, including the locations of the failing keyword and of the part
+of the value it applied to. Its Basic and Detailed methods return the failures
+in the output formats described in [section 12 of the core spec].
+
 # Inference
 
 The [For] function returns a [Schema] describing the given Go type.
@@ -76,6 +94,21 @@ Use the "jsonschema" struct tag to provide a description for the property:
 		Scores []int  `json:"scores" jsonschema:"scores of player's games"`
 	}
 
+The tag can also set other keywords, like the enum, minimum, pattern or default
+of the property; see [For] for details. To control the schema of a type wherever
+it appears, use [RegisterTypeSchema].
+
+Recursive types are described with "$defs" and "$ref". [ForWithOptions] can
+also place the schemas of all named struct types in "$defs".
+
+# Compatibility
+
+[Compare] reports the changes between two versions of a schema that break
+compatibility: those that may cause the new schema to reject an instance that
+the old one accepted (breaking backward compatibility), or to accept one that
+the old one rejected (breaking forward compatibility). Use it in tests to
+check that a schema evolves safely.
+
 # Deviations from the specification
 
 Regular expressions are processed with Go's regexp package, which differs
@@ -83,15 +116,24 @@ from ECMA 262, most significantly in not supporting back-references.
 See [this table of differences] for more.
 
 The "format" keyword described in [section 7 of the validation spec] is recorded
-in the Schema, but is ignored during validation.
-It does not even produce [annotations].
-Use the "pattern" keyword instead: it will work more reliably across JSON Schema
-implementations. See [learnjsonschema.com] for more recommendations about "format".
+in the Schema. By default it is ignored during validation, and it does not even
+produce [annotations]. If [ResolveOptions.ValidateFormats] is true, strings are
+checked against the formats listed in that section, and against any custom
+formats in [ResolveOptions.Formats]; other formats are ignored.
+The "regex" format uses Go's regexp syntax, and "idn-hostname" is checked only
+approximately.
+If portability matters, prefer the "pattern" keyword: it will work more reliably
+across JSON Schema implementations. See [learnjsonschema.com] for more
+recommendations about "format".
 
 The content keywords described in [section 8 of the validation spec]
-are recorded in the schema, but ignored during validation.
+are recorded in the schema. By default they are ignored during validation.
+If [ResolveOptions.ValidateContent] is true, string content is decoded
+according to "contentEncoding" and, for JSON media types, parsed and validated
+against "contentSchema".
 
 [JSON Schema specification]: https://json-schema.org
+[section 12 of the core spec]: https://json-schema.org/draft/2020-12/json-schema-core#section-12
 [section 7 of the validation spec]: https://json-schema.org/draft/2020-12/draft-bhutton-json-schema-validation-00#rfc.section.7
 [section 8 of the validation spec]: https://json-schema.org/draft/2020-12/draft-bhutton-json-schema-validation-00#rfc.section.8
 [learnjsonschema.com]: https://www.learnjsonschema.com/2020-12/format-annotation/format/
@@ -0,0 +1,365 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// This file implements the format vocabulary.
+// See https://json-schema.org/draft/2020-12/draft-bhutton-json-schema-validation-01#section-7.
+
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A FormatChecker reports whether a string conforms to a format.
+// It returns a non-nil error describing the problem if it does not.
+// Checkers are called only for string instances.
+type FormatChecker func(string) error
+
+// formatCheckers holds the checkers for the formats defined in the 2020-12
+// validation specification.
+var formatCheckers = map[string]FormatChecker{
+	"date-time":             checkDateTime,
+	"date":                  checkDate,
+	"time":                  checkTime,
+	"duration":              checkDuration,
+	"email":                 func(s string) error { return checkEmail(s, false) },
+	"idn-email":             func(s string) error { return checkEmail(s, true) },
+	"hostname":              func(s string) error { return checkHostname(s, false) },
+	"idn-hostname":          func(s string) error { return checkHostname(s, true) },
+	"ipv4":                  checkIPv4,
+	"ipv6":                  checkIPv6,
+	"uri":                   func(s string) error { return checkURI(s, true, false) },
+	"uri-reference":         func(s string) error { return checkURI(s, false, false) },
+	"iri":                   func(s string) error { return checkURI(s, true, true) },
+	"iri-reference":         func(s string) error { return checkURI(s, false, true) },
+	"uuid":                  checkUUID,
+	"uri-template":          checkURITemplate,
+	"json-pointer":          checkJSONPointer,
+	"relative-json-pointer": checkRelativeJSONPointer,
+	"regex":                 checkRegex,
+}
+
+// formatsFor returns the format checkers to use for validation, or nil if
+// formats should not be asserted.
+func formatsFor(opts *ResolveOptions) map[string]FormatChecker {
+	if !opts.ValidateFormats {
+		return nil
+	}
+	if len(opts.Formats) == 0 {
+		return formatCheckers
+	}
+	m := make(map[string]FormatChecker, len(formatCheckers)+len(opts.Formats))
+	for name, c := range formatCheckers {
+		m[name] = c
+	}
+	for name, c := range opts.Formats {
+		m[name] = c
+	}
+	return m
+}
+
+var (
+	dateRegexp = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})$`)
+	timeRegexp = regexp.MustCompile(`^(\d{2}):(\d{2}):(\d{2})(?:\.\d+)?(?:[Zz]|([+-])(\d{2}):(\d{2}))$`)
+)
+
+// checkDateTime checks a "date-time" production of RFC 3339, section 5.6.
+func checkDateTime(s string) error {
+	i := strings.IndexAny(s, "Tt")
+	if i < 0 {
+		return errors.New("missing 'T' separator")
+	}
+	if err := checkDate(s[:i]); err != nil {
+		return err
+	}
+	return checkTime(s[i+1:])
+}
+
+// checkDate checks a "full-date" production of RFC 3339, section 5.6.
+func checkDate(s string) error {
+	m := dateRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return errors.New("not of the form YYYY-MM-DD")
+	}
+	year, month, day := atoi(m[1]), atoi(m[2]), atoi(m[3])
+	if month < 1 || month > 12 {
+		return fmt.Errorf("month %d out of range", month)
+	}
+	days := [...]int{31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}[month-1]
+	if month == 2 && year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+		days = 29
+	}
+	if day < 1 || day > days {
+		return fmt.Errorf("day %d out of range", day)
+	}
+	return nil
+}
+
+// checkTime checks a "full-time" production of RFC 3339, section 5.6.
+// A time zone offset is required.
+func checkTime(s string) error {
+	m := timeRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return errors.New("not of the form HH:MM:SS[.frac](Z|+HH:MM|-HH:MM)")
+	}
+	hour, min, sec := atoi(m[1]), atoi(m[2]), atoi(m[3])
+	if hour > 23 || min > 59 || sec > 60 {
+		return errors.New("time out of range")
+	}
+	var offHour, offMin int
+	if m[4] != "" {
+		offHour, offMin = atoi(m[5]), atoi(m[6])
+		if offHour > 23 || offMin > 59 {
+			return errors.New("time zone offset out of range")
+		}
+	}
+	if sec == 60 {
+		// Leap seconds occur only at the end of a UTC day.
+		t := hour*60 + min
+		switch m[4] {
+		case "+":
+			t -= offHour*60 + offMin
+		case "-":
+			t += offHour*60 + offMin
+		}
+		if (t+24*60)%(24*60) != 23*60+59 {
+			return errors.New("leap second not at 23:59:60 UTC")
+		}
+	}
+	return nil
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// durationRegexp matches the "duration" production of RFC 3339, appendix A.
+var durationRegexp = regexp.MustCompile(`^P(?:\d+W|` +
+	`(?:\d+Y(?:\d+M(?:\d+D)?)?|\d+M(?:\d+D)?|\d+D)(?:T(?:\d+H(?:\d+M(?:\d+S)?)?|\d+M(?:\d+S)?|\d+S))?|` +
+	`T(?:\d+H(?:\d+M(?:\d+S)?)?|\d+M(?:\d+S)?|\d+S))$`)
+
+func checkDuration(s string) error {
+	if !durationRegexp.MatchString(s) {
+		return errors.New("not an ISO 8601 duration")
+	}
+	return nil
+}
+
+var (
+	// The atext characters of RFC 5322, section 3.2.3.
+	dotAtomRegexp      = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+/=?^_`{|}~-]+(?:\\.[A-Za-z0-9!#$%&'*+/=?^_`{|}~-]+)*$")
+	quotedStringRegexp = regexp.MustCompile(`^"(?:[^"\\\x00-\x1f\x7f]|\\[\x20-\x7e])*"$`)
+)
+
+// checkEmail checks a "Mailbox" production of RFC 5321, section 4.1.2.
+// If idn is true, it also allows the internationalized addresses of RFC 6531.
+func checkEmail(s string, idn bool) error {
+	i := strings.LastIndexByte(s, '@')
+	if i < 0 {
+		return errors.New("missing '@'")
+	}
+	local, domain := s[:i], s[i+1:]
+	if local == "" {
+		return errors.New("empty local part")
+	}
+	atext := local
+	if idn {
+		// RFC 6531 extends atext with all non-ASCII UTF-8 characters.
+		atext = strings.Map(func(r rune) rune {
+			if r >= utf8.RuneSelf {
+				return 'a'
+			}
+			return r
+		}, local)
+	}
+	if !dotAtomRegexp.MatchString(atext) && !quotedStringRegexp.MatchString(atext) {
+		return fmt.Errorf("invalid local part %q", local)
+	}
+	if strings.HasPrefix(domain, "[") && strings.HasSuffix(domain, "]") {
+		lit := domain[1 : len(domain)-1]
+		if v6, ok := strings.CutPrefix(lit, "IPv6:"); ok {
+			return checkIPv6(v6)
+		}
+		return checkIPv4(lit)
+	}
+	return checkHostname(domain, idn)
+}
+
+// checkHostname checks a hostname as described in RFC 1123, section 2.1.
+// If idn is true, labels may also contain Unicode letters, marks and digits.
+// This is an approximation of the IDNA2008 rules of RFC 5890, which depend
+// on Unicode tables that the standard library does not provide.
+func checkHostname(s string, idn bool) error {
+	if idn {
+		// Label separators of RFC 3490, section 3.1.
+		s = strings.NewReplacer("。", ".", "．", ".", "｡", ".").Replace(s)
+	}
+	if s == "" {
+		return errors.New("empty hostname")
+	}
+	if len(s) > 253 {
+		return errors.New("hostname longer than 253 characters")
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" {
+			return errors.New("empty label")
+		}
+		if !idn && len(label) > 63 {
+			return fmt.Errorf("label %q longer than 63 characters", label)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Errorf("label %q begins or ends with a hyphen", label)
+		}
+		if len(label) >= 4 && label[2:4] == "--" && !strings.HasPrefix(strings.ToLower(label), "xn--") {
+			return fmt.Errorf("label %q has hyphens in the third and fourth positions", label)
+		}
+		for _, r := range label {
+			switch {
+			case r == '-', r < utf8.RuneSelf && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'):
+			case idn && r >= utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r)):
+			default:
+				return fmt.Errorf("label %q contains invalid character %q", label, r)
+			}
+		}
+		if idn && unicode.IsMark([]rune(label)[0]) {
+			return fmt.Errorf("label %q begins with a combining mark", label)
+		}
+	}
+	return nil
+}
+
+// checkIPv4 checks a "dotted-quad" as described in RFC 2673, section 3.2.
+func checkIPv4(s string) error {
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return err
+	}
+	if !a.Is4() {
+		return errors.New("not an IPv4 address")
+	}
+	return nil
+}
+
+// checkIPv6 checks an IPv6 address as described in RFC 4291, section 2.2.
+func checkIPv6(s string) error {
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return err
+	}
+	if !a.Is6() {
+		return errors.New("not an IPv6 address")
+	}
+	if a.Zone() != "" {
+		return errors.New("zone not allowed")
+	}
+	return nil
+}
+
+// checkURI checks a URI (RFC 3986) or IRI (RFC 3987).
+// If abs is true, the URI must have a scheme.
+func checkURI(s string, abs, iri bool) error {
+	for _, r := range s {
+		if r < 0x21 || r == 0x7f || strings.ContainsRune(`"<>\^`+"`{|}", r) {
+			return fmt.Errorf("invalid character %q", r)
+		}
+		if !iri && r >= utf8.RuneSelf {
+			return fmt.Errorf("non-ASCII character %q", r)
+		}
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if abs && u.Scheme == "" {
+		return errors.New("missing scheme")
+	}
+	return nil
+}
+
+var uuidRegexp = regexp.MustCompile(`^[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}$`)
+
+// checkUUID checks a UUID as described in RFC 4122, section 3.
+func checkUUID(s string) error {
+	if !uuidRegexp.MatchString(s) {
+		return errors.New("not of the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx")
+	}
+	return nil
+}
+
+// uriTemplateExprRegexp matches the contents of an RFC 6570 expression
+// (section 2.2): an optional operator followed by a list of varspecs.
+var uriTemplateExprRegexp = regexp.MustCompile(`^[+#./;?&=,!@|]?` +
+	`(?:[A-Za-z0-9_]|%[0-9A-Fa-f]{2})(?:\.?(?:[A-Za-z0-9_]|%[0-9A-Fa-f]{2}))*(?::[1-9][0-9]{0,3}|\*)?` +
+	`(?:,(?:[A-Za-z0-9_]|%[0-9A-Fa-f]{2})(?:\.?(?:[A-Za-z0-9_]|%[0-9A-Fa-f]{2}))*(?::[1-9][0-9]{0,3}|\*)?)*$`)
+
+// checkURITemplate checks a URI template as described in RFC 6570.
+func checkURITemplate(s string) error {
+	for s != "" {
+		i := strings.IndexAny(s, "{}")
+		if i < 0 {
+			break
+		}
+		if s[i] == '}' {
+			return errors.New("unmatched '}'")
+		}
+		j := strings.IndexAny(s[i+1:], "{}")
+		if j < 0 || s[i+1+j] == '{' {
+			return errors.New("unclosed '{'")
+		}
+		if expr := s[i+1 : i+1+j]; !uriTemplateExprRegexp.MatchString(expr) {
+			return fmt.Errorf("invalid expression %q", expr)
+		}
+		s = s[i+1+j+1:]
+	}
+	for _, r := range s {
+		if r < 0x21 || r == 0x7f || strings.ContainsRune(`"'<>\^`+"`|", r) {
+			return fmt.Errorf("invalid character %q", r)
+		}
+	}
+	return nil
+}
+
+// checkJSONPointer checks a JSON Pointer as described in RFC 6901, section 3.
+func checkJSONPointer(s string) error {
+	if s != "" && s[0] != '/' {
+		return errors.New("does not begin with '/'")
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '~' && (i+1 == len(s) || s[i+1] != '0' && s[i+1] != '1') {
+			return errors.New("'~' not followed by '0' or '1'")
+		}
+	}
+	return nil
+}
+
+var relativeJSONPointerPrefixRegexp = regexp.MustCompile(`^(?:0|[1-9][0-9]*)`)
+
+// checkRelativeJSONPointer checks a Relative JSON Pointer as described in
+// https://datatracker.ietf.org/doc/html/draft-handrews-relative-json-pointer-01.
+func checkRelativeJSONPointer(s string) error {
+	prefix := relativeJSONPointerPrefixRegexp.FindString(s)
+	if prefix == "" {
+		return errors.New("does not begin with a non-negative integer")
+	}
+	if rest := s[len(prefix):]; rest != "#" {
+		return checkJSONPointer(rest)
+	}
+	return nil
+}
+
+// checkRegex checks a regular expression.
+// As with the "pattern" keyword, Go's regexp syntax is used instead of ECMA 262.
+func checkRegex(s string) error {
+	_, err := regexp.Compile(s)
+	return err
+}
@@ -0,0 +1,198 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFormatCheckers(t *testing.T) {
+	for _, test := range []struct {
+		format  string
+		valid   []string
+		invalid []string
+	}{
+		{
+			"date-time",
+			[]string{"1963-06-19T08:30:06.283185Z", "1963-06-19t08:30:06z", "1998-12-31T23:59:60Z", "1998-12-31T15:59:60.123-08:00"},
+			[]string{"1963-06-19", "1963-06-19T08:30:06", "1990-02-31T15:59:59Z", "1998-12-31T22:59:60Z", "06/19/1963 08:30:06 PST"},
+		},
+		{
+			"date",
+			[]string{"1963-06-19", "2020-02-29", "2000-02-29"},
+			[]string{"1963-6-19", "2021-02-29", "1900-02-29", "2020-13-01", "2020-00-10", "1963-06-1৪"},
+		},
+		{
+			"time",
+			[]string{"08:30:06Z", "08:30:06.5+01:00", "23:59:60Z", "01:29:60+01:30"},
+			[]string{"08:30:06", "24:00:00Z", "08:60:00Z", "08:30:06+24:00", "12:00:60Z"},
+		},
+		{
+			"duration",
+			[]string{"P4DT12H30M5S", "P1Y", "PT1M", "P2W", "PT36H"},
+			[]string{"P", "PT", "P1D2H", "P2W1D", "4DT12H", "P1S"},
+		},
+		{
+			"email",
+			[]string{"joe.bloggs@example.com", "te~st@example.com", `"joe bloggs"@example.com`, "joe@[127.0.0.1]", "joe@[IPv6:::1]"},
+			[]string{"2962", ".test@example.com", "te..st@example.com", "joe@-example.com", "@example.com", "joe@[300.0.0.1]"},
+		},
+		{
+			"idn-email",
+			[]string{"실례@실례.테스트", "joe@example.com"},
+			[]string{"2962", "실례@-실례.테스트"},
+		},
+		{
+			"hostname",
+			[]string{"www.example.com", "xn--4gbwdl.xn--wgbh1c", "a"},
+			[]string{"-a-host-name", "not_a_valid_host_name", "", ".", "a..b", strings.Repeat("a", 64), "ab--cd", "실례.테스트"},
+		},
+		{
+			"idn-hostname",
+			[]string{"실례.테스트", "www.example.com", "실례。테스트"},
+			[]string{"-실례.테스트", "실례..테스트", "̀hello", "a b"},
+		},
+		{
+			"ipv4",
+			[]string{"192.168.0.1", "0.0.0.0"},
+			[]string{"127.0.0.0.1", "256.256.256.256", "087.10.0.1", "::1", "1"},
+		},
+		{
+			"ipv6",
+			[]string{"::1", "::", "1:2:3:4:5:6:7:8", "::ffff:192.168.0.1"},
+			[]string{"12345::", "1:2:3:4:5:6:7:8:9", "::laptop", "fe80::1%eth0", "127.0.0.1"},
+		},
+		{
+			"uri",
+			[]string{"http://foo.bar/?baz=qux#quux", "urn:isbn:0451450523", "mailto:joe@example.com", "http://[2001:db8::1]/"},
+			[]string{"//foo.bar/?baz=qux#quux", "/abc", "http://example.com/a b", "http://exämple.com", "http://example.com/%zz"},
+		},
+		{
+			"uri-reference",
+			[]string{"http://foo.bar", "/abc", "#frag", "", "../x?y"},
+			[]string{`\\WINDOWS\fileshare`, "#frag\\ment", "a b"},
+		},
+		{
+			"iri",
+			[]string{"http://ƒøø.ßår/?∂éœ=πîx#πîüx", "http://example.com"},
+			[]string{"/abc", "http://ƒøø.ßår/a b"},
+		},
+		{
+			"iri-reference",
+			[]string{"http://ƒøø.ßår", "//ƒøø.ßår/", "#ƒrägmênt"},
+			[]string{`\\WINDOWS\filëßåré`, "#ƒräg\\mênt"},
+		},
+		{
+			"uuid",
+			[]string{"2EB8AA08-AA98-11EA-B4AA-73B441D16380", "2eb8aa08-aa98-11ea-b4aa-73b441d16380", "00000000-0000-0000-0000-000000000000"},
+			[]string{"2eb8aa08-aa98-11ea-b4aa-73b441d1638", "2eb8aa08aa9811eab4aa73b441d16380", "2eb8aa08-aa98-11ea-b4ga-73b441d16380"},
+		},
+		{
+			"uri-template",
+			[]string{"http://example.com/dictionary/{term:1}/{term}", "http://example.com/{+path}{?q,lang}", "/{a.b*}", "plain"},
+			[]string{"http://example.com/dictionary/{term:1}/{term", "http://example.com/}", "{}", "{a b}", "{x:0}"},
+		},
+		{
+			"json-pointer",
+			[]string{"", "/", "/foo/bar~0/baz~1/%a", "/foo//bar"},
+			[]string{"foo", "/foo/bar~", "/~2", "#/foo"},
+		},
+		{
+			"relative-json-pointer",
+			[]string{"1", "0/foo/bar", "2/0/baz/1/zip", "0#", "120/foo"},
+			[]string{"/foo/bar", "-1/foo", "01/a", "0##", "1foo"},
+		},
+		{
+			"regex",
+			[]string{`([abc])+\s+$`, ""},
+			[]string{`^(abc]`, `a**`},
+		},
+	} {
+		check := formatCheckers[test.format]
+		for _, s := range test.valid {
+			if err := check(s); err != nil {
+				t.Errorf("%s: %q: got %v, want valid", test.format, s, err)
+			}
+		}
+		for _, s := range test.invalid {
+			if err := check(s); err == nil {
+				t.Errorf("%s: %q: got valid, want error", test.format, s)
+			}
+		}
+	}
+}
+
+func TestValidateFormats(t *testing.T) {
+	schema := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"when":  {Format: "date-time"},
+			"id":    {Format: "uuid"},
+			"color": {Format: "color"},
+		},
+	}
+	valid := map[string]any{"when": "2025-06-19T08:30:00Z", "id": "2eb8aa08-aa98-11ea-b4aa-73b441d16380", "color": "red"}
+	invalid := map[string]any{"when": "yesterday"}
+
+	// By default, format is an annotation.
+	rs, err := schema.Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Validate(invalid); err != nil {
+		t.Errorf("without ValidateFormats: %v", err)
+	}
+
+	rs, err = schema.Resolve(&ResolveOptions{ValidateFormats: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Validate(valid); err != nil {
+		t.Errorf("valid instance: %v", err)
+	}
+	err = rs.Validate(invalid)
+	if want := `"yesterday" is not a valid date-time`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want one containing %q", err, want)
+	}
+	// Formats apply only to strings.
+	if err := rs.Validate(map[string]any{"when": 1}); err != nil {
+		t.Errorf("non-string: %v", err)
+	}
+
+	// Custom formats.
+	errNotColor := errors.New("not a color")
+	rs, err = schema.Resolve(&ResolveOptions{
+		ValidateFormats: true,
+		Formats: map[string]FormatChecker{
+			"color": func(s string) error {
+				if s != "red" && s != "green" && s != "blue" {
+					return errNotColor
+				}
+				return nil
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Validate(valid); err != nil {
+		t.Errorf("valid instance: %v", err)
+	}
+	if err := rs.Validate(map[string]any{"color": "mauve"}); !errors.Is(err, errNotColor) {
+		t.Errorf("got error %v, want %v", err, errNotColor)
+	}
+	// Custom formats apply only to strings, too.
+	if err := rs.Validate(map[string]any{"color": 3}); err != nil {
+		t.Errorf("non-string color: %v", err)
+	}
+
+	// Defaults are checked against formats too.
+	schema = &Schema{Format: "ipv4", Default: mustMarshal("localhost")}
+	if _, err := schema.Resolve(&ResolveOptions{ValidateDefaults: true, ValidateFormats: true}); err == nil {
+		t.Error("invalid default: got nil error")
+	}
+}
@@ -7,11 +7,18 @@
 package jsonschema
 
 import (
+	"encoding"
+	"encoding/json"
 	"fmt"
+	"math/big"
+	"net/url"
 	"reflect"
 	"regexp"
-
-	"github.com/modelcontextprotocol/go-sdk/internal/util"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 // For constructs a JSON schema object for the given type argument.
@@ -29,6 +36,17 @@ import (
 //     Their properties are derived from exported struct fields, using the
 //     struct field JSON name. Fields that are marked "omitempty" are
 //     considered optional; all other fields become required properties.
+//     As with encoding/json, the fields of embedded structs without a JSON
+//     name are promoted to the outer struct.
+//
+// Some types are treated specially:
+//   - Types registered with [RegisterTypeSchema] get the registered schema.
+//     By default, [time.Time] has type "string" and format "date-time",
+//     [url.URL] has type "string" and format "uri", [big.Int] has type
+//     "integer", and [json.RawMessage] is unrestricted.
+//   - Other types that implement [json.Marshaler] are unrestricted, since
+//     their JSON form cannot be known.
+//   - Other types that implement [encoding.TextMarshaler] have type "string".
 //
 // For returns an error if t contains (possibly recursively) any of the following Go
 // types, as they are incompatible with the JSON schema spec.
@@ -38,16 +56,42 @@ import (
 //   - complex numbers
 //   - unsafe pointers
 //
-// It will return an error if there is a cycle in the types.
+// The schema of a recursive type is placed in the "$defs" of the result, and
+// referred to with "$ref" wherever the type appears. (If the type is T itself,
+// the reference is to the root schema, "#".) A pointer to such a type is
+// described by an "anyOf" that also allows null. To place every named struct
+// type in "$defs", use [ForWithOptions].
 //
 // This function recognizes struct field tags named "jsonschema".
-// A jsonschema tag on a field is used as the description for the corresponding property.
-// For future compatibility, descriptions must not start with "WORD=", where WORD is a
-// sequence of non-whitespace characters.
+// A jsonschema tag on a field is used as the description for the corresponding property,
+// unless it starts with "WORD=", where WORD is a sequence of non-whitespace
+// characters. In that case the tag is a comma-separated list of key=value pairs
+// that set keywords of the property's schema:
+//
+//	description=TEXT    the description
+//	title=TEXT          the title
+//	format=NAME         the format
+//	pattern=REGEXP      the pattern
+//	minimum=NUMBER      the minimum
+//	maximum=NUMBER      the maximum
+//	default=VALUE       the default
+//	enum=VALUE|...      the allowed values
+//	examples=VALUE|...  example values
+//	deprecated[=BOOL]   whether the property is deprecated
+//
+// A backslash escapes the following character, so \, and \| stand for a literal
+// comma and vertical bar. (Struct tag values are quoted strings, so within a tag
+// the backslash itself is written \\.)
+// If the property's type allows strings, a VALUE is the string itself.
+// Otherwise it is a JSON value, like 1 or true.
+// For example:
+//
+//	type Options struct {
+//		Unit  string `json:"unit" jsonschema:"enum=metric|imperial,default=metric,description=system of units"`
+//		Limit int    `json:"limit,omitempty" jsonschema:"minimum=1,maximum=100,title=Limit"`
+//	}
 func For[T any]() (*Schema, error) {
-	// TODO: consider skipping incompatible fields, instead of failing.
-	seen := make(map[reflect.Type]bool)
-	s, err := forType(reflect.TypeFor[T](), seen, false)
+	s, err := forOptions(reflect.TypeFor[T](), &ForOptions{})
 	if err != nil {
 		var z T
 		return nil, fmt.Errorf("For[%T](): %w", z, err)
@@ -60,9 +104,7 @@ func For[T any]() (*Schema, error) {
 // For example, an interface type where all the possible implementations are known
 // can be described with "oneof".
 func ForLax[T any]() (*Schema, error) {
-	// TODO: consider skipping incompatible fields, instead of failing.
-	seen := make(map[reflect.Type]bool)
-	s, err := forType(reflect.TypeFor[T](), seen, true)
+	s, err := forOptions(reflect.TypeFor[T](), &ForOptions{IgnoreInvalidTypes: true})
 	if err != nil {
 		var z T
 		return nil, fmt.Errorf("ForLax[%T](): %w", z, err)
@@ -70,7 +112,71 @@ func ForLax[T any]() (*Schema, error) {
 	return s, nil
 }
 
-func forType(t reflect.Type, seen map[reflect.Type]bool, lax bool) (*Schema, error) {
+// ForOptions are options for [ForWithOptions].
+type ForOptions struct {
+	// IgnoreInvalidTypes causes struct fields with invalid types to be
+	// ignored, as with [ForLax].
+	IgnoreInvalidTypes bool
+	// UseDefs causes the schema of every named struct type other than T to be
+	// placed in the "$defs" of the result, and referred to with "$ref".
+	// That makes the schema smaller when a type is used more than once.
+	// Recursive types are always referred to this way.
+	UseDefs bool
+}
+
+// ForWithOptions behaves like [For], with the given options.
+// A nil opts is equivalent to an empty ForOptions.
+func ForWithOptions[T any](opts *ForOptions) (*Schema, error) {
+	if opts == nil {
+		opts = &ForOptions{}
+	}
+	s, err := forOptions(reflect.TypeFor[T](), opts)
+	if err != nil {
+		var z T
+		return nil, fmt.Errorf("ForWithOptions[%T](): %w", z, err)
+	}
+	return s, nil
+}
+
+func forOptions(t reflect.Type, opts *ForOptions) (*Schema, error) {
+	// TODO: consider skipping incompatible fields, instead of failing.
+	inf := &inferrer{
+		opts:      *opts,
+		root:      t,
+		seen:      map[reflect.Type]bool{},
+		recursive: map[reflect.Type]bool{},
+		defNames:  map[reflect.Type]string{},
+		usedNames: map[string]bool{},
+		defs:      map[string]*Schema{},
+	}
+	for inf.root.Kind() == reflect.Pointer {
+		inf.root = inf.root.Elem()
+	}
+	s, err := inf.forType(t)
+	if err != nil || s == nil {
+		return s, err
+	}
+	if len(inf.defs) > 0 {
+		s.Defs = inf.defs
+	}
+	return s, nil
+}
+
+// An inferrer holds the state of a call to [ForWithOptions].
+type inferrer struct {
+	opts ForOptions
+	root reflect.Type // the type argument, with pointers removed
+	// Named types whose schemas are being computed.
+	seen map[reflect.Type]bool
+	// Named types that are referred to while their schemas are being computed.
+	recursive map[reflect.Type]bool
+	// The names of the types whose schemas are in defs.
+	defNames  map[reflect.Type]string
+	usedNames map[string]bool
+	defs      map[string]*Schema
+}
+
+func (inf *inferrer) forType(t reflect.Type) (*Schema, error) {
 	// Follow pointers: the schema for *T is almost the same as for T, except that
 	// an explicit JSON "null" is allowed for the pointer.
 	allowNull := false
@@ -79,16 +185,110 @@ func forType(t reflect.Type, seen map[reflect.Type]bool, lax bool) (*Schema, err
 		t = t.Elem()
 	}
 
-	// Check for cycles
-	// User defined types have a name, so we can skip those that are natively defined
-	if t.Name() != "" {
-		if seen[t] {
-			return nil, fmt.Errorf("cycle detected for type %v", t)
+	s, err := inf.forNonPointer(t)
+	if s == nil || err != nil {
+		return nil, err
+	}
+	if allowNull {
+		if s.Ref != "" {
+			// The referenced schema doesn't allow null.
+			s = &Schema{AnyOf: []*Schema{{Type: "null"}, s}}
+		} else if s.Type != "" {
+			s.Types = []string{"null", s.Type}
+			s.Type = ""
+		}
+	}
+	return s, nil
+}
+
+// forNonPointer returns the schema for t, which is not a pointer type.
+// The schema may be a reference to a schema in inf.defs.
+func (inf *inferrer) forNonPointer(t reflect.Type) (*Schema, error) {
+	// User-defined types have a name, so we can skip those that are natively defined.
+	// Only named types can be recursive.
+	if t.Name() == "" {
+		return inf.forValueType(t)
+	}
+	if _, ok := inf.defNames[t]; ok {
+		return inf.ref(t), nil
+	}
+	if inf.seen[t] {
+		// A cycle: refer to the schema being computed.
+		inf.recursive[t] = true
+		return inf.ref(t), nil
+	}
+	inf.seen[t] = true
+	s, err := inf.forValueType(t)
+	delete(inf.seen, t)
+	if s == nil || err != nil || t == inf.root {
+		return s, err
+	}
+	// With UseDefs, move only the schemas of plain structs: not those of
+	// well-known types or marshalers.
+	if inf.recursive[t] || inf.opts.UseDefs && t.Kind() == reflect.Struct && s.Type == "object" {
+		inf.defs[inf.defName(t)] = s
+		return inf.ref(t), nil
+	}
+	return s, nil
+}
+
+// ref returns a schema that refers to the schema for the named type t.
+func (inf *inferrer) ref(t reflect.Type) *Schema {
+	if t == inf.root {
+		return &Schema{Ref: "#"}
+	}
+	return &Schema{Ref: "#/$defs/" + escapeJSONPointerSegment(inf.defName(t))}
+}
+
+// defName returns the name of t in the "$defs" of the schema, choosing
+// it if necessary.
+func (inf *inferrer) defName(t reflect.Type) string {
+	if name, ok := inf.defNames[t]; ok {
+		return name
+	}
+	// Type names of generic instantiations can contain arbitrary characters;
+	// keep the names simple.
+	base := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' || r == '.' {
+			return r
 		}
-		seen[t] = true
-		defer delete(seen, t)
+		return '_'
+	}, t.Name())
+	name := base
+	for i := 2; inf.usedNames[name]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
 	}
+	inf.defNames[t] = name
+	inf.usedNames[name] = true
+	return name
+}
 
+// forValueType returns the schema for t, which is not a pointer type,
+// without considering whether t is named.
+func (inf *inferrer) forValueType(t reflect.Type) (*Schema, error) {
+	var (
+		s   = new(Schema)
+		err error
+	)
+
+	if rs, ok := typeSchemas.Load(t); ok {
+		s = rs.(*Schema).clone()
+	} else if implements(t, marshalerType) {
+		// Unrestricted: we can't know what the MarshalJSON method produces.
+	} else if implements(t, textMarshalerType) {
+		s.Type = "string"
+	} else {
+		s, err = inf.forKind(t)
+		if s == nil || err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// forKind returns the schema for t based on its kind.
+func (inf *inferrer) forKind(t reflect.Type) (*Schema, error) {
+	lax := inf.opts.IgnoreInvalidTypes
 	var (
 		s   = new(Schema)
 		err error
@@ -116,10 +316,8 @@ func forType(t reflect.Type, seen map[reflect.Type]bool, lax bool) (*Schema, err
 			}
 			return nil, fmt.Errorf("unsupported map key type %v", t.Key().Kind())
 		}
-		if t.Key().Kind() != reflect.String {
-		}
 		s.Type = "object"
-		s.AdditionalProperties, err = forType(t.Elem(), seen, lax)
+		s.AdditionalProperties, err = inf.forType(t.Elem())
 		if err != nil {
 			return nil, fmt.Errorf("computing map value schema: %v", err)
 		}
@@ -130,7 +328,7 @@ func forType(t reflect.Type, seen map[reflect.Type]bool, lax bool) (*Schema, err
 
 	case reflect.Slice, reflect.Array:
 		s.Type = "array"
-		s.Items, err = forType(t.Elem(), seen, lax)
+		s.Items, err = inf.forType(t.Elem())
 		if err != nil {
 			return nil, fmt.Errorf("computing element schema: %v", err)
 		}
@@ -151,16 +349,12 @@ func forType(t reflect.Type, seen map[reflect.Type]bool, lax bool) (*Schema, err
 		// no additional properties are allowed
 		s.AdditionalProperties = falseSchema()
 
-		for i := range t.NumField() {
-			field := t.Field(i)
-			info := util.FieldJSONInfo(field)
-			if info.Omit {
-				continue
-			}
+		for _, f := range jsonFields(t) {
+			field, info := f.sf, f.info
 			if s.Properties == nil {
 				s.Properties = make(map[string]*Schema)
 			}
-			fs, err := forType(field.Type, seen, lax)
+			fs, err := inf.forType(field.Type)
 			if err != nil {
 				return nil, err
 			}
@@ -172,10 +366,9 @@ func forType(t reflect.Type, seen map[reflect.Type]bool, lax bool) (*Schema, err
 				if tag == "" {
 					return nil, fmt.Errorf("empty jsonschema tag on struct field %s.%s", t, field.Name)
 				}
-				if disallowedPrefixRegexp.MatchString(tag) {
-					return nil, fmt.Errorf("tag must not begin with 'WORD=': %q", tag)
+				if err := applyTag(fs, tag); err != nil {
+					return nil, fmt.Errorf("jsonschema tag on struct field %s.%s: %w", t, field.Name, err)
 				}
-				fs.Description = tag
 			}
 			s.Properties[info.Name] = fs
 			if !info.Settings["omitempty"] && !info.Settings["omitzero"] {
@@ -190,12 +383,165 @@ func forType(t reflect.Type, seen map[reflect.Type]bool, lax bool) (*Schema, err
 		}
 		return nil, fmt.Errorf("type %v is unsupported by jsonschema", t)
 	}
-	if allowNull && s.Type != "" {
-		s.Types = []string{"null", s.Type}
-		s.Type = ""
-	}
 	return s, nil
 }
 
-// Disallow jsonschema tag values beginning "WORD=", for future expansion.
-var disallowedPrefixRegexp = regexp.MustCompile("^[^ \t\n]*=")
+var (
+	marshalerType     = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// implements reports whether t or *t implements the interface type it.
+func implements(t, it reflect.Type) bool {
+	return t.Implements(it) || t.Kind() != reflect.Interface && reflect.PointerTo(t).Implements(it)
+}
+
+var typeSchemas sync.Map // from reflect.Type to *Schema
+
+func init() {
+	RegisterTypeSchema(reflect.TypeFor[time.Time](), &Schema{Type: "string", Format: "date-time"})
+	RegisterTypeSchema(reflect.TypeFor[url.URL](), &Schema{Type: "string", Format: "uri"})
+	RegisterTypeSchema(reflect.TypeFor[big.Int](), &Schema{Type: "integer"})
+	RegisterTypeSchema(reflect.TypeFor[json.RawMessage](), &Schema{})
+}
+
+// RegisterTypeSchema arranges for [For] and [ForLax] to use s as the schema for t,
+// wherever t appears. A pointer to t gets the same schema, except that it
+// also allows null.
+// Each use gets a copy of s, so s may be modified after RegisterTypeSchema returns
+// without affecting later calls.
+// If s is nil, the registration for t is removed, and For reverts to its
+// usual rules for t.
+//
+// RegisterTypeSchema is typically called during program initialization.
+// It is safe to call concurrently with For.
+func RegisterTypeSchema(t reflect.Type, s *Schema) {
+	if s == nil {
+		typeSchemas.Delete(t)
+	} else {
+		typeSchemas.Store(t, s.clone())
+	}
+}
+
+// keyValueTagRegexp matches jsonschema tags that consist of key=value pairs,
+// rather than a description.
+var keyValueTagRegexp = regexp.MustCompile("^[^ \t\n]*=")
+
+// applyTag sets the keywords of s from the value of a jsonschema struct tag.
+func applyTag(s *Schema, tag string) error {
+	if !keyValueTagRegexp.MatchString(tag) {
+		s.Description = tag
+		return nil
+	}
+	for _, pair := range splitEscaped(tag, ',') {
+		key, value, hasValue := strings.Cut(pair, "=")
+		if !hasValue && key != "deprecated" {
+			return fmt.Errorf("%q: missing '='", pair)
+		}
+		var err error
+		switch key {
+		case "description":
+			s.Description = unescape(value)
+		case "title":
+			s.Title = unescape(value)
+		case "format":
+			s.Format = unescape(value)
+		case "pattern":
+			s.Pattern = unescape(value)
+			_, err = regexp.Compile(s.Pattern)
+		case "minimum":
+			s.Minimum, err = parseNumber(value)
+		case "maximum":
+			s.Maximum, err = parseNumber(value)
+		case "default":
+			var v any
+			if v, err = tagValue(s, value); err == nil {
+				s.Default, err = json.Marshal(v)
+			}
+		case "enum", "examples":
+			var vs []any
+			for _, e := range splitEscaped(value, '|') {
+				v, err := tagValue(s, e)
+				if err != nil {
+					return fmt.Errorf("%s: %w", key, err)
+				}
+				vs = append(vs, v)
+			}
+			if key == "enum" {
+				s.Enum = vs
+			} else {
+				s.Examples = vs
+			}
+		case "deprecated":
+			s.Deprecated = true
+			if hasValue {
+				s.Deprecated, err = strconv.ParseBool(value)
+			}
+		default:
+			return fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// tagValue returns the value of an enum, default or example in a jsonschema tag.
+// If the schema allows strings, the value is the unescaped string.
+// Otherwise, it is parsed as JSON; if the schema has no type, an invalid JSON
+// value is treated as a string.
+func tagValue(s *Schema, value string) (any, error) {
+	value = unescape(value)
+	if s.Type == "string" || slices.Contains(s.Types, "string") {
+		return value, nil
+	}
+	var v any
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		if s.Type == "" && s.Types == nil {
+			return value, nil
+		}
+		return nil, fmt.Errorf("%q is not a valid JSON value", value)
+	}
+	return v, nil
+}
+
+func parseNumber(s string) (*float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// splitEscaped splits s at each occurrence of sep that is not preceded by a
+// backslash. Escapes are preserved in the result.
+func splitEscaped(s string, sep byte) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++ // skip the escaped character
+		case sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unescape removes the backslashes from s that escape the following character.
+func unescape(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
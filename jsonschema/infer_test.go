@@ -5,8 +5,13 @@
 package jsonschema_test
 
 import (
+	"encoding/json"
+	"math/big"
+	"net/url"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -109,16 +114,89 @@ func TestFor(t *testing.T) {
 							Required:             []string{"B"},
 							AdditionalProperties: falseSchema(),
 						},
-						"S": {
-							Type: "object",
-							Properties: map[string]*schema{
-								"B": {Type: "integer", Description: "bdesc"},
-							},
-							Required:             []string{"B"},
-							AdditionalProperties: falseSchema(),
+						// S is embedded, so its fields are promoted.
+						"B": {Type: "integer", Description: "bdesc"},
+					},
+					Required:             []string{"A", "B"},
+					AdditionalProperties: falseSchema(),
+				},
+			},
+			{
+				"embedded conflicts",
+				forType[struct {
+					Inner  // X is hidden by the outer X
+					*Other // Z conflicts with Inner.Z at the same depth, so neither appears
+					X      string
+				}](lax),
+				&schema{
+					Type: "object",
+					Properties: map[string]*schema{
+						"X": {Type: "string"},
+						"y": {Type: "integer"},
+						"Y": {Type: "string"},
+					},
+					Required:             []string{"y", "Y", "X"},
+					AdditionalProperties: falseSchema(),
+				},
+			},
+			{
+				"tags",
+				forType[struct {
+					Unit   string   `json:"unit" jsonschema:"enum=metric|imperial,default=metric,description=system of units\\, e.g. metric"`
+					Limit  int      `json:"limit,omitempty" jsonschema:"minimum=1,maximum=100,title=Limit,examples=10|20"`
+					Ratio  *float64 `json:"ratio" jsonschema:"enum=0.5|1|null"`
+					Code   string   `json:"code" jsonschema:"pattern=^[a-z]{2\\,3}$,deprecated"`
+					Email  string   `json:"email" jsonschema:"format=email,deprecated=false"`
+					Legacy string   `json:"legacy" jsonschema:"a plain description"`
+				}](lax),
+				&schema{
+					Type: "object",
+					Properties: map[string]*schema{
+						"unit": {
+							Type:        "string",
+							Enum:        []any{"metric", "imperial"},
+							Default:     json.RawMessage(`"metric"`),
+							Description: "system of units, e.g. metric",
+						},
+						"limit": {
+							Type:     "integer",
+							Minimum:  jsonschema.Ptr(1.0),
+							Maximum:  jsonschema.Ptr(100.0),
+							Title:    "Limit",
+							Examples: []any{10.0, 20.0},
 						},
+						"ratio":  {Types: []string{"null", "number"}, Enum: []any{0.5, 1.0, nil}},
+						"code":   {Type: "string", Pattern: "^[a-z]{2,3}$", Deprecated: true},
+						"email":  {Type: "string", Format: "email"},
+						"legacy": {Type: "string", Description: "a plain description"},
 					},
-					Required:             []string{"A", "S"},
+					Required:             []string{"unit", "ratio", "code", "email", "legacy"},
+					AdditionalProperties: falseSchema(),
+				},
+			},
+			{
+				"well-known types",
+				forType[struct {
+					T  time.Time
+					PT *time.Time
+					U  url.URL
+					B  *big.Int
+					R  json.RawMessage
+					M  marshaler
+					TM textMarshaler
+				}](lax),
+				&schema{
+					Type: "object",
+					Properties: map[string]*schema{
+						"T":  {Type: "string", Format: "date-time"},
+						"PT": {Types: []string{"null", "string"}, Format: "date-time"},
+						"U":  {Type: "string", Format: "uri"},
+						"B":  {Types: []string{"null", "integer"}},
+						"R":  {},
+						"M":  {},
+						"TM": {Type: "string"},
+					},
+					Required:             []string{"T", "PT", "U", "B", "R", "M", "TM"},
 					AdditionalProperties: falseSchema(),
 				},
 			},
@@ -164,6 +242,59 @@ func TestFor(t *testing.T) {
 	})
 }
 
+type (
+	Inner struct {
+		X int
+		Y int `json:"y"`
+		Z int
+	}
+	Other struct {
+		Y string
+		Z string
+	}
+	marshaler     struct{ A int }
+	textMarshaler struct{ A int }
+)
+
+func (marshaler) MarshalJSON() ([]byte, error)      { return []byte(`"m"`), nil }
+func (*textMarshaler) MarshalText() ([]byte, error) { return []byte("t"), nil }
+
+func TestRegisterTypeSchema(t *testing.T) {
+	type Color struct{ R, G, B uint8 }
+	jsonschema.RegisterTypeSchema(reflect.TypeFor[Color](), &jsonschema.Schema{Type: "string", Pattern: "^#[0-9a-f]{6}$"})
+	defer jsonschema.RegisterTypeSchema(reflect.TypeFor[Color](), nil)
+
+	type S struct {
+		Fg Color
+		Bg *Color `json:",omitempty"`
+	}
+	got := forType[S](false)
+	want := &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"Fg": {Type: "string", Pattern: "^#[0-9a-f]{6}$"},
+			"Bg": {Types: []string{"null", "string"}, Pattern: "^#[0-9a-f]{6}$"},
+		},
+		Required:             []string{"Fg"},
+		AdditionalProperties: falseSchema(),
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(jsonschema.Schema{})); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Each use gets its own copy.
+	got.Properties["Fg"].Pattern = "mutated"
+	if p := forType[S](false).Properties["Fg"].Pattern; p == "mutated" {
+		t.Error("registered schema was mutated")
+	}
+
+	// Removing the registration restores the default behavior.
+	jsonschema.RegisterTypeSchema(reflect.TypeFor[Color](), nil)
+	if typ := forType[Color](false).Type; typ != "object" {
+		t.Errorf("after removal: got type %q, want object", typ)
+	}
+}
+
 func forErr[T any]() error {
 	_, err := jsonschema.For[T]()
 	return err
@@ -177,6 +308,15 @@ func TestForErrors(t *testing.T) {
 		s2 struct {
 			Bad int `jsonschema:"$foo=1,bar"`
 		}
+		s3 struct {
+			Bad int `jsonschema:"minimum=one"`
+		}
+		s4 struct {
+			Bad int `jsonschema:"enum=1|x"`
+		}
+		s5 struct {
+			Bad string `jsonschema:"pattern=("`
+		}
 	)
 
 	for _, tt := range []struct {
@@ -185,7 +325,10 @@ func TestForErrors(t *testing.T) {
 	}{
 		{forErr[map[int]int](), "unsupported map key type"},
 		{forErr[s1](), "empty jsonschema tag"},
-		{forErr[s2](), "must not begin with"},
+		{forErr[s2](), `unknown key "$foo"`},
+		{forErr[s3](), "minimum"},
+		{forErr[s4](), `"x" is not a valid JSON value`},
+		{forErr[s5](), "pattern"},
 		{forErr[func()](), "unsupported"},
 	} {
 		if tt.got == nil {
@@ -260,34 +403,181 @@ func TestForWithCycle(t *testing.T) {
 	type b2 struct{ B *b2 }
 	type c1 struct{ c map[string]*c1 } // unexported field should be skipped
 	type c2 struct{ C map[string]*c2 }
+	type d struct{ B b2 }
+
+	nullOrRef := func(ref string) *jsonschema.Schema {
+		return &jsonschema.Schema{AnyOf: []*jsonschema.Schema{{Type: "null"}, {Ref: ref}}}
+	}
+	b2Schema := &jsonschema.Schema{
+		Type:                 "object",
+		Properties:           map[string]*jsonschema.Schema{"B": nullOrRef("#")},
+		Required:             []string{"B"},
+		AdditionalProperties: falseSchema(),
+	}
+	xSchema := func(ys *jsonschema.Schema) *jsonschema.Schema {
+		return &jsonschema.Schema{
+			Type:                 "object",
+			Properties:           map[string]*jsonschema.Schema{"Y": ys},
+			Required:             []string{"Y"},
+			AdditionalProperties: falseSchema(),
+		}
+	}
+	ySchema := func(xs *jsonschema.Schema) *jsonschema.Schema {
+		return &jsonschema.Schema{
+			Type:                 "object",
+			Properties:           map[string]*jsonschema.Schema{"X": {Type: "array", Items: xs}},
+			Required:             []string{"X"},
+			AdditionalProperties: falseSchema(),
+		}
+	}
 
 	tests := []struct {
-		name      string
-		shouldErr bool
-		fn        func() error
+		name string
+		got  *jsonschema.Schema
+		want *jsonschema.Schema
 	}{
-		{"slice alias (a)", true, func() error { _, err := jsonschema.For[a](); return err }},
-		{"unexported self cycle (b1)", false, func() error { _, err := jsonschema.For[b1](); return err }},
-		{"exported self cycle (b2)", true, func() error { _, err := jsonschema.For[b2](); return err }},
-		{"unexported map self cycle (c1)", false, func() error { _, err := jsonschema.For[c1](); return err }},
-		{"exported map self cycle (c2)", true, func() error { _, err := jsonschema.For[c2](); return err }},
-		{"cross-cycle x -> y -> x", true, func() error { _, err := jsonschema.For[x](); return err }},
-		{"cross-cycle y -> x -> y", true, func() error { _, err := jsonschema.For[y](); return err }},
+		{"slice alias (a)", forType[a](false), &jsonschema.Schema{Type: "array", Items: nullOrRef("#")}},
+		{
+			"unexported self cycle (b1)",
+			forType[b1](false),
+			&jsonschema.Schema{Type: "object", AdditionalProperties: falseSchema()},
+		},
+		{"exported self cycle (b2)", forType[b2](false), b2Schema},
+		{
+			"unexported map self cycle (c1)",
+			forType[c1](false),
+			&jsonschema.Schema{Type: "object", AdditionalProperties: falseSchema()},
+		},
+		{
+			"exported map self cycle (c2)",
+			forType[c2](false),
+			&jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"C": {Type: "object", AdditionalProperties: nullOrRef("#")},
+				},
+				Required:             []string{"C"},
+				AdditionalProperties: falseSchema(),
+			},
+		},
+		{
+			"cycle below the root (d)",
+			forType[d](false),
+			&jsonschema.Schema{
+				Type:                 "object",
+				Properties:           map[string]*jsonschema.Schema{"B": {Ref: "#/$defs/b2"}},
+				Required:             []string{"B"},
+				AdditionalProperties: falseSchema(),
+				Defs: map[string]*jsonschema.Schema{
+					"b2": {
+						Type:                 "object",
+						Properties:           map[string]*jsonschema.Schema{"B": nullOrRef("#/$defs/b2")},
+						Required:             []string{"B"},
+						AdditionalProperties: falseSchema(),
+					},
+				},
+			},
+		},
+		// Only the root is recursive, so the other type is not placed in $defs.
+		{"cross-cycle x -> y -> x", forType[x](false), xSchema(ySchema(&jsonschema.Schema{Ref: "#"}))},
+		{"cross-cycle y -> x -> y", forType[y](false), ySchema(xSchema(&jsonschema.Schema{Ref: "#"}))},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			err := test.fn()
-			if test.shouldErr && err == nil {
-				t.Errorf("expected cycle error, got nil")
+			if diff := cmp.Diff(test.want, test.got, cmpopts.IgnoreUnexported(jsonschema.Schema{})); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
 			}
-			if !test.shouldErr && err != nil {
-				t.Errorf("unexpected error: %v", err)
+			if _, err := test.got.Resolve(nil); err != nil {
+				t.Errorf("Resolve: %v", err)
 			}
 		})
 	}
 }
 
+func TestForRecursiveValidation(t *testing.T) {
+	type Node struct {
+		Name     string  `json:"name"`
+		Children []*Node `json:"children,omitempty"`
+	}
+	type Tree struct {
+		Root *Node `json:"root"`
+	}
+
+	s, err := jsonschema.For[Tree]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := s.Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := Tree{Root: &Node{Name: "a", Children: []*Node{{Name: "b", Children: []*Node{{Name: "c"}}}}}}
+	if err := rs.Validate(valid); err != nil {
+		t.Errorf("valid struct: %v", err)
+	}
+	for _, inst := range []any{
+		map[string]any{"root": map[string]any{"name": "a", "children": []any{map[string]any{"name": "b"}, nil}}},
+		map[string]any{"root": nil},
+	} {
+		if err := rs.Validate(inst); err != nil {
+			t.Errorf("%v: %v", inst, err)
+		}
+	}
+	invalid := map[string]any{"root": map[string]any{"name": "a", "children": []any{map[string]any{"name": 1}}}}
+	if err := rs.Validate(invalid); err == nil {
+		t.Error("invalid instance: got nil error")
+	} else if want := "/root/children/0/name"; !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want one mentioning %q", err, want)
+	}
+}
+
+func TestForWithOptionsUseDefs(t *testing.T) {
+	type Point struct {
+		X, Y int
+	}
+	type Segment struct {
+		Start, End Point
+		Created    time.Time
+	}
+
+	got, err := jsonschema.ForWithOptions[Segment](&jsonschema.ForOptions{UseDefs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"Start":   {Ref: "#/$defs/Point"},
+			"End":     {Ref: "#/$defs/Point"},
+			"Created": {Type: "string", Format: "date-time"},
+		},
+		Required:             []string{"Start", "End", "Created"},
+		AdditionalProperties: falseSchema(),
+		Defs: map[string]*jsonschema.Schema{
+			"Point": {
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"X": {Type: "integer"},
+					"Y": {Type: "integer"},
+				},
+				Required:             []string{"X", "Y"},
+				AdditionalProperties: falseSchema(),
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(jsonschema.Schema{})); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+	rs, err := got.Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Validate(map[string]any{"Start": map[string]any{"X": 1, "Y": 2}, "End": map[string]any{"X": "3", "Y": 4}, "Created": "now"}); err == nil {
+		t.Error("invalid instance: got nil error")
+	}
+}
+
 func falseSchema() *jsonschema.Schema {
 	return &jsonschema.Schema{Not: &jsonschema.Schema{}}
 }
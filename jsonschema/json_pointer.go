@@ -141,6 +141,17 @@ func lookupSchemaField(v reflect.Value, name string) reflect.Value {
 		}
 		return v.FieldByName("Types")
 	}
+	if name == "items" {
+		// The "items" keyword may refer to Items or ItemsArray.
+		if i := v.FieldByName("Items"); !i.IsNil() {
+			return i
+		}
+		return v.FieldByName("ItemsArray")
+	}
+	if name == "dependencies" {
+		// Only the schema values of "dependencies" can be referred to.
+		return v.FieldByName("DependencySchemas")
+	}
 	if sf, ok := schemaFieldMap[name]; ok {
 		return v.FieldByIndex(sf.Index)
 	}
@@ -0,0 +1,270 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// This file defines implementations of Loader.
+
+package jsonschema
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FSLoader returns a [Loader] that reads schemas from the files of fsys.
+// It loads a URI that begins with prefix from the file whose name is the
+// rest of the URI. If there is no such file and the name does not end in
+// ".json", it tries the name with ".json" appended.
+// For example, with the prefix "https://example.com/schemas/", the URI
+// https://example.com/schemas/geo/point is loaded from "geo/point" or
+// "geo/point.json".
+// FSLoader returns an error for a URI that does not begin with prefix.
+//
+// Use FSLoader with an [embed.FS] to embed schemas in a program.
+func FSLoader(fsys fs.FS, prefix string) Loader {
+	return func(uri *url.URL) (*Schema, error) {
+		name, ok := strings.CutPrefix(uri.String(), prefix)
+		if !ok {
+			return nil, fmt.Errorf("%s does not begin with %s", uri, prefix)
+		}
+		name = path.Clean(name)
+		if !fs.ValidPath(name) {
+			return nil, fmt.Errorf("%s: invalid file name %q", uri, name)
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if errors.Is(err, fs.ErrNotExist) && path.Ext(name) != ".json" {
+			data, err = fs.ReadFile(fsys, name+".json")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return unmarshalLoaded(uri, data)
+	}
+}
+
+// PrefixLoader returns a [Loader] that delegates to other loaders according to
+// the beginning of the URI. It calls the loader in m whose key is the longest
+// prefix of the URI. If no key is a prefix of the URI, it returns an error.
+func PrefixLoader(m map[string]Loader) Loader {
+	return func(uri *url.URL) (*Schema, error) {
+		s := uri.String()
+		var loader Loader
+		longest := -1
+		for prefix, l := range m {
+			if strings.HasPrefix(s, prefix) && len(prefix) > longest {
+				loader = l
+				longest = len(prefix)
+			}
+		}
+		if loader == nil {
+			return nil, fmt.Errorf("no loader for %s", uri)
+		}
+		return loader(uri)
+	}
+}
+
+// HTTPLoaderOptions are options for [HTTPLoader].
+type HTTPLoaderOptions struct {
+	// Client is used to make requests. If nil, [http.DefaultClient] is used.
+	Client *http.Client
+	// Timeout limits the time for each request. If zero, there is a limit
+	// of 30 seconds. If negative, there is no limit.
+	Timeout time.Duration
+	// MaxSize is the maximum size of a schema, in bytes. If zero, the limit
+	// is 10 MiB.
+	MaxSize int64
+	// CacheDir is a directory for caching schemas. If non-empty, schemas
+	// are read from it if they are present, and written to it after they are
+	// fetched, so they survive across processes. There is no expiration:
+	// to reload a schema, remove its file.
+	// In any case, schemas are cached in memory for the lifetime of the Loader.
+	CacheDir string
+}
+
+// HTTPLoader returns a [Loader] that fetches schemas with HTTP GET requests.
+// It loads only URIs with the "http" or "https" scheme.
+// If opts is nil, the default values are used.
+//
+// The Loader caches the schemas it fetches, so it fetches each URI at most
+// once, even when it is called concurrently. A fetch that fails is tried
+// again on the next load of its URI. The Loader is safe for concurrent use.
+func HTTPLoader(opts *HTTPLoaderOptions) Loader {
+	l := &httpLoader{cache: map[string]*httpLoad{}}
+	if opts != nil {
+		l.opts = *opts
+	}
+	if l.opts.Client == nil {
+		l.opts.Client = http.DefaultClient
+	}
+	if l.opts.Timeout == 0 {
+		l.opts.Timeout = 30 * time.Second
+	}
+	if l.opts.MaxSize == 0 {
+		l.opts.MaxSize = 10 << 20
+	}
+	return l.load
+}
+
+type httpLoader struct {
+	opts HTTPLoaderOptions
+
+	mu    sync.Mutex
+	cache map[string]*httpLoad // from URI to a completed or in-flight load
+}
+
+// An httpLoad is a load of a single URI.
+type httpLoad struct {
+	done chan struct{} // closed when the load completes
+	data []byte        // schema contents; set before done is closed
+	err  error         // set before done is closed
+}
+
+func (l *httpLoader) load(uri *url.URL) (*Schema, error) {
+	if uri.Scheme != "http" && uri.Scheme != "https" {
+		return nil, fmt.Errorf("cannot load %s: scheme is not http or https", uri)
+	}
+	u := *uri
+	u.Fragment = ""
+	key := u.String()
+
+	l.mu.Lock()
+	ld, ok := l.cache[key]
+	if !ok {
+		ld = &httpLoad{done: make(chan struct{})}
+		l.cache[key] = ld
+	}
+	l.mu.Unlock()
+	if ok {
+		// Another call is loading the URI, or has loaded it.
+		<-ld.done
+	} else {
+		ld.data, ld.err = l.read(key)
+		if ld.err != nil {
+			// Forget the failure, so that a later load tries again.
+			l.mu.Lock()
+			delete(l.cache, key)
+			l.mu.Unlock()
+		}
+		close(ld.done)
+	}
+	if ld.err != nil {
+		return nil, fmt.Errorf("loading %s: %w", key, ld.err)
+	}
+	return unmarshalLoaded(uri, ld.data)
+}
+
+// read returns the contents of the schema at uri, from the disk cache or the
+// network.
+func (l *httpLoader) read(uri string) ([]byte, error) {
+	var cacheFile string
+	if l.opts.CacheDir != "" {
+		sum := sha256.Sum256([]byte(uri))
+		cacheFile = filepath.Join(l.opts.CacheDir, hex.EncodeToString(sum[:])+".json")
+		if data, err := os.ReadFile(cacheFile); err == nil {
+			return data, nil
+		}
+	}
+	data, err := l.fetch(uri)
+	if err != nil {
+		return nil, err
+	}
+	if cacheFile != "" {
+		// The cache is an optimization: ignore errors writing to it.
+		_ = writeFileAtomic(cacheFile, data)
+	}
+	return data, nil
+}
+
+func (l *httpLoader) fetch(uri string) ([]byte, error) {
+	ctx := context.Background()
+	if l.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.opts.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/schema+json, application/json")
+	resp, err := l.opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, l.opts.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > l.opts.MaxSize {
+		return nil, fmt.Errorf("schema is larger than %d bytes", l.opts.MaxSize)
+	}
+	return data, nil
+}
+
+// writeFileAtomic writes data to filename by way of a temporary file, so that
+// readers never see a partially written file.
+func writeFileAtomic(filename string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func unmarshalLoaded(uri *url.URL, data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("unmarshaling %s: %w", uri, err)
+	}
+	return &s, nil
+}
+
+//go:embed meta-schemas/draft2020-12
+var metaSchemaFiles embed.FS
+
+// loadMetaSchema loads the draft 2020-12 meta-schemas from the files embedded
+// in the package. It reports false if uri is not one of them.
+func loadMetaSchema(uri *url.URL) (*Schema, bool, error) {
+	const prefix = "https://json-schema.org/draft/2020-12/"
+	if !strings.HasPrefix(uri.String(), prefix) {
+		return nil, false, nil
+	}
+	fsys, err := fs.Sub(metaSchemaFiles, "meta-schemas/draft2020-12")
+	if err != nil {
+		return nil, false, err
+	}
+	s, err := FSLoader(fsys, prefix)(uri)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	return s, true, err
+}
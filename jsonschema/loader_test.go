@@ -0,0 +1,193 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func mustParseURL(t *testing.T, s string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestFSLoader(t *testing.T) {
+	fsys := fstest.MapFS{
+		"geo/point.json": {Data: []byte(`{"type": "object"}`)},
+		"name":           {Data: []byte(`{"type": "string"}`)},
+	}
+	loader := FSLoader(fsys, "https://example.com/schemas/")
+	for _, test := range []struct {
+		uri      string
+		wantType string // empty for an error
+	}{
+		{"https://example.com/schemas/geo/point.json", "object"},
+		{"https://example.com/schemas/geo/point", "object"},
+		{"https://example.com/schemas/name", "string"},
+		{"https://example.com/schemas/missing", ""},
+		{"https://example.com/schemas/../x.json", ""},
+		{"https://other.com/schemas/name", ""},
+	} {
+		s, err := loader(mustParseURL(t, test.uri))
+		if test.wantType == "" {
+			if err == nil {
+				t.Errorf("%s: got nil error, want error", test.uri)
+			}
+		} else if err != nil {
+			t.Errorf("%s: %v", test.uri, err)
+		} else if s.Type != test.wantType {
+			t.Errorf("%s: got type %q, want %q", test.uri, s.Type, test.wantType)
+		}
+	}
+
+	// A schema can refer to the files of an FS.
+	root := &Schema{ID: "https://example.com/schemas/person", Properties: map[string]*Schema{"name": {Ref: "name"}}}
+	rs, err := root.Resolve(&ResolveOptions{Loader: loader})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Validate(map[string]any{"name": 1}); err == nil {
+		t.Error("got nil error, want error")
+	}
+}
+
+func TestPrefixLoader(t *testing.T) {
+	constLoader := func(typ string) Loader {
+		return func(*url.URL) (*Schema, error) { return &Schema{Type: typ}, nil }
+	}
+	loader := PrefixLoader(map[string]Loader{
+		"https://a.com/":     constLoader("a"),
+		"https://a.com/b/":   constLoader("ab"),
+		"https://other.com/": constLoader("other"),
+	})
+	for _, test := range []struct {
+		uri, want string
+	}{
+		{"https://a.com/x", "a"},
+		{"https://a.com/b/x", "ab"},
+		{"https://other.com/x", "other"},
+		{"https://b.com/x", ""},
+	} {
+		s, err := loader(mustParseURL(t, test.uri))
+		if err != nil {
+			if test.want != "" {
+				t.Errorf("%s: %v", test.uri, err)
+			}
+			continue
+		}
+		if s.Type != test.want {
+			t.Errorf("%s: got %q, want %q", test.uri, s.Type, test.want)
+		}
+	}
+}
+
+func TestHTTPLoader(t *testing.T) {
+	var requests, gatedRequests atomic.Int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/name.json":
+			w.Write([]byte(`{"type": "string"}`))
+		case "/gated.json":
+			gatedRequests.Add(1)
+			<-release
+			w.Write([]byte(`{"type": "integer"}`))
+		case "/slow.json":
+			<-r.Context().Done()
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	cacheDir := t.TempDir()
+	loader := HTTPLoader(&HTTPLoaderOptions{Timeout: 50 * time.Millisecond, CacheDir: cacheDir})
+	name := mustParseURL(t, ts.URL+"/name.json")
+	for range 2 {
+		s, err := loader(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Type != "string" {
+			t.Errorf("got type %q, want string", s.Type)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+
+	// A new loader with the same cache directory does not make a request.
+	requests.Store(0)
+	if _, err := HTTPLoader(&HTTPLoaderOptions{CacheDir: cacheDir})(name); err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("got %d requests, want 0", got)
+	}
+
+	// Concurrent loads of the same URI share a single fetch.
+	gated := mustParseURL(t, ts.URL+"/gated.json")
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := loader(gated); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	for gatedRequests.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond) // give the other loads time to start
+	close(release)
+	wg.Wait()
+	if got := gatedRequests.Load(); got != 1 {
+		t.Errorf("concurrent loads: got %d requests, want 1", got)
+	}
+
+	for _, test := range []struct {
+		uri, want string
+	}{
+		{ts.URL + "/missing.json", "404"},
+		{ts.URL + "/slow.json", "deadline exceeded"},
+		{"file:///etc/passwd", "scheme"},
+	} {
+		_, err := loader(mustParseURL(t, test.uri))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got error %v, want one containing %q", test.uri, err, test.want)
+		}
+	}
+}
+
+func TestMetaSchemasBuiltIn(t *testing.T) {
+	// The meta-schemas are loaded without a Loader.
+	s := &Schema{Ref: "https://json-schema.org/draft/2020-12/schema"}
+	rs, err := s.Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Validate(map[string]any{"type": "string"}); err != nil {
+		t.Errorf("valid schema: %v", err)
+	}
+	if err := rs.Validate(map[string]any{"type": 1}); err == nil {
+		t.Error("invalid schema: got nil error")
+	}
+}
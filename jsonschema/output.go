@@ -0,0 +1,154 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// This file describes the results of validation.
+// See https://json-schema.org/draft/2020-12/json-schema-core#section-12.
+
+package jsonschema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// An OutputUnit describes the result of validating a location in an instance
+// against a location in a schema. Its JSON encoding follows the output format of
+// [section 12.4 of the core specification].
+//
+// [section 12.4 of the core specification]: https://json-schema.org/draft/2020-12/json-schema-core#section-12.4
+type OutputUnit struct {
+	// Valid reports whether validation succeeded.
+	// It is false for all the units of a [ValidationError].
+	Valid bool `json:"valid"`
+	// KeywordLocation is a JSON Pointer to the keyword or schema, following the
+	// path of keywords from the root schema, including "$ref" and "$dynamicRef".
+	KeywordLocation string `json:"keywordLocation"`
+	// AbsoluteKeywordLocation is the absolute URI of the keyword or schema,
+	// with a JSON Pointer fragment. It is empty if the schema's base URI
+	// is not absolute.
+	AbsoluteKeywordLocation string `json:"absoluteKeywordLocation,omitempty"`
+	// InstanceLocation is a JSON Pointer to the part of the instance being
+	// validated.
+	InstanceLocation string `json:"instanceLocation"`
+	// Error describes why the keyword failed.
+	// It is empty for units that only group other units.
+	Error string `json:"error,omitempty"`
+	// Errors holds the units for the keywords or subschemas that failed.
+	Errors []*OutputUnit `json:"errors,omitempty"`
+
+	err error // the error that Error describes
+}
+
+// A ValidationError is returned by [Resolved.Validate] when an instance does not
+// validate. It holds all the failures found.
+//
+// A ValidationError unwraps to the errors underlying the failures, so for example
+// [errors.Is] will report the errors returned by a [FormatChecker].
+type ValidationError struct {
+	unit *OutputUnit // the hierarchy of failures, rooted at the schema that was validated
+}
+
+// Errors returns the failures of individual keywords, ordered by keyword location
+// and then by instance location. Each has a non-empty Error field.
+func (e *ValidationError) Errors() []*OutputUnit {
+	var us []*OutputUnit
+	var walk func(*OutputUnit)
+	walk = func(u *OutputUnit) {
+		if u.Error != "" {
+			us = append(us, u)
+		}
+		for _, c := range u.Errors {
+			walk(c)
+		}
+	}
+	walk(e.unit)
+	return us
+}
+
+// Basic returns the failures in the "basic" output format: a single unit
+// whose Errors field lists the failures of individual keywords, without
+// further nesting.
+func (e *ValidationError) Basic() *OutputUnit {
+	out := &OutputUnit{
+		KeywordLocation:         e.unit.KeywordLocation,
+		AbsoluteKeywordLocation: e.unit.AbsoluteKeywordLocation,
+		InstanceLocation:        e.unit.InstanceLocation,
+	}
+	for _, u := range e.Errors() {
+		c := *u
+		c.Errors = nil
+		out.Errors = append(out.Errors, &c)
+	}
+	return out
+}
+
+// Detailed returns the failures in the "detailed" output format: a hierarchy
+// of units that follows the structure of the schema. Units that would only
+// group a single other unit are omitted.
+func (e *ValidationError) Detailed() *OutputUnit {
+	var condense func(*OutputUnit) *OutputUnit
+	condense = func(u *OutputUnit) *OutputUnit {
+		if u.Error == "" && len(u.Errors) == 1 {
+			return condense(u.Errors[0])
+		}
+		c := *u
+		c.Errors = make([]*OutputUnit, len(u.Errors))
+		for i, cu := range u.Errors {
+			c.Errors[i] = condense(cu)
+		}
+		if len(c.Errors) == 0 {
+			c.Errors = nil
+		}
+		return &c
+	}
+	out := *e.unit
+	out.Errors = nil
+	for _, u := range e.unit.Errors {
+		out.Errors = append(out.Errors, condense(u))
+	}
+	return &out
+}
+
+// Error returns the failures of individual keywords, one per line.
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	for i, u := range e.Errors() {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "instance %q: keyword %q: %s", u.InstanceLocation, u.KeywordLocation, u.Error)
+	}
+	return b.String()
+}
+
+// Unwrap returns the errors underlying the failures.
+func (e *ValidationError) Unwrap() []error {
+	var errs []error
+	for _, u := range e.Errors() {
+		if u.err != nil {
+			errs = append(errs, u.err)
+		}
+	}
+	return errs
+}
+
+// newValidationError returns a ValidationError for the failures in u.
+// It sorts the units so that the results don't depend on map iteration order.
+func newValidationError(u *OutputUnit) *ValidationError {
+	var sort func(*OutputUnit)
+	sort = func(u *OutputUnit) {
+		slices.SortStableFunc(u.Errors, func(a, b *OutputUnit) int {
+			if c := strings.Compare(a.KeywordLocation, b.KeywordLocation); c != 0 {
+				return c
+			}
+			return strings.Compare(a.InstanceLocation, b.InstanceLocation)
+		})
+		for _, c := range u.Errors {
+			sort(c)
+		}
+	}
+	sort(u)
+	return &ValidationError{unit: u}
+}
@@ -27,6 +27,10 @@ type Resolved struct {
 	resolvedURIs map[string]*Schema
 	// map from schemas to additional info computed during resolution
 	resolvedInfos map[*Schema]*resolvedInfo
+	// checkers for the "format" keyword, or nil if formats are not asserted
+	formats map[string]FormatChecker
+	// whether to assert the content keywords
+	validateContent bool
 }
 
 func newResolved(s *Schema) *Resolved {
@@ -40,6 +44,9 @@ func newResolved(s *Schema) *Resolved {
 // resolvedInfo holds information specific to a schema that is computed by [Schema.Resolve].
 type resolvedInfo struct {
 	s *Schema
+	// The root of the document containing the schema: the schema passed
+	// to Resolve, or one returned by a Loader.
+	root *Schema
 	// The JSON Pointer path from the root schema to here.
 	// Used in errors.
 	path string
@@ -65,6 +72,11 @@ type resolvedInfo struct {
 	resolvedDynamicRef *Schema
 	// The anchor to look up on the stack when the dynamic ref acts dynamically.
 	dynamicRefAnchor string
+	// The schema to which RecursiveRef refers before considering the dynamic scope.
+	resolvedRecursiveRef *Schema
+
+	// The version of JSON Schema that applies to the schema.
+	dialect dialect
 
 	// The following fields are independent of arguments to Schema.Resolved,
 	// so they could live on the Schema. We put them here for simplicity.
@@ -111,6 +123,10 @@ type ResolveOptions struct {
 	// Loader loads schemas that are referred to by a $ref but are not under the
 	// root schema (remote references).
 	// If nil, resolving a remote reference will return an error.
+	// The draft 2020-12 meta-schemas, whose URIs begin with
+	// https://json-schema.org/draft/2020-12/, are built in: they are never
+	// loaded with Loader.
+	// See [FSLoader], [HTTPLoader] and [PrefixLoader] for implementations.
 	Loader Loader
 	// ValidateDefaults determines whether to validate values of "default" keywords
 	// against their schemas.
@@ -119,6 +135,42 @@ type ResolveOptions struct {
 	//
 	// [JSON Schema specification]: https://json-schema.org/understanding-json-schema/reference/annotations
 	ValidateDefaults bool
+	// ValidateFormats determines whether [Resolved.Validate] asserts the "format"
+	// keyword. By default, as the specification requires, "format" is only
+	// an annotation.
+	// When true, string instances are checked against the formats defined in
+	// section 7.3 of the [validation specification], and against Formats.
+	// Formats that are neither defined there nor in Formats are ignored.
+	//
+	// [validation specification]: https://json-schema.org/draft/2020-12/draft-bhutton-json-schema-validation-01#section-7.3
+	ValidateFormats bool
+	// Formats maps format names to checkers for additional formats.
+	// An entry for a format defined by the specification replaces the
+	// built-in checker.
+	// Like the built-in checkers, these apply only to string instances:
+	// instances of other types always conform to a format.
+	// Formats is used only if ValidateFormats is true.
+	Formats map[string]FormatChecker
+	// ValidateContent determines whether [Resolved.Validate] asserts the
+	// content keywords. By default, as the specification requires, they are only
+	// annotations.
+	// When true, a string instance is decoded according to "contentEncoding",
+	// which may be "base64", "base64url", "base32", "quoted-printable", "7bit",
+	// "8bit" or "binary". If "contentMediaType" is a JSON media type, such as
+	// "application/json", the decoded content must be valid JSON, and it is
+	// validated against "contentSchema", if present.
+	// Other encodings and media types are ignored.
+	ValidateContent bool
+	// DefaultDialect is the URI of the meta-schema for the version of JSON
+	// Schema to use for schemas that don't specify one with "$schema".
+	// Drafts 2020-12, 2019-09 and 7 are supported:
+	//
+	//	https://json-schema.org/draft/2020-12/schema
+	//	https://json-schema.org/draft/2019-09/schema
+	//	http://json-schema.org/draft-07/schema#
+	//
+	// If empty, draft 2020-12 is used.
+	DefaultDialect string
 }
 
 // Resolve resolves all references within the schema and performs other tasks that
@@ -153,6 +205,14 @@ func (root *Schema) Resolve(opts *ResolveOptions) (*Resolved, error) {
 		}
 	}
 
+	if r.opts.DefaultDialect != "" {
+		d, ok := dialectFor(r.opts.DefaultDialect)
+		if !ok {
+			return nil, fmt.Errorf("unsupported default dialect %q", r.opts.DefaultDialect)
+		}
+		r.dialect = d
+	}
+
 	if r.opts.Loader == nil {
 		r.opts.Loader = func(uri *url.URL) (*Schema, error) {
 			return nil, errors.New("cannot resolve remote schemas: no loader passed to Schema.Resolve")
@@ -163,6 +223,8 @@ func (root *Schema) Resolve(opts *ResolveOptions) (*Resolved, error) {
 	if err != nil {
 		return nil, err
 	}
+	resolved.formats = formatsFor(&r.opts)
+	resolved.validateContent = r.opts.ValidateContent
 	if r.opts.ValidateDefaults {
 		if err := resolved.validateDefaults(); err != nil {
 			return nil, err
@@ -179,6 +241,8 @@ type resolver struct {
 	// refs resolved.) The cache ensures that the loader will never be called more
 	// than once with the same URI, and that reference cycles are handled properly.
 	loaded map[string]*Resolved
+	// The dialect of schemas without a $schema keyword.
+	dialect dialect
 }
 
 func (r *resolver) resolve(s *Schema, baseURI *url.URL) (*Resolved, error) {
@@ -187,7 +251,7 @@ func (r *resolver) resolve(s *Schema, baseURI *url.URL) (*Resolved, error) {
 	}
 	rs := newResolved(s)
 
-	if err := s.check(rs.resolvedInfos); err != nil {
+	if err := s.check(rs.resolvedInfos, r.dialect); err != nil {
 		return nil, err
 	}
 
@@ -207,12 +271,26 @@ func (r *resolver) resolve(s *Schema, baseURI *url.URL) (*Resolved, error) {
 	return rs, nil
 }
 
-func (root *Schema) check(infos map[*Schema]*resolvedInfo) error {
+// check checks root and its subschemas for validity.
+// It also determines the dialect of each schema: the one named by its $schema
+// keyword, or else its parent's. The dialect of the root defaults to d.
+func (root *Schema) check(infos map[*Schema]*resolvedInfo, d dialect) error {
 	// Check for structural validity. Do this first and fail fast:
 	// bad structure will cause other code to panic.
 	if err := root.checkStructure(infos); err != nil {
 		return err
 	}
+	var setDialect func(s *Schema, d dialect)
+	setDialect = func(s *Schema, d dialect) {
+		if sd, ok := dialectFor(s.Schema); ok {
+			d = sd
+		}
+		infos[s].dialect = d
+		for c := range s.children() {
+			setDialect(c, d)
+		}
+	}
+	setDialect(root, d)
 
 	var errs []error
 	report := func(err error) { errs = append(errs, err) }
@@ -248,7 +326,7 @@ func (root *Schema) checkStructure(infos map[*Schema]*resolvedInfo) error {
 			return fmt.Errorf("jsonschema: schemas at %s do not form a tree; %s appears more than once (also at %s)",
 				root, info.path, p)
 		}
-		infos[s] = &resolvedInfo{s: s, path: p}
+		infos[s] = &resolvedInfo{s: s, root: root, path: p}
 
 		for _, info := range schemaFieldInfos {
 			fv := v.Elem().FieldByIndex(info.sf.Index)
@@ -311,13 +389,26 @@ func (s *Schema) checkLocal(report func(error), infos map[*Schema]*resolvedInfo)
 	// Some properties are present so that Schemas can round-trip, but we do not
 	// validate them.
 	// Currently, it's just the $vocabulary property.
-	// As a special case, we can validate the 2020-12 meta-schema.
-	if s.Vocabulary != nil && s.Schema != draft202012 {
-		addf("cannot validate a schema with $vocabulary")
+	// As a special case, we can validate the 2020-12 and 2019-09 meta-schemas.
+	if s.Vocabulary != nil {
+		if d, ok := dialectFor(s.Schema); !ok || d == draft7 {
+			addf("cannot validate a schema with $vocabulary")
+		}
 	}
 
 	info := infos[s]
 
+	// The array form of "items" and the "dependencies" keyword were removed in
+	// draft 2020-12.
+	if info.dialect == draft202012 {
+		if s.ItemsArray != nil {
+			addf("the array form of items requires draft 7 or 2019-09; use prefixItems")
+		}
+		if s.DependencySchemas != nil || s.DependencyStrings != nil {
+			addf("dependencies requires draft 7 or 2019-09; use dependentSchemas or dependentRequired")
+		}
+	}
+
 	// Check and compile regexps.
 	if s.Pattern != "" {
 		re, err := regexp.Compile(s.Pattern)
@@ -382,30 +473,43 @@ func (s *Schema) checkLocal(report func(error), infos map[*Schema]*resolvedInfo)
 //	allOf/1        http://b.com (absolute $id; doesn't matter that it's not under the loaded URI)
 //	allOf/2        http://a.com/root.json (inherited from parent)
 //	allOf/2/not    http://a.com/root.json (inherited from parent)
+//
+// resolveURIs uses the dialects of the schemas determined by [Schema.check].
 func resolveURIs(rs *Resolved, baseURI *url.URL) error {
 	var resolve func(s, base *Schema) error
 	resolve = func(s, base *Schema) error {
 		info := rs.resolvedInfos[s]
 		baseInfo := rs.resolvedInfos[base]
+		d := info.dialect
 
+		// In draft 7, a $ref causes all sibling keywords to be ignored, including $id.
+		// The $id of draft 7 can also be a plain-name fragment, which acts as an anchor.
+		var idAnchor string
 		// ids are scoped to the root.
-		if s.ID != "" {
-			// A non-empty ID establishes a new base.
+		if s.ID != "" && !(d == draft7 && s.Ref != "") {
 			idURI, err := url.Parse(s.ID)
 			if err != nil {
 				return err
 			}
 			if idURI.Fragment != "" {
-				return fmt.Errorf("$id %s must not have a fragment", s.ID)
+				if d != draft7 || strings.HasPrefix(idURI.Fragment, "/") {
+					return fmt.Errorf("$id %s must not have a fragment", s.ID)
+				}
+				idAnchor = idURI.Fragment
+				idURI.Fragment = ""
+				idURI.RawFragment = ""
 			}
-			// The base URI for this schema is its $id resolved against the parent base.
-			info.uri = baseInfo.uri.ResolveReference(idURI)
-			if !info.uri.IsAbs() {
-				return fmt.Errorf("$id %s does not resolve to an absolute URI (base is %q)", s.ID, baseInfo.uri)
+			// A non-empty ID establishes a new base.
+			if *idURI != (url.URL{}) {
+				// The base URI for this schema is its $id resolved against the parent base.
+				info.uri = baseInfo.uri.ResolveReference(idURI)
+				if !info.uri.IsAbs() {
+					return fmt.Errorf("$id %s does not resolve to an absolute URI (base is %q)", s.ID, baseInfo.uri)
+				}
+				rs.resolvedURIs[info.uri.String()] = s
+				base = s // needed for anchors
+				baseInfo = rs.resolvedInfos[base]
 			}
-			rs.resolvedURIs[info.uri.String()] = s
-			base = s // needed for anchors
-			baseInfo = rs.resolvedInfos[base]
 		}
 		info.base = base
 
@@ -424,8 +528,20 @@ func resolveURIs(rs *Resolved, baseURI *url.URL) error {
 			return nil
 		}
 
-		setAnchor(s.Anchor, false)
-		setAnchor(s.DynamicAnchor, true)
+		if err := setAnchor(idAnchor, false); err != nil {
+			return err
+		}
+		// $anchor was introduced in draft 2019-09, and $dynamicAnchor in 2020-12.
+		if d != draft7 {
+			if err := setAnchor(s.Anchor, false); err != nil {
+				return err
+			}
+		}
+		if d == draft202012 {
+			if err := setAnchor(s.DynamicAnchor, true); err != nil {
+				return err
+			}
+		}
 
 		for c := range s.children() {
 			if err := resolve(c, base); err != nil {
@@ -458,7 +574,14 @@ func (r *resolver) resolveRefs(rs *Resolved) error {
 			// the ref still treats it lexically.
 			info.resolvedRef = refSchema
 		}
-		if s.DynamicRef != "" {
+		if s.RecursiveRef != "" && info.dialect == draft201909 {
+			refSchema, _, err := r.resolveRef(rs, s, s.RecursiveRef)
+			if err != nil {
+				return err
+			}
+			info.resolvedRecursiveRef = refSchema
+		}
+		if s.DynamicRef != "" && info.dialect == draft202012 {
 			refSchema, frag, err := r.resolveRef(rs, s, s.DynamicRef)
 			if err != nil {
 				return err
@@ -504,8 +627,11 @@ func (r *resolver) resolveRef(rs *Resolved, s *Schema, ref string) (_ *Schema, d
 		if lrs := r.loaded[fraglessRefURI.String()]; lrs != nil {
 			referencedSchema = lrs.root
 		} else {
-			// Try to load the schema.
-			ls, err := r.opts.Loader(fraglessRefURI)
+			// Try to load the schema. The meta-schemas are built in.
+			ls, ok, err := loadMetaSchema(fraglessRefURI)
+			if !ok && err == nil {
+				ls, err = r.opts.Loader(fraglessRefURI)
+			}
 			if err != nil {
 				return nil, "", fmt.Errorf("loading %s: %w", fraglessRefURI, err)
 			}
@@ -52,6 +52,14 @@ func TestCheckLocal(t *testing.T) {
 			&Schema{PatternProperties: map[string]*Schema{"*": {}}},
 			"regexp",
 		},
+		{
+			&Schema{ItemsArray: []*Schema{{}}},
+			"prefixItems",
+		},
+		{
+			&Schema{Items: &Schema{DependencyStrings: map[string][]string{"a": {"b"}}}},
+			"dependentRequired",
+		},
 	} {
 		_, err := tt.s.Resolve(nil)
 		if err == nil {
@@ -133,7 +141,7 @@ func TestResolveURIs(t *testing.T) {
 			}
 
 			rs := newResolved(root)
-			if err := root.check(rs.resolvedInfos); err != nil {
+			if err := root.check(rs.resolvedInfos, draft202012); err != nil {
 				t.Fatal(err)
 			}
 			if err := resolveURIs(rs, base); err != nil {
@@ -216,3 +224,50 @@ func TestRefCycle(t *testing.T) {
 	check(schemas["a"], "b")
 	check(schemas["b"], "a")
 }
+
+func TestDialect(t *testing.T) {
+	// In draft 2020-12, the array form of "items" is an error.
+	// In earlier drafts, it applies to the items at the same positions.
+	itemsArray := func(schemaURI string) *Schema {
+		return &Schema{Schema: schemaURI, ItemsArray: []*Schema{{Type: "string"}}}
+	}
+	for _, tt := range []struct {
+		name           string
+		schema         *Schema
+		defaultDialect string
+		wantValid      bool
+	}{
+		{"default dialect", itemsArray(""), draft7URI, false},
+		{"$schema", itemsArray(draft201909URI), "", false},
+		{"$schema overrides default", itemsArray("http://json-schema.org/draft-07/schema"), draft201909URI, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, err := tt.schema.Resolve(&ResolveOptions{DefaultDialect: tt.defaultDialect})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = rs.Validate([]any{1})
+			if got := err == nil; got != tt.wantValid {
+				t.Errorf("valid: got %t, want %t (err: %v)", got, tt.wantValid, err)
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := (&Schema{}).Resolve(&ResolveOptions{DefaultDialect: "http://json-schema.org/draft-04/schema#"}); err == nil {
+			t.Error("unsupported DefaultDialect: got nil error")
+		}
+		if _, err := itemsArray(draft202012URI).Resolve(&ResolveOptions{DefaultDialect: draft7URI}); err == nil {
+			t.Error("items array in draft 2020-12: got nil error")
+		}
+		// "$anchor" is not a keyword in draft 7, so the reference is unresolved.
+		s := &Schema{
+			Schema: draft7URI,
+			Ref:    "#foo",
+			Defs:   map[string]*Schema{"a": {Anchor: "foo"}},
+		}
+		if _, err := s.Resolve(nil); err == nil {
+			t.Error("$anchor in draft 7: got nil error")
+		}
+	})
+}
@@ -25,10 +25,13 @@ import (
 // - https://json-schema.org/draft/2020-12/draft-bhutton-json-schema-01
 // - https://json-schema.org/draft/2020-12/draft-bhutton-json-schema-validation-01
 //
+// It also has fields for the keywords of the earlier drafts 2019-09 and 7 that
+// are not part of 2020-12.
+//
 // A Schema value may have non-zero values for more than one field:
 // all relevant non-zero fields are used for validation.
-// There is one exception to provide more Go type-safety: the Type and Types fields
-// are mutually exclusive.
+// There are exceptions to provide more Go type-safety: the Type and Types fields
+// are mutually exclusive, as are Items and ItemsArray.
 //
 // Since this struct is a Go representation of a JSON value, it inherits JSON's
 // distinction between nil and empty. Nil slices and maps are considered absent,
@@ -56,6 +59,10 @@ type Schema struct {
 	DynamicRef    string          `json:"$dynamicRef,omitempty"`
 	Vocabulary    map[string]bool `json:"$vocabulary,omitempty"`
 
+	// draft 2019-09 only
+	RecursiveAnchor bool   `json:"$recursiveAnchor,omitempty"`
+	RecursiveRef    string `json:"$recursiveRef,omitempty"`
+
 	// metadata
 	Title       string          `json:"title,omitempty"`
 	Description string          `json:"description,omitempty"`
@@ -82,8 +89,11 @@ type Schema struct {
 	Pattern          string   `json:"pattern,omitempty"`
 
 	// arrays
-	PrefixItems      []*Schema `json:"prefixItems,omitempty"`
+	PrefixItems []*Schema `json:"prefixItems,omitempty"`
+	// Use Items for a single schema. Use ItemsArray for the array form of "items"
+	// in drafts 2019-09 and 7, which is equivalent to prefixItems.
 	Items            *Schema   `json:"items,omitempty"`
+	ItemsArray       []*Schema `json:"-"`
 	MinItems         *int      `json:"minItems,omitempty"`
 	MaxItems         *int      `json:"maxItems,omitempty"`
 	AdditionalItems  *Schema   `json:"additionalItems,omitempty"`
@@ -103,6 +113,12 @@ type Schema struct {
 	AdditionalProperties  *Schema             `json:"additionalProperties,omitempty"`
 	PropertyNames         *Schema             `json:"propertyNames,omitempty"`
 	UnevaluatedProperties *Schema             `json:"unevaluatedProperties,omitempty"`
+	// The "dependencies" keyword of draft 7, which was split into
+	// dependentRequired and dependentSchemas in draft 2019-09.
+	// DependencyStrings holds the values that are arrays of property names,
+	// and DependencySchemas holds the values that are schemas.
+	DependencySchemas map[string]*Schema  `json:"-"`
+	DependencyStrings map[string][]string `json:"-"`
 
 	// logic
 	AllOf []*Schema `json:"allOf,omitempty"`
@@ -159,6 +175,14 @@ func (s *Schema) basicChecks() error {
 	if s.Defs != nil && s.Definitions != nil {
 		return errors.New("both Defs and Definitions are set; at most one should be")
 	}
+	if s.Items != nil && s.ItemsArray != nil {
+		return errors.New("both Items and ItemsArray are set; at most one should be")
+	}
+	for name := range s.DependencySchemas {
+		if _, ok := s.DependencyStrings[name]; ok {
+			return fmt.Errorf("dependency %q is in both DependencySchemas and DependencyStrings", name)
+		}
+	}
 	return nil
 }
 
@@ -176,11 +200,34 @@ func (s *Schema) MarshalJSON() ([]byte, error) {
 	case s.Types != nil:
 		typ = s.Types
 	}
+	// Marshal either Items or ItemsArray as "items".
+	var items any
+	switch {
+	case s.Items != nil:
+		items = s.Items
+	case s.ItemsArray != nil:
+		items = s.ItemsArray
+	}
+	// Marshal DependencySchemas and DependencyStrings together as "dependencies".
+	var deps map[string]any
+	if s.DependencySchemas != nil || s.DependencyStrings != nil {
+		deps = map[string]any{}
+		for name, ds := range s.DependencySchemas {
+			deps[name] = ds
+		}
+		for name, ds := range s.DependencyStrings {
+			deps[name] = ds
+		}
+	}
 	ms := struct {
-		Type any `json:"type,omitempty"`
+		Type         any            `json:"type,omitempty"`
+		Items        any            `json:"items,omitempty"`
+		Dependencies map[string]any `json:"dependencies,omitempty"`
 		*schemaWithoutMethods
 	}{
 		Type:                 typ,
+		Items:                items,
+		Dependencies:         deps,
 		schemaWithoutMethods: (*schemaWithoutMethods)(s),
 	}
 	return marshalStructWithMap(&ms, "Extra")
@@ -201,16 +248,18 @@ func (s *Schema) UnmarshalJSON(data []byte) error {
 	}
 
 	ms := struct {
-		Type          json.RawMessage `json:"type,omitempty"`
-		Const         json.RawMessage `json:"const,omitempty"`
-		MinLength     *integer        `json:"minLength,omitempty"`
-		MaxLength     *integer        `json:"maxLength,omitempty"`
-		MinItems      *integer        `json:"minItems,omitempty"`
-		MaxItems      *integer        `json:"maxItems,omitempty"`
-		MinProperties *integer        `json:"minProperties,omitempty"`
-		MaxProperties *integer        `json:"maxProperties,omitempty"`
-		MinContains   *integer        `json:"minContains,omitempty"`
-		MaxContains   *integer        `json:"maxContains,omitempty"`
+		Type          json.RawMessage            `json:"type,omitempty"`
+		Items         json.RawMessage            `json:"items,omitempty"`
+		Dependencies  map[string]json.RawMessage `json:"dependencies,omitempty"`
+		Const         json.RawMessage            `json:"const,omitempty"`
+		MinLength     *integer                   `json:"minLength,omitempty"`
+		MaxLength     *integer                   `json:"maxLength,omitempty"`
+		MinItems      *integer                   `json:"minItems,omitempty"`
+		MaxItems      *integer                   `json:"maxItems,omitempty"`
+		MinProperties *integer                   `json:"minProperties,omitempty"`
+		MaxProperties *integer                   `json:"maxProperties,omitempty"`
+		MinContains   *integer                   `json:"minContains,omitempty"`
+		MaxContains   *integer                   `json:"maxContains,omitempty"`
 
 		*schemaWithoutMethods
 	}{
@@ -235,6 +284,41 @@ func (s *Schema) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	// Unmarshal "items" as either Items or ItemsArray.
+	if len(ms.Items) > 0 {
+		if ms.Items[0] == '[' {
+			err = json.Unmarshal(ms.Items, &s.ItemsArray)
+		} else {
+			err = json.Unmarshal(ms.Items, &s.Items)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Unmarshal each value of "dependencies" into DependencyStrings or DependencySchemas.
+	for name, raw := range ms.Dependencies {
+		if len(raw) > 0 && raw[0] == '[' {
+			var strs []string
+			if err := json.Unmarshal(raw, &strs); err != nil {
+				return err
+			}
+			if s.DependencyStrings == nil {
+				s.DependencyStrings = map[string][]string{}
+			}
+			s.DependencyStrings[name] = strs
+		} else {
+			var ds *Schema
+			if err := json.Unmarshal(raw, &ds); err != nil {
+				return err
+			}
+			if s.DependencySchemas == nil {
+				s.DependencySchemas = map[string]*Schema{}
+			}
+			s.DependencySchemas[name] = ds
+		}
+	}
+
 	unmarshalAnyPtr := func(p **any, raw json.RawMessage) error {
 		if len(raw) == 0 {
 			return nil
@@ -301,6 +385,58 @@ func (ip *integer) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// clone returns a deep copy of s.
+func (s *Schema) clone() *Schema {
+	return deepCopy(reflect.ValueOf(s)).Interface().(*Schema)
+}
+
+// deepCopy returns a deep copy of v, which must not contain cycles or
+// unexported struct fields.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for k, e := range v.Seq2() {
+			c.SetMapIndex(k, deepCopy(e))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		for i := range v.NumField() {
+			c.Field(i).Set(deepCopy(v.Field(i)))
+		}
+		return c
+	default:
+		return v
+	}
+}
+
 // Ptr returns a pointer to a new variable whose value is x.
 func Ptr[T any](x T) *T { return &x }
 
@@ -386,4 +522,13 @@ func init() {
 	for _, info := range schemaFieldInfos {
 		schemaFieldMap[info.jsonName] = info.sf
 	}
+	// Add the fields that hold subschemas but are marshaled specially.
+	// They are not in schemaFieldMap; see lookupSchemaField.
+	for name, jsonName := range map[string]string{"ItemsArray": "items", "DependencySchemas": "dependencies"} {
+		sf, _ := reflect.TypeFor[Schema]().FieldByName(name)
+		schemaFieldInfos = append(schemaFieldInfos, structFieldInfo{sf, jsonName})
+	}
+	slices.SortStableFunc(schemaFieldInfos, func(i1, i2 structFieldInfo) int {
+		return cmp.Compare(i1.jsonName, i2.jsonName)
+	})
 }
@@ -72,8 +72,14 @@ func TestJSONRoundTrip(t *testing.T) {
 			`{"comment":"test","type":"example","unk":0}`,
 			`{"type":"example","comment":"test","unk":0}`,
 		},
-		{`{"extra":0}`, `{"extra":0}`}, // extra is not a special keyword and should not be dropped
-		{`{"Extra":0}`, `{"Extra":0}`}, // Extra is not a special keyword and should not be dropped
+		{`{"extra":0}`, `{"extra":0}`},                                             // extra is not a special keyword and should not be dropped
+		{`{"Extra":0}`, `{"Extra":0}`},                                             // Extra is not a special keyword and should not be dropped
+		{`{"items":[{"type":"string"},true]}`, `{"items":[{"type":"string"},{}]}`}, // items array from earlier drafts
+		{
+			// dependencies from draft 7
+			`{"dependencies":{"b":{"type":"object"},"a":["c"]}}`,
+			`{"dependencies":{"a":["c"],"b":{"type":"object"}}}`,
+		},
 	} {
 		var s Schema
 		mustUnmarshal(t, []byte(tt.in), &s)
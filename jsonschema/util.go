@@ -15,6 +15,7 @@ import (
 	"math/big"
 	"reflect"
 	"slices"
+	"strings"
 	"sync"
 
 	"github.com/modelcontextprotocol/go-sdk/internal/util"
@@ -248,6 +249,15 @@ func jsonNumber(v reflect.Value) (*big.Rat, bool) {
 	return r, true
 }
 
+// displayValue returns a value that formats like v in error messages.
+// The zero Value, which is how a JSON null arrives at validation, formats as null.
+func displayValue(v reflect.Value) any {
+	if !v.IsValid() {
+		return "null"
+	}
+	return v
+}
+
 // jsonType returns a string describing the type of the JSON value,
 // as described in the JSON Schema specification:
 // https://json-schema.org/draft/2020-12/draft-bhutton-json-schema-validation-01#section-6.1.1.
@@ -418,3 +428,101 @@ func jsonNames(t reflect.Type) map[string]bool {
 	jsonNamesMap.Store(t, m)
 	return m
 }
+
+// A jsonField is a struct field that encoding/json marshals.
+type jsonField struct {
+	sf   reflect.StructField // Index is the path from the outermost struct
+	info util.JSONInfo
+}
+
+var jsonFieldsMap sync.Map // from reflect.Type to []jsonField
+
+// jsonFields returns the fields of struct type t that encoding/json marshals,
+// in the order it marshals them.
+// Like encoding/json, it promotes the fields of embedded structs without a JSON
+// name, and resolves conflicting names by depth and by the presence of a tag.
+// The caller must not mutate the result.
+func jsonFields(t reflect.Type) []jsonField {
+	// Lock not necessary: at worst we'll duplicate work.
+	if val, ok := jsonFieldsMap.Load(t); ok {
+		return val.([]jsonField)
+	}
+
+	type candidate struct {
+		jsonField
+		depth  int
+		tagged bool
+	}
+	var cands []candidate
+	// Walk the embedded structs breadth-first, as encoding/json does.
+	type level struct {
+		t     reflect.Type
+		index []int
+	}
+	current := []level{{t: t}}
+	visited := map[reflect.Type]bool{}
+	for depth := 0; len(current) > 0; depth++ {
+		var next []level
+		for _, l := range current {
+			if visited[l.t] {
+				continue
+			}
+			visited[l.t] = true
+			for i := range l.t.NumField() {
+				sf := l.t.Field(i)
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous {
+					// Embedded structs are visited even if unexported, because their
+					// exported fields are promoted.
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+				tag, hasTag := sf.Tag.Lookup("json")
+				if tag == "-" {
+					continue
+				}
+				name, _, _ := strings.Cut(tag, ",")
+				sf.Index = append(slices.Clip(l.index), i)
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, level{ft, sf.Index})
+					continue
+				}
+				info := util.FieldJSONInfo(sf)
+				if info.Omit {
+					// An unexported embedded struct with a JSON name.
+					continue
+				}
+				cands = append(cands, candidate{jsonField{sf, info}, depth, hasTag && name != ""})
+			}
+		}
+		current = next
+	}
+
+	// Among fields with the same name, keep the one with the shallowest depth,
+	// provided it is unique at that depth or the only one with a tag.
+	// Otherwise drop them all.
+	byName := map[string][]candidate{}
+	for _, c := range cands {
+		byName[c.info.Name] = append(byName[c.info.Name], c)
+	}
+	var fields []jsonField
+	for _, cs := range byName {
+		minDepth := slices.MinFunc(cs, func(a, b candidate) int { return cmp.Compare(a.depth, b.depth) }).depth
+		cs = slices.DeleteFunc(cs, func(c candidate) bool { return c.depth > minDepth })
+		if len(cs) > 1 {
+			cs = slices.DeleteFunc(cs, func(c candidate) bool { return !c.tagged })
+		}
+		if len(cs) == 1 {
+			fields = append(fields, cs[0].jsonField)
+		}
+	}
+	slices.SortFunc(fields, func(a, b jsonField) int { return slices.Compare(a.sf.Index, b.sf.Index) })
+	jsonFieldsMap.Store(t, fields)
+	return fields
+}
@@ -6,9 +6,11 @@ package jsonschema
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hash/maphash"
 	"iter"
+	"maps"
 	"math"
 	"math/big"
 	"reflect"
@@ -20,18 +22,23 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/internal/util"
 )
 
-// The value of the "$schema" keyword for the version that we can validate.
-const draft202012 = "https://json-schema.org/draft/2020-12/schema"
-
 // Validate validates the instance, which must be a JSON value, against the schema.
 // It returns nil if validation is successful or an error if it is not.
+// If the instance is invalid, the error is a [*ValidationError] describing
+// every failure.
 // If the schema type is "object", instance can be a map[string]any or a struct.
+//
+// The version of JSON Schema used for validation is given by the "$schema"
+// keyword, or [ResolveOptions.DefaultDialect].
 func (rs *Resolved) Validate(instance any) error {
-	if s := rs.root.Schema; s != "" && s != draft202012 {
-		return fmt.Errorf("cannot validate version %s, only %s", s, draft202012)
+	if err := rs.checkDialect(); err != nil {
+		return err
 	}
 	st := &state{rs: rs}
-	return st.validate(reflect.ValueOf(instance), st.rs.root, nil)
+	if u := st.validate(reflect.ValueOf(instance), st.rs.root, nil, "", ""); u != nil {
+		return newValidationError(u)
+	}
+	return nil
 }
 
 // validateDefaults walks the schema tree. If it finds a default, it validates it
@@ -40,14 +47,14 @@ func (rs *Resolved) Validate(instance any) error {
 // TODO(jba): account for dynamic refs. This algorithm simple-mindedly
 // treats each schema with a default as its own root.
 func (rs *Resolved) validateDefaults() error {
-	if s := rs.root.Schema; s != "" && s != draft202012 {
-		return fmt.Errorf("cannot validate version %s, only %s", s, draft202012)
+	if err := rs.checkDialect(); err != nil {
+		return err
 	}
 	st := &state{rs: rs}
 	for s := range rs.root.all() {
 		// We checked for nil schemas in [Schema.Resolve].
 		assert(s != nil, "nil schema")
-		if s.DynamicRef != "" {
+		if s.DynamicRef != "" && rs.resolvedInfos[s].dialect == draft202012 {
 			return fmt.Errorf("jsonschema: %s: validateDefaults does not support dynamic refs", rs.schemaString(s))
 		}
 		if s.Default != nil {
@@ -55,9 +62,25 @@ func (rs *Resolved) validateDefaults() error {
 			if err := json.Unmarshal(s.Default, &d); err != nil {
 				return fmt.Errorf("unmarshaling default value of schema %s: %w", rs.schemaString(s), err)
 			}
-			if err := st.validate(reflect.ValueOf(d), s, nil); err != nil {
-				return err
+			// Keyword locations start at the schema with the default.
+			loc := rs.resolvedInfos[s].path
+			if loc == "root" {
+				loc = ""
 			}
+			if u := st.validate(reflect.ValueOf(d), s, nil, "", loc); u != nil {
+				return fmt.Errorf("default value of schema %s: %w", rs.schemaString(s), newValidationError(u))
+			}
+		}
+	}
+	return nil
+}
+
+// checkDialect returns an error if the root schema's "$schema" keyword names
+// an unsupported version of JSON Schema.
+func (rs *Resolved) checkDialect() error {
+	if s := rs.root.Schema; s != "" {
+		if _, ok := dialectFor(s); !ok {
+			return fmt.Errorf("cannot validate version %s, only %s, %s and %s", s, draft202012, draft201909, draft7)
 		}
 	}
 	return nil
@@ -72,10 +95,12 @@ type state struct {
 	stack []*Schema
 }
 
-// validate validates the reflected value of the instance.
-func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *annotations) (err error) {
-	defer util.Wrapf(&err, "validating %s", st.rs.schemaString(schema))
-
+// validate validates the reflected value of the instance against schema.
+// It returns nil if the instance is valid, and otherwise an [OutputUnit]
+// describing all the failures.
+// instanceLoc is the JSON Pointer to the instance from the root instance, and
+// keywordLoc is the path of keywords that led from the root schema to schema.
+func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *annotations, instanceLoc, keywordLoc string) *OutputUnit {
 	// Maintain a stack for dynamic schema resolution.
 	st.stack = append(st.stack, schema) // push
 	defer func() {
@@ -91,22 +116,82 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 	}
 
 	schemaInfo := st.rs.resolvedInfos[schema]
+	d := schemaInfo.dialect
+
+	var anns annotations // all the annotations for this call and child calls
+
+	// The failures of this schema's keywords.
+	var errs []*OutputUnit
+	// fail records a failure of keyword, a JSON Pointer relative to schema.
+	// The causes are the failures of subschemas that led to it, if any.
+	// An empty keyword denotes the schema itself.
+	fail := func(keyword string, err error, causes ...*OutputUnit) {
+		if keyword != "" {
+			keyword = "/" + keyword
+		}
+		errs = append(errs, &OutputUnit{
+			KeywordLocation:         keywordLoc + keyword,
+			AbsoluteKeywordLocation: st.absoluteLocation(schema, keyword),
+			InstanceLocation:        instanceLoc,
+			Error:                   err.Error(),
+			Errors:                  causes,
+			err:                     err,
+		})
+	}
+	failf := func(keyword, format string, args ...any) {
+		fail(keyword, fmt.Errorf(format, args...))
+	}
+	result := func() *OutputUnit {
+		if len(errs) == 0 {
+			if callerAnns != nil {
+				// Our caller wants to know what we've validated.
+				// A schema that fails produces no annotations.
+				// https://json-schema.org/draft/2020-12/json-schema-core#section-7.7.1.2
+				callerAnns.merge(&anns)
+			}
+			return nil
+		}
+		return &OutputUnit{
+			KeywordLocation:         keywordLoc,
+			AbsoluteKeywordLocation: st.absoluteLocation(schema, ""),
+			InstanceLocation:        instanceLoc,
+			Errors:                  errs,
+		}
+	}
+	// sub validates a subinstance at instanceLoc+instancePath against the subschema ss
+	// at keywordLoc+keyword. If it fails, sub records the failure and returns false.
+	sub := func(inst reflect.Value, ss *Schema, anns *annotations, instancePath, keyword string) bool {
+		if u := st.validate(inst, ss, anns, instanceLoc+instancePath, keywordLoc+"/"+keyword); u != nil {
+			errs = append(errs, u)
+			return false
+		}
+		return true
+	}
+
+	// In draft 7, $ref overrides all other keywords.
+	// https://json-schema.org/draft-07/draft-handrews-json-schema-01#rfc.section.8.3
+	if d == draft7 && schema.Ref != "" {
+		sub(instance, schemaInfo.resolvedRef, &anns, "", "$ref")
+		return result()
+	}
 
 	// type: https://json-schema.org/draft/2020-12/draft-bhutton-json-schema-validation-01#section-6.1.1
 	if schema.Type != "" || schema.Types != nil {
 		gotType, ok := jsonType(instance)
 		if !ok {
-			return fmt.Errorf("type: %v of type %[1]T is not a valid JSON value", instance)
+			// Nothing else can be checked.
+			failf("type", "%v of type %[1]T is not a valid JSON value", instance)
+			return result()
 		}
 		if schema.Type != "" {
 			// "number" subsumes integers
 			if !(gotType == schema.Type ||
 				gotType == "integer" && schema.Type == "number") {
-				return fmt.Errorf("type: %v has type %q, want %q", instance, gotType, schema.Type)
+				failf("type", "%v has type %q, want %q", displayValue(instance), gotType, schema.Type)
 			}
 		} else {
 			if !(slices.Contains(schema.Types, gotType) || (gotType == "integer" && slices.Contains(schema.Types, "number"))) {
-				return fmt.Errorf("type: %v has type %q, want one of %q",
+				failf("type", "%v has type %q, want one of %q",
 					instance, gotType, strings.Join(schema.Types, ", "))
 			}
 		}
@@ -121,14 +206,14 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 			}
 		}
 		if !ok {
-			return fmt.Errorf("enum: %v does not equal any of: %v", instance, schema.Enum)
+			failf("enum", "%v does not equal any of: %v", displayValue(instance), schema.Enum)
 		}
 	}
 
 	// const: https://json-schema.org/draft/2020-12/draft-bhutton-json-schema-validation-01#section-6.1.3
 	if schema.Const != nil {
 		if !equalValue(reflect.ValueOf(*schema.Const), instance) {
-			return fmt.Errorf("const: %v does not equal %v", instance, *schema.Const)
+			failf("const", "%v does not equal %v", displayValue(instance), *schema.Const)
 		}
 	}
 
@@ -141,7 +226,7 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 				// The test suite assumes floats.
 				nf, _ := n.Float64() // don't care if it's exact or not
 				if _, f := math.Modf(nf / *schema.MultipleOf); f != 0 {
-					return fmt.Errorf("multipleOf: %s is not a multiple of %f", n, *schema.MultipleOf)
+					failf("multipleOf", "%s is not a multiple of %f", n, *schema.MultipleOf)
 				}
 			}
 
@@ -149,16 +234,16 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 			cmp := func(f float64) int { return n.Cmp(m.SetFloat64(f)) }
 
 			if schema.Minimum != nil && cmp(*schema.Minimum) < 0 {
-				return fmt.Errorf("minimum: %s is less than %f", n, *schema.Minimum)
+				failf("minimum", "%s is less than %f", n, *schema.Minimum)
 			}
 			if schema.Maximum != nil && cmp(*schema.Maximum) > 0 {
-				return fmt.Errorf("maximum: %s is greater than %f", n, *schema.Maximum)
+				failf("maximum", "%s is greater than %f", n, *schema.Maximum)
 			}
 			if schema.ExclusiveMinimum != nil && cmp(*schema.ExclusiveMinimum) <= 0 {
-				return fmt.Errorf("exclusiveMinimum: %s is less than or equal to %f", n, *schema.ExclusiveMinimum)
+				failf("exclusiveMinimum", "%s is less than or equal to %f", n, *schema.ExclusiveMinimum)
 			}
 			if schema.ExclusiveMaximum != nil && cmp(*schema.ExclusiveMaximum) >= 0 {
-				return fmt.Errorf("exclusiveMaximum: %s is greater than or equal to %f", n, *schema.ExclusiveMaximum)
+				failf("exclusiveMaximum", "%s is greater than or equal to %f", n, *schema.ExclusiveMaximum)
 			}
 		}
 	}
@@ -169,64 +254,71 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 		n := utf8.RuneCountInString(str)
 		if schema.MinLength != nil {
 			if m := *schema.MinLength; n < m {
-				return fmt.Errorf("minLength: %q contains %d Unicode code points, fewer than %d", str, n, m)
+				failf("minLength", "%q contains %d Unicode code points, fewer than %d", str, n, m)
 			}
 		}
 		if schema.MaxLength != nil {
 			if m := *schema.MaxLength; n > m {
-				return fmt.Errorf("maxLength: %q contains %d Unicode code points, more than %d", str, n, m)
+				failf("maxLength", "%q contains %d Unicode code points, more than %d", str, n, m)
 			}
 		}
 
 		if schema.Pattern != "" && !schemaInfo.pattern.MatchString(str) {
-			return fmt.Errorf("pattern: %q does not match regular expression %q", str, schema.Pattern)
+			failf("pattern", "%q does not match regular expression %q", str, schema.Pattern)
 		}
 	}
 
-	var anns annotations // all the annotations for this call and child calls
+	// format: https://json-schema.org/draft/2020-12/draft-bhutton-json-schema-validation-01#section-7
+	// All the defined formats apply only to strings, and so do custom ones.
+	if schema.Format != "" && instance.Kind() == reflect.String && st.rs.formats != nil {
+		if check := st.rs.formats[schema.Format]; check != nil {
+			if err := check(instance.String()); err != nil {
+				fail("format", fmt.Errorf("%q is not a valid %s: %w", instance.String(), schema.Format, err))
+			}
+		}
+	}
+
+	// content: https://json-schema.org/draft/2020-12/draft-bhutton-json-schema-validation-01#section-8
+	if st.rs.validateContent && instance.Kind() == reflect.String && (schema.ContentEncoding != "" || schema.ContentMediaType != "") {
+		content, ok := []byte(instance.String()), true
+		if schema.ContentEncoding != "" {
+			b, known, err := decodeContent(instance.String(), schema.ContentEncoding)
+			if err != nil {
+				fail("contentEncoding", fmt.Errorf("cannot decode %s content: %w", schema.ContentEncoding, err))
+				ok = false
+			} else if known {
+				content = b
+			}
+		}
+		if ok && schema.ContentMediaType != "" && isJSONMediaType(schema.ContentMediaType) {
+			var v any
+			if err := json.Unmarshal(content, &v); err != nil {
+				fail("contentMediaType", fmt.Errorf("content is not valid %s: %w", schema.ContentMediaType, err))
+			} else if schema.ContentSchema != nil && d != draft7 {
+				// The decoded document is a separate instance, so its
+				// annotations don't apply to this one.
+				sub(reflect.ValueOf(v), schema.ContentSchema, nil, "", "contentSchema")
+			}
+		}
+	}
 
 	// $ref: https://json-schema.org/draft/2020-12/json-schema-core#section-8.2.3.1
 	if schema.Ref != "" {
-		if err := st.validate(instance, schemaInfo.resolvedRef, &anns); err != nil {
-			return err
-		}
+		sub(instance, schemaInfo.resolvedRef, &anns, "", "$ref")
+	}
+
+	// $recursiveRef: https://json-schema.org/draft/2019-09/json-schema-core#rfc.section.8.2.4.2
+	if schema.RecursiveRef != "" && d == draft201909 {
+		sub(instance, st.resolveRecursiveRef(schema), &anns, "", "$recursiveRef")
 	}
 
 	// $dynamicRef: https://json-schema.org/draft/2020-12/json-schema-core#section-8.2.3.2
-	if schema.DynamicRef != "" {
-		// The ref behaves lexically or dynamically, but not both.
-		assert((schemaInfo.resolvedDynamicRef == nil) != (schemaInfo.dynamicRefAnchor == ""),
-			"DynamicRef not resolved properly")
-		if schemaInfo.resolvedDynamicRef != nil {
-			// Same as $ref.
-			if err := st.validate(instance, schemaInfo.resolvedDynamicRef, &anns); err != nil {
-				return err
-			}
+	if schema.DynamicRef != "" && d == draft202012 {
+		dynamicSchema, err := st.resolveDynamicRef(schema)
+		if err != nil {
+			fail("$dynamicRef", err)
 		} else {
-			// Dynamic behavior.
-			// Look for the base of the outermost schema on the stack with this dynamic
-			// anchor. (Yes, outermost: the one farthest from here. This the opposite
-			// of how ordinary dynamic variables behave.)
-			// Why the base of the schema being validated and not the schema itself?
-			// Because the base is the scope for anchors. In fact it's possible to
-			// refer to a schema that is not on the stack, but a child of some base
-			// on the stack.
-			// For an example, search for "detached" in testdata/draft2020-12/dynamicRef.json.
-			var dynamicSchema *Schema
-			for _, s := range st.stack {
-				base := st.rs.resolvedInfos[s].base
-				info, ok := st.rs.resolvedInfos[base].anchors[schemaInfo.dynamicRefAnchor]
-				if ok && info.dynamic {
-					dynamicSchema = info.schema
-					break
-				}
-			}
-			if dynamicSchema == nil {
-				return fmt.Errorf("missing dynamic anchor %q", schemaInfo.dynamicRefAnchor)
-			}
-			if err := st.validate(instance, dynamicSchema, &anns); err != nil {
-				return err
-			}
+			sub(instance, dynamicSchema, &anns, "", "$dynamicRef")
 		}
 	}
 
@@ -239,59 +331,60 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 	// If any of these fail, then validation fails, even if there is an unevaluatedXXX
 	// keyword in the schema. The spec is unclear about this, but that is the intention.
 
-	valid := func(s *Schema, anns *annotations) bool { return st.validate(instance, s, anns) == nil }
+	// try validates the instance against the subschema at keyword, returning the failure, if any,
+	// without recording it.
+	try := func(ss *Schema, anns *annotations, keyword string) *OutputUnit {
+		return st.validate(instance, ss, anns, instanceLoc, keywordLoc+"/"+keyword)
+	}
 
 	if schema.AllOf != nil {
-		for _, ss := range schema.AllOf {
-			if err := st.validate(instance, ss, &anns); err != nil {
-				return err
-			}
+		for i, ss := range schema.AllOf {
+			sub(instance, ss, &anns, "", fmt.Sprintf("allOf/%d", i))
 		}
 	}
 	if schema.AnyOf != nil {
 		// We must visit them all, to collect annotations.
-		ok := false
-		for _, ss := range schema.AnyOf {
-			if valid(ss, &anns) {
-				ok = true
+		var causes []*OutputUnit
+		for i, ss := range schema.AnyOf {
+			if u := try(ss, &anns, fmt.Sprintf("anyOf/%d", i)); u != nil {
+				causes = append(causes, u)
 			}
 		}
-		if !ok {
-			return fmt.Errorf("anyOf: did not validate against any of %v", schema.AnyOf)
+		if len(causes) == len(schema.AnyOf) {
+			fail("anyOf", errors.New("did not validate against any subschema"), causes...)
 		}
 	}
 	if schema.OneOf != nil {
 		// Exactly one.
-		var okSchema *Schema
-		for _, ss := range schema.OneOf {
-			if valid(ss, &anns) {
-				if okSchema != nil {
-					return fmt.Errorf("oneOf: validated against both %v and %v", okSchema, ss)
-				}
-				okSchema = ss
+		okIndex := -1
+		var causes []*OutputUnit
+		for i, ss := range schema.OneOf {
+			if u := try(ss, &anns, fmt.Sprintf("oneOf/%d", i)); u != nil {
+				causes = append(causes, u)
+			} else if okIndex >= 0 {
+				failf("oneOf", "validated against both subschemas %d and %d", okIndex, i)
+				break
+			} else {
+				okIndex = i
 			}
 		}
-		if okSchema == nil {
-			return fmt.Errorf("oneOf: did not validate against any of %v", schema.OneOf)
+		if okIndex < 0 {
+			fail("oneOf", errors.New("did not validate against any subschema"), causes...)
 		}
 	}
 	if schema.Not != nil {
 		// Ignore annotations from "not".
-		if valid(schema.Not, nil) {
-			return fmt.Errorf("not: validated against %v", schema.Not)
+		if try(schema.Not, nil, "not") == nil {
+			failf("not", "validated against %v", schema.Not)
 		}
 	}
 	if schema.If != nil {
-		var ss *Schema
-		if valid(schema.If, &anns) {
-			ss = schema.Then
-		} else {
-			ss = schema.Else
-		}
-		if ss != nil {
-			if err := st.validate(instance, ss, &anns); err != nil {
-				return err
+		if try(schema.If, &anns, "if") == nil {
+			if schema.Then != nil {
+				sub(instance, schema.Then, &anns, "", "then")
 			}
+		} else if schema.Else != nil {
+			sub(instance, schema.Else, &anns, "", "else")
 		}
 	}
 
@@ -302,59 +395,61 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 		// This validate call doesn't collect annotations for the items of the instance; they are separate
 		// instances in their own right.
 		// TODO(jba): if the test suite doesn't cover this case, add a test. For example, nested arrays.
-		for i, ischema := range schema.PrefixItems {
+		prefixItems, prefixKeyword, restItems, restKeyword := itemSchemas(schema, d)
+		for i, ischema := range prefixItems {
 			if i >= instance.Len() {
 				break // shorter is OK
 			}
-			if err := st.validate(instance.Index(i), ischema, nil); err != nil {
-				return err
-			}
+			sub(instance.Index(i), ischema, nil, fmt.Sprintf("/%d", i), fmt.Sprintf("%s/%d", prefixKeyword, i))
 		}
-		anns.noteEndIndex(min(len(schema.PrefixItems), instance.Len()))
+		anns.noteEndIndex(min(len(prefixItems), instance.Len()))
 
-		if schema.Items != nil {
-			for i := len(schema.PrefixItems); i < instance.Len(); i++ {
-				if err := st.validate(instance.Index(i), schema.Items, nil); err != nil {
-					return err
-				}
+		if restItems != nil {
+			for i := len(prefixItems); i < instance.Len(); i++ {
+				sub(instance.Index(i), restItems, nil, fmt.Sprintf("/%d", i), restKeyword)
 			}
 			// Note that all the items in this array have been validated.
 			anns.allItems = true
 		}
 
+		// minContains and maxContains were introduced in draft 2019-09.
+		minContains, maxContains := schema.MinContains, schema.MaxContains
+		if d == draft7 {
+			minContains, maxContains = nil, nil
+		}
 		nContains := 0
 		if schema.Contains != nil {
 			for i := range instance.Len() {
-				if err := st.validate(instance.Index(i), schema.Contains, nil); err == nil {
+				if st.validate(instance.Index(i), schema.Contains, nil, fmt.Sprintf("%s/%d", instanceLoc, i), keywordLoc+"/contains") == nil {
 					nContains++
 					anns.noteIndex(i)
 				}
 			}
-			if nContains == 0 && (schema.MinContains == nil || *schema.MinContains > 0) {
-				return fmt.Errorf("contains: %s does not have an item matching %s", instance, schema.Contains)
+			if nContains == 0 && (minContains == nil || *minContains > 0) {
+				failf("contains", "%s does not have an item matching %s", instance, schema.Contains)
 			}
 		}
 
 		// https://json-schema.org/draft/2020-12/draft-bhutton-json-schema-validation-01#section-6.4
 		// TODO(jba): check that these next four keywords' values are integers.
-		if schema.MinContains != nil && schema.Contains != nil {
-			if m := *schema.MinContains; nContains < m {
-				return fmt.Errorf("minContains: contains validated %d items, less than %d", nContains, m)
+		if minContains != nil && schema.Contains != nil {
+			if m := *minContains; nContains < m {
+				failf("minContains", "contains validated %d items, less than %d", nContains, m)
 			}
 		}
-		if schema.MaxContains != nil && schema.Contains != nil {
-			if m := *schema.MaxContains; nContains > m {
-				return fmt.Errorf("maxContains: contains validated %d items, greater than %d", nContains, m)
+		if maxContains != nil && schema.Contains != nil {
+			if m := *maxContains; nContains > m {
+				failf("maxContains", "contains validated %d items, greater than %d", nContains, m)
 			}
 		}
 		if schema.MinItems != nil {
 			if m := *schema.MinItems; instance.Len() < m {
-				return fmt.Errorf("minItems: array length %d is less than %d", instance.Len(), m)
+				failf("minItems", "array length %d is less than %d", instance.Len(), m)
 			}
 		}
 		if schema.MaxItems != nil {
 			if m := *schema.MaxItems; instance.Len() > m {
-				return fmt.Errorf("maxItems: array length %d is greater than %d", instance.Len(), m)
+				failf("maxItems", "array length %d is greater than %d", instance.Len(), m)
 			}
 		}
 		if schema.UniqueItems {
@@ -366,6 +461,7 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 				// TODO(jba): Use container/hash.Map when it becomes available (https://go.dev/issue/69559),
 				hashes := map[uint64][]int{} // from hash to indices
 				seed := maphash.MakeSeed()
+			uniqueLoop:
 				for i := range instance.Len() {
 					item := instance.Index(i)
 					var h maphash.Hash
@@ -375,7 +471,8 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 					if sames := hashes[hv]; len(sames) > 0 {
 						for _, j := range sames {
 							if equalValue(item, instance.Index(j)) {
-								return fmt.Errorf("uniqueItems: array items %d and %d are equal", i, j)
+								failf("uniqueItems", "array items %d and %d are equal", i, j)
+								break uniqueLoop
 							}
 						}
 					}
@@ -385,14 +482,12 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 		}
 
 		// https://json-schema.org/draft/2020-12/json-schema-core#section-11.2
-		if schema.UnevaluatedItems != nil && !anns.allItems {
+		if schema.UnevaluatedItems != nil && !anns.allItems && d != draft7 {
 			// Apply this subschema to all items in the array that haven't been successfully validated.
 			// That includes validations by subschemas on the same instance, like allOf.
 			for i := anns.endIndex; i < instance.Len(); i++ {
 				if !anns.evaluatedIndexes[i] {
-					if err := st.validate(instance.Index(i), schema.UnevaluatedItems, nil); err != nil {
-						return err
-					}
+					sub(instance.Index(i), schema.UnevaluatedItems, nil, fmt.Sprintf("/%d", i), "unevaluatedItems")
 				}
 			}
 			anns.allItems = true
@@ -404,12 +499,16 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 	if instance.Kind() == reflect.Map || instance.Kind() == reflect.Struct {
 		if instance.Kind() == reflect.Map {
 			if kt := instance.Type().Key(); kt.Kind() != reflect.String {
-				return fmt.Errorf("map key type %s is not a string", kt)
+				// Nothing else can be checked.
+				fail("", fmt.Errorf("map key type %s is not a string", kt))
+				return result()
 			}
 		}
 		// Track the evaluated properties for just this schema, to support additionalProperties.
 		// If we used anns here, then we'd be including properties evaluated in subschemas
 		// from allOf, etc., which additionalProperties shouldn't observe.
+		// A property is noted even if it fails validation: a failing schema produces no
+		// annotations anyway, and this way unevaluatedProperties doesn't report it again.
 		evalProps := map[string]bool{}
 		for prop, subschema := range schema.Properties {
 			val := property(instance, prop)
@@ -423,9 +522,8 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 			if instance.Kind() == reflect.Struct && val.IsZero() && !schemaInfo.isRequired[prop] {
 				continue
 			}
-			if err := st.validate(val, subschema, nil); err != nil {
-				return err
-			}
+			p := escapeJSONPointerSegment(prop)
+			sub(val, subschema, nil, "/"+p, "properties/"+p)
 			evalProps[prop] = true
 		}
 		if len(schema.PatternProperties) > 0 {
@@ -433,9 +531,7 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 				// Check every matching pattern.
 				for re, schema := range schemaInfo.patternProperties {
 					if re.MatchString(prop) {
-						if err := st.validate(val, schema, nil); err != nil {
-							return err
-						}
+						sub(val, schema, nil, "/"+escapeJSONPointerSegment(prop), "patternProperties/"+escapeJSONPointerSegment(re.String()))
 						evalProps[prop] = true
 					}
 				}
@@ -445,9 +541,7 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 			// Apply to all properties not handled above.
 			for prop, val := range properties(instance) {
 				if !evalProps[prop] {
-					if err := st.validate(val, schema.AdditionalProperties, nil); err != nil {
-						return err
-					}
+					sub(val, schema.AdditionalProperties, nil, "/"+escapeJSONPointerSegment(prop), "additionalProperties")
 					evalProps[prop] = true
 				}
 			}
@@ -456,10 +550,9 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 		if schema.PropertyNames != nil {
 			// Note: properties unnecessarily fetches each value. We could define a propertyNames function
 			// if performance ever matters.
+			// A property name has no location of its own, so failures are reported at the property.
 			for prop := range properties(instance) {
-				if err := st.validate(reflect.ValueOf(prop), schema.PropertyNames, nil); err != nil {
-					return err
-				}
+				sub(reflect.ValueOf(prop), schema.PropertyNames, nil, "/"+escapeJSONPointerSegment(prop), "propertyNames")
 			}
 		}
 
@@ -470,12 +563,12 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 		}
 		if schema.MinProperties != nil {
 			if n, m := max, *schema.MinProperties; n < m {
-				return fmt.Errorf("minProperties: object has %d properties, less than %d", n, m)
+				failf("minProperties", "object has %d properties, less than %d", n, m)
 			}
 		}
 		if schema.MaxProperties != nil {
 			if n, m := min, *schema.MaxProperties; n > m {
-				return fmt.Errorf("maxProperties: object has %d properties, greater than %d", n, m)
+				failf("maxProperties", "object has %d properties, greater than %d", n, m)
 			}
 		}
 
@@ -495,43 +588,53 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 
 		if schema.Required != nil {
 			if m := missingProperties(schema.Required); len(m) > 0 {
-				return fmt.Errorf("required: missing properties: %q", m)
+				failf("required", "missing properties: %q", m)
 			}
 		}
-		if schema.DependentRequired != nil {
+		// dependentRequired and dependentSchemas replaced the "dependencies" keyword of
+		// draft 7 in draft 2019-09.
+		if d == draft7 {
+			for dprop, reqs := range schema.DependencyStrings {
+				if hasProperty(dprop) {
+					if m := missingProperties(reqs); len(m) > 0 {
+						failf("dependencies/"+escapeJSONPointerSegment(dprop), "missing properties %q", m)
+					}
+				}
+			}
+			for dprop, ss := range schema.DependencySchemas {
+				if hasProperty(dprop) {
+					sub(instance, ss, &anns, "", "dependencies/"+escapeJSONPointerSegment(dprop))
+				}
+			}
+		}
+		if schema.DependentRequired != nil && d != draft7 {
 			// "Validation succeeds if, for each name that appears in both the instance
 			// and as a name within this keyword's value, every item in the corresponding
 			// array is also the name of a property in the instance." §6.5.4
 			for dprop, reqs := range schema.DependentRequired {
 				if hasProperty(dprop) {
 					if m := missingProperties(reqs); len(m) > 0 {
-						return fmt.Errorf("dependentRequired[%q]: missing properties %q", dprop, m)
+						failf("dependentRequired/"+escapeJSONPointerSegment(dprop), "missing properties %q", m)
 					}
 				}
 			}
 		}
 
 		// https://json-schema.org/draft/2020-12/json-schema-core#section-10.2.2.4
-		if schema.DependentSchemas != nil {
+		if schema.DependentSchemas != nil && d != draft7 {
 			// This does not collect annotations, although it seems like it should.
 			for dprop, ss := range schema.DependentSchemas {
 				if hasProperty(dprop) {
-					// TODO: include dependentSchemas[dprop] in the errors.
-					err := st.validate(instance, ss, &anns)
-					if err != nil {
-						return err
-					}
+					sub(instance, ss, &anns, "", "dependentSchemas/"+escapeJSONPointerSegment(dprop))
 				}
 			}
 		}
-		if schema.UnevaluatedProperties != nil && !anns.allProperties {
+		if schema.UnevaluatedProperties != nil && !anns.allProperties && d != draft7 {
 			// This looks a lot like AdditionalProperties, but depends on in-place keywords like allOf
 			// in addition to sibling keywords.
 			for prop, val := range properties(instance) {
 				if !anns.evaluatedProperties[prop] {
-					if err := st.validate(val, schema.UnevaluatedProperties, nil); err != nil {
-						return err
-					}
+					sub(val, schema.UnevaluatedProperties, nil, "/"+escapeJSONPointerSegment(prop), "unevaluatedProperties")
 				}
 			}
 			// The spec says the annotation should be the set of evaluated properties, but we can optimize
@@ -541,11 +644,27 @@ func (st *state) validate(instance reflect.Value, schema *Schema, callerAnns *an
 		}
 	}
 
-	if callerAnns != nil {
-		// Our caller wants to know what we've validated.
-		callerAnns.merge(&anns)
+	return result()
+}
+
+// absoluteLocation returns the absolute keyword location of keyword, a JSON
+// Pointer relative to s: the URI of s's base, with a fragment that locates
+// the keyword within the base.
+// It returns the empty string if the base URI is not absolute.
+func (st *state) absoluteLocation(s *Schema, keyword string) string {
+	info := st.rs.resolvedInfos[s]
+	baseInfo := st.rs.resolvedInfos[info.base]
+	if baseInfo.uri == nil || !baseInfo.uri.IsAbs() {
+		return ""
 	}
-	return nil
+	// Paths are JSON Pointers from the document root, except that the root is "root".
+	pointer := func(path string) string {
+		if path == "root" {
+			return ""
+		}
+		return path
+	}
+	return baseInfo.uri.String() + "#" + strings.TrimPrefix(pointer(info.path), pointer(baseInfo.path)) + keyword
 }
 
 // resolveDynamicRef returns the schema referred to by the argument schema's
@@ -584,6 +703,33 @@ func (st *state) resolveDynamicRef(schema *Schema) (*Schema, error) {
 	return nil, fmt.Errorf("missing dynamic anchor %q", info.dynamicRefAnchor)
 }
 
+// resolveRecursiveRef returns the schema referred to by the argument schema's
+// $recursiveRef value.
+// If the schema that $recursiveRef refers to lexically has "$recursiveAnchor": true,
+// then the result is the outermost schema resource in the dynamic scope that can
+// be reached from the innermost one through resources that also have
+// "$recursiveAnchor": true.
+// See https://json-schema.org/draft/2019-09/json-schema-core#rfc.section.8.2.4.2.
+func (st *state) resolveRecursiveRef(schema *Schema) *Schema {
+	target := st.rs.resolvedInfos[schema].resolvedRecursiveRef
+	if !target.RecursiveAnchor {
+		return target
+	}
+	var prev *Schema
+	for _, s := range slices.Backward(st.stack) {
+		base := st.rs.resolvedInfos[s].base
+		if base == prev {
+			continue
+		}
+		prev = base
+		if !base.RecursiveAnchor {
+			break
+		}
+		target = base
+	}
+	return target
+}
+
 // ApplyDefaults modifies an instance by applying the schema's defaults to it. If
 // a schema or sub-schema has a default, then a corresponding zero instance value
 // is set to the default.
@@ -596,6 +742,15 @@ func (st *state) resolveDynamicRef(schema *Schema) (*Schema, error) {
 // its value is zero, the field is set to the default.
 // ApplyDefaults can panic if a default cannot be assigned to a field.
 //
+// Defaults are applied recursively: to the values of properties, using the schemas
+// of "properties"; to the elements of arrays, using the schemas of "prefixItems"
+// and "items"; and through "$ref" and "$dynamicRef". A property value that was
+// just set to its default also gets the defaults of its sub-schemas.
+// ApplyDefaults never creates an object or array that is absent (or nil)
+// in the instance, except as the default of a property. So to have defaults
+// applied within a missing object property, give that property a default of {}.
+// Array elements are never added.
+//
 // The argument must be a pointer to the instance.
 // (In case we decide that top-level defaults are meaningful.)
 //
@@ -603,58 +758,133 @@ func (st *state) resolveDynamicRef(schema *Schema) (*Schema, error) {
 // then call this method, and lastly call Validate.
 //
 // TODO(jba): consider what defaults on top-level or array instances might mean.
-// TODO(jba): follow $ref and $dynamicRef
-// TODO(jba): apply defaults on sub-schemas to corresponding sub-instances.
-func (rs *Resolved) ApplyDefaults(instancep any) error {
+func (rs *Resolved) ApplyDefaults(instancep any) (err error) {
+	defer util.Wrapf(&err, "applyDefaults: schema %s, instance %v", rs.schemaString(rs.root), instancep)
+
 	st := &state{rs: rs}
 	return st.applyDefaults(reflect.ValueOf(instancep), rs.root)
 }
 
-// Leave this as a potentially recursive helper function, because we'll surely want
-// to apply defaults on sub-schemas someday.
-func (st *state) applyDefaults(instancep reflect.Value, schema *Schema) (err error) {
-	defer util.Wrapf(&err, "applyDefaults: schema %s, instance %v", st.rs.schemaString(schema), instancep)
+// applyDefaults applies the defaults of schema to the instance v, and recursively
+// to its sub-instances.
+// If v is not settable, values in it are modified only if they are reachable
+// through a pointer, map or slice.
+func (st *state) applyDefaults(v reflect.Value, schema *Schema) error {
+	st.stack = append(st.stack, schema) // push
+	defer func() {
+		st.stack = st.stack[:len(st.stack)-1] // pop
+	}()
+
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		// Work on a settable copy of the dynamic value. Maps and slices share
+		// their contents with the original, so this matters only for other values,
+		// which are copied back if possible.
+		c := reflect.New(v.Elem().Type()).Elem()
+		c.Set(v.Elem())
+		if err := st.applyDefaults(c, schema); err != nil {
+			return err
+		}
+		if v.CanSet() {
+			v.Set(c)
+		}
+		return nil
+	}
 
 	schemaInfo := st.rs.resolvedInfos[schema]
-	instance := instancep.Elem()
-	if instance.Kind() == reflect.Map || instance.Kind() == reflect.Struct {
-		if instance.Kind() == reflect.Map {
-			if kt := instance.Type().Key(); kt.Kind() != reflect.String {
-				return fmt.Errorf("map key type %s is not a string", kt)
+	switch v.Kind() {
+	case reflect.Map:
+		if kt := v.Type().Key(); kt.Kind() != reflect.String {
+			return fmt.Errorf("map key type %s is not a string", kt)
+		}
+		if v.IsNil() {
+			// There is nowhere to put the defaults.
+			return nil
+		}
+		for _, prop := range slices.Sorted(maps.Keys(schema.Properties)) {
+			subschema := schema.Properties[prop]
+			// Map values aren't addressable, so work on a copy.
+			val := reflect.New(v.Type().Elem()).Elem()
+			if mv := v.MapIndex(reflect.ValueOf(prop)); mv.IsValid() {
+				val.Set(mv)
+			} else if subschema.Default != nil && !schemaInfo.isRequired[prop] {
+				// Ignore defaults on required properties. (A required property shouldn't have a default.)
+				// If there is a default for this property, and the map key is missing,
+				// set the map value to the default.
+				if err := json.Unmarshal(subschema.Default, val.Addr().Interface()); err != nil {
+					return err
+				}
+			} else {
+				continue
+			}
+			if err := st.applyDefaults(val, subschema); err != nil {
+				return err
 			}
+			v.SetMapIndex(reflect.ValueOf(prop), val)
 		}
-		for prop, subschema := range schema.Properties {
-			// Ignore defaults on required properties. (A required property shouldn't have a default.)
-			if schemaInfo.isRequired[prop] {
+
+	case reflect.Struct:
+		for _, prop := range slices.Sorted(maps.Keys(schema.Properties)) {
+			subschema := schema.Properties[prop]
+			val := property(v, prop)
+			if !val.IsValid() {
 				continue
 			}
-			val := property(instance, prop)
-			switch instance.Kind() {
-			case reflect.Map:
-				// If there is a default for this property, and the map key is missing,
-				// set the map value to the default.
-				if subschema.Default != nil && !val.IsValid() {
-					// Create an lvalue, since map values aren't addressable.
-					lvalue := reflect.New(instance.Type().Elem())
-					if err := json.Unmarshal(subschema.Default, lvalue.Interface()); err != nil {
-						return err
-					}
-					instance.SetMapIndex(reflect.ValueOf(prop), lvalue.Elem())
-				}
-			case reflect.Struct:
-				// If there is a default for this property, and the field exists but is zero,
-				// set the field to the default.
-				if subschema.Default != nil && val.IsValid() && val.IsZero() {
-					if err := json.Unmarshal(subschema.Default, val.Addr().Interface()); err != nil {
-						return err
-					}
+			// If there is a default for this property, and the field exists but is zero,
+			// set the field to the default.
+			if subschema.Default != nil && !schemaInfo.isRequired[prop] && val.IsZero() && val.CanAddr() {
+				if err := json.Unmarshal(subschema.Default, val.Addr().Interface()); err != nil {
+					return err
 				}
-			default:
-				panic(fmt.Sprintf("applyDefaults: property %s: bad value %s of kind %s",
-					prop, instance, instance.Kind()))
+			}
+			if err := st.applyDefaults(val, subschema); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		prefixItems, _, restItems, _ := itemSchemas(schema, schemaInfo.dialect)
+		for i := range v.Len() {
+			itemSchema := restItems
+			if i < len(prefixItems) {
+				itemSchema = prefixItems[i]
+			}
+			if itemSchema == nil {
+				continue
+			}
+			if err := st.applyDefaults(v.Index(i), itemSchema); err != nil {
+				return err
 			}
 		}
 	}
+
+	if schema.Ref != "" {
+		if err := st.applyDefaults(v, schemaInfo.resolvedRef); err != nil {
+			return err
+		}
+	}
+	if schema.RecursiveRef != "" && schemaInfo.dialect == draft201909 {
+		if err := st.applyDefaults(v, st.resolveRecursiveRef(schema)); err != nil {
+			return err
+		}
+	}
+	if schema.DynamicRef != "" && schemaInfo.dialect == draft202012 {
+		dschema, err := st.resolveDynamicRef(schema)
+		if err != nil {
+			return err
+		}
+		if err := st.applyDefaults(v, dschema); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -672,7 +902,7 @@ func property(v reflect.Value, name string) reflect.Value {
 		props := structPropertiesOf(v.Type())
 		// Ignore nonexistent properties.
 		if sf, ok := props[name]; ok {
-			return v.FieldByIndex(sf.Index)
+			return fieldByIndex(v, sf.Index)
 		}
 		return reflect.Value{}
 	default:
@@ -695,7 +925,10 @@ func properties(v reflect.Value) iter.Seq2[string, reflect.Value] {
 			}
 		case reflect.Struct:
 			for name, sf := range structPropertiesOf(v.Type()) {
-				val := v.FieldByIndex(sf.Index)
+				val := fieldByIndex(v, sf.Index)
+				if !val.IsValid() {
+					continue
+				}
 				if val.IsZero() {
 					info := util.FieldJSONInfo(sf)
 					if info.Settings["omitempty"] || info.Settings["omitzero"] {
@@ -726,7 +959,7 @@ func numPropertiesBounds(v reflect.Value, isRequired map[string]bool) (int, int)
 		sp := structPropertiesOf(v.Type())
 		min := 0
 		for prop, sf := range sp {
-			if !v.FieldByIndex(sf.Index).IsZero() || isRequired[prop] {
+			if f := fieldByIndex(v, sf.Index); f.IsValid() && !f.IsZero() || isRequired[prop] {
 				min++
 			}
 		}
@@ -749,12 +982,21 @@ func structPropertiesOf(t reflect.Type) propertyMap {
 		return props.(propertyMap)
 	}
 	props := map[string]reflect.StructField{}
-	for _, sf := range reflect.VisibleFields(t) {
-		info := util.FieldJSONInfo(sf)
-		if !info.Omit {
-			props[info.Name] = sf
-		}
+	for _, f := range jsonFields(t) {
+		props[f.info.Name] = f.sf
 	}
 	structProperties.Store(t, props)
 	return props
 }
+
+// fieldByIndex returns the field of the struct v with the given index sequence,
+// or the invalid reflect.Value if the field is in an embedded struct that
+// is reached through a nil pointer. Like encoding/json, we treat such a
+// field as absent.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	f, err := v.FieldByIndexErr(index)
+	if err != nil {
+		return reflect.Value{}
+	}
+	return f
+}
@@ -6,6 +6,7 @@ package jsonschema
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -13,6 +14,9 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 // The test for validation uses the official test suite, expressed as a set of JSON files.
@@ -33,7 +37,24 @@ type test struct {
 }
 
 func TestValidate(t *testing.T) {
-	files, err := filepath.Glob(filepath.FromSlash("testdata/draft2020-12/*.json"))
+	// The tests for each draft are in a directory of testdata,
+	// and are run with that draft as the default dialect.
+	for _, dir := range []struct {
+		name    string
+		dialect string
+	}{
+		{"draft2020-12", ""},
+		{"draft2019-09", draft201909URI},
+		{"draft7", draft7URI},
+	} {
+		t.Run(dir.name, func(t *testing.T) {
+			testValidateDir(t, filepath.Join("testdata", dir.name), dir.dialect)
+		})
+	}
+}
+
+func testValidateDir(t *testing.T, dir, dialect string) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -53,7 +74,7 @@ func TestValidate(t *testing.T) {
 			}
 			for _, g := range groups {
 				t.Run(g.Description, func(t *testing.T) {
-					rs, err := g.Schema.Resolve(&ResolveOptions{Loader: loadRemote})
+					rs, err := g.Schema.Resolve(&ResolveOptions{Loader: loadRemote, DefaultDialect: dialect})
 					if err != nil {
 						t.Fatal(err)
 					}
@@ -93,6 +114,117 @@ func TestValidateErrors(t *testing.T) {
 	}
 }
 
+func TestValidationError(t *testing.T) {
+	schema := &Schema{
+		ID:   "https://example.com/person",
+		Type: "object",
+		Properties: map[string]*Schema{
+			"name": {Type: "string", MinLength: Ptr(1)},
+			"age":  {Ref: "#/$defs/age"},
+			"tags": {Items: &Schema{AnyOf: []*Schema{{Type: "string"}, {Type: "integer"}}}},
+		},
+		Required: []string{"name", "email"},
+		Defs:     map[string]*Schema{"age": {Type: "integer", Minimum: Ptr(0.0)}},
+	}
+	rs, err := schema.Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rs.Validate(map[string]any{"name": "", "age": -1.5, "tags": []any{"a", true}})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("got %v, want a *ValidationError", err)
+	}
+
+	const abs = "https://example.com/person#"
+	wantBasic := &OutputUnit{
+		AbsoluteKeywordLocation: abs,
+		Errors: []*OutputUnit{
+			{
+				KeywordLocation:         "/properties/age/$ref/minimum",
+				AbsoluteKeywordLocation: abs + "/$defs/age/minimum",
+				InstanceLocation:        "/age",
+				Error:                   "-3/2 is less than 0.000000",
+			},
+			{
+				KeywordLocation:         "/properties/age/$ref/type",
+				AbsoluteKeywordLocation: abs + "/$defs/age/type",
+				InstanceLocation:        "/age",
+				Error:                   `-1.5 has type "number", want "integer"`,
+			},
+			{
+				KeywordLocation:         "/properties/name/minLength",
+				AbsoluteKeywordLocation: abs + "/properties/name/minLength",
+				InstanceLocation:        "/name",
+				Error:                   `"" contains 0 Unicode code points, fewer than 1`,
+			},
+			{
+				KeywordLocation:         "/properties/tags/items/anyOf",
+				AbsoluteKeywordLocation: abs + "/properties/tags/items/anyOf",
+				InstanceLocation:        "/tags/1",
+				Error:                   "did not validate against any subschema",
+			},
+			{
+				KeywordLocation:         "/properties/tags/items/anyOf/0/type",
+				AbsoluteKeywordLocation: abs + "/properties/tags/items/anyOf/0/type",
+				InstanceLocation:        "/tags/1",
+				Error:                   `true has type "boolean", want "string"`,
+			},
+			{
+				KeywordLocation:         "/properties/tags/items/anyOf/1/type",
+				AbsoluteKeywordLocation: abs + "/properties/tags/items/anyOf/1/type",
+				InstanceLocation:        "/tags/1",
+				Error:                   `true has type "boolean", want "integer"`,
+			},
+			{
+				KeywordLocation:         "/required",
+				AbsoluteKeywordLocation: abs + "/required",
+				Error:                   `missing properties: ["email"]`,
+			},
+		},
+	}
+	if diff := cmp.Diff(wantBasic, verr.Basic(), cmpopts.IgnoreUnexported(OutputUnit{})); diff != "" {
+		t.Errorf("Basic mismatch (-want +got):\n%s", diff)
+	}
+
+	// The detailed format nests the failures according to the schema, omitting
+	// units with a single child.
+	var shape func(*OutputUnit) string
+	shape = func(u *OutputUnit) string {
+		var cs []string
+		for _, c := range u.Errors {
+			cs = append(cs, shape(c))
+		}
+		s := u.KeywordLocation
+		if len(cs) > 0 {
+			s += "[" + strings.Join(cs, " ") + "]"
+		}
+		return s
+	}
+	wantShape := "[/properties/age/$ref[/properties/age/$ref/minimum /properties/age/$ref/type] " +
+		"/properties/name/minLength " +
+		"/properties/tags/items/anyOf[/properties/tags/items/anyOf/0/type /properties/tags/items/anyOf/1/type] " +
+		"/required]"
+	if got := shape(verr.Detailed()); got != wantShape {
+		t.Errorf("Detailed:\ngot  %s\nwant %s", got, wantShape)
+	}
+
+	// The JSON form follows the specification.
+	data, err := json.Marshal(verr.Detailed().Errors[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `{"valid":false,"keywordLocation":"/properties/name/minLength","absoluteKeywordLocation":"https://example.com/person#/properties/name/minLength","instanceLocation":"/name","error":"\"\" contains 0 Unicode code points, fewer than 1"}`
+	if string(data) != wantJSON {
+		t.Errorf("JSON:\ngot  %s\nwant %s", data, wantJSON)
+	}
+
+	wantErr := `instance "": keyword "/required": missing properties: ["email"]`
+	if !strings.Contains(verr.Error(), wantErr) {
+		t.Errorf("error:\n%s\ndoes not contain %q", verr, wantErr)
+	}
+}
+
 func TestValidateDefaults(t *testing.T) {
 	s := &Schema{
 		Properties: map[string]*Schema{
@@ -259,6 +391,36 @@ func TestStructInstance(t *testing.T) {
 	}
 }
 
+func TestEmbeddedStructInstance(t *testing.T) {
+	type Inner struct{ A, B int }
+	type Outer struct {
+		*Inner
+		B string
+	}
+	// The fields of Inner are promoted, as with encoding/json, and B is hidden.
+	schema := &Schema{
+		Properties: map[string]*Schema{
+			"A": {Type: "integer", Minimum: Ptr(1.0)},
+			"B": {Type: "string"},
+		},
+		AdditionalProperties: falseSchema(),
+	}
+	rs, err := schema.Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Validate(Outer{&Inner{A: 1}, "b"}); err != nil {
+		t.Error(err)
+	}
+	if err := rs.Validate(Outer{&Inner{A: -1}, "b"}); err == nil {
+		t.Error("A = -1: succeeded unexpectedly")
+	}
+	// Fields behind a nil pointer are absent.
+	if err := rs.Validate(Outer{B: "b"}); err != nil {
+		t.Error(err)
+	}
+}
+
 func mustMarshal(x any) json.RawMessage {
 	data, err := json.Marshal(x)
 	if err != nil {
@@ -273,11 +435,6 @@ func loadRemote(uri *url.URL) (*Schema, error) {
 	if uri.Host == "localhost:1234" {
 		return loadSchemaFromFile(filepath.FromSlash(filepath.Join("testdata/remotes", uri.Path)))
 	}
-	// One test needs the meta-schema files.
-	const metaPrefix = "https://json-schema.org/draft/2020-12/"
-	if after, ok := strings.CutPrefix(uri.String(), metaPrefix); ok {
-		return loadSchemaFromFile(filepath.FromSlash("meta-schemas/draft2020-12/" + after + ".json"))
-	}
 	return nil, fmt.Errorf("don't know how to load %s", uri)
 }
 
@@ -292,3 +449,125 @@ func loadSchemaFromFile(filename string) (*Schema, error) {
 	}
 	return &s, nil
 }
+
+func TestApplyDefaultsRecursive(t *testing.T) {
+	schema := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"options": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"verbose": {Type: "boolean", Default: mustMarshal(true)},
+					"level":   {Type: "integer", Default: mustMarshal(3)},
+				},
+			},
+			"limits": {Ref: "#/$defs/limits", Default: mustMarshal(map[string]any{})},
+			"tags": {
+				Type: "array",
+				Items: &Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{"color": {Default: mustMarshal("red")}},
+				},
+			},
+			"pair": {
+				Type: "array",
+				PrefixItems: []*Schema{
+					{Properties: map[string]*Schema{"x": {Default: mustMarshal(1)}}},
+					{Properties: map[string]*Schema{"y": {Default: mustMarshal(2)}}},
+				},
+			},
+			"missing": {
+				Type:       "object",
+				Properties: map[string]*Schema{"z": {Default: mustMarshal(0)}},
+			},
+		},
+		Defs: map[string]*Schema{
+			"limits": {
+				Type:       "object",
+				Properties: map[string]*Schema{"max": {Default: mustMarshal(10)}},
+			},
+		},
+	}
+	rs, err := schema.Resolve(&ResolveOptions{ValidateDefaults: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("map", func(t *testing.T) {
+		m := map[string]any{
+			"options": map[string]any{"level": 1},
+			"tags":    []any{map[string]any{}, map[string]any{"color": "blue"}},
+			"pair":    []any{map[string]any{}, map[string]any{}},
+		}
+		if err := rs.ApplyDefaults(&m); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]any{
+			"options": map[string]any{"level": 1, "verbose": true},
+			"limits":  map[string]any{"max": float64(10)},
+			"tags":    []any{map[string]any{"color": "red"}, map[string]any{"color": "blue"}},
+			"pair":    []any{map[string]any{"x": float64(1)}, map[string]any{"y": float64(2)}},
+			// "missing" is not created: it has no default.
+		}
+		if diff := cmp.Diff(want, m); diff != "" {
+			t.Errorf("mismatch (-want, +got):\n%s", diff)
+		}
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		type options struct {
+			Verbose bool `json:"verbose,omitempty"`
+			Level   int  `json:"level,omitempty"`
+		}
+		type limits struct {
+			Max int `json:"max,omitempty"`
+		}
+		type tag struct {
+			Color string `json:"color,omitempty"`
+		}
+		type S struct {
+			Options options  `json:"options"`
+			Limits  *limits  `json:"limits,omitempty"`
+			Tags    []tag    `json:"tags,omitempty"`
+			Missing *options `json:"missing,omitempty"`
+		}
+		s := S{Options: options{Level: 1}, Tags: []tag{{}, {Color: "blue"}}}
+		if err := rs.ApplyDefaults(&s); err != nil {
+			t.Fatal(err)
+		}
+		want := S{
+			Options: options{Verbose: true, Level: 1},
+			Limits:  &limits{Max: 10},
+			Tags:    []tag{{Color: "red"}, {Color: "blue"}},
+		}
+		if diff := cmp.Diff(want, s); diff != "" {
+			t.Errorf("mismatch (-want, +got):\n%s", diff)
+		}
+	})
+	t.Run("nil map", func(t *testing.T) {
+		// Nil maps are left alone.
+		s := struct {
+			Options map[string]any `json:"options"`
+		}{}
+		if err := rs.ApplyDefaults(&s); err != nil {
+			t.Fatal(err)
+		}
+		if s.Options != nil {
+			t.Errorf("got options %v, want nil", s.Options)
+		}
+		m := map[string]any{"options": nil}
+		if err := rs.ApplyDefaults(&m); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(map[string]any{"options": nil, "limits": map[string]any{"max": float64(10)}}, m); diff != "" {
+			t.Errorf("mismatch (-want, +got):\n%s", diff)
+		}
+		mm := map[string]map[string]any{"options": nil}
+		if err := rs.ApplyDefaults(&mm); err != nil {
+			t.Fatal(err)
+		}
+		if mm["options"] != nil {
+			t.Errorf("got options %v, want nil", mm["options"])
+		}
+	})
+}
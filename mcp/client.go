@@ -6,6 +6,8 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"iter"
 	"slices"
@@ -14,6 +16,7 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/internal/jsonrpc2"
 	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 )
 
 // A Client is an MCP client, which may be connected to an MCP server
@@ -56,6 +59,9 @@ type ClientOptions struct {
 	// Handler for sampling.
 	// Called when a server calls CreateMessage.
 	CreateMessageHandler func(context.Context, *ClientSession, *CreateMessageParams) (*CreateMessageResult, error)
+	// Handler for elicitation.
+	// Called when a server calls Elicit.
+	ElicitationHandler func(context.Context, *ClientSession, *ElicitParams) (*ElicitResult, error)
 	// Handlers for notifications from the server.
 	ToolListChangedHandler      func(context.Context, *ClientSession, *ToolListChangedParams)
 	PromptListChangedHandler    func(context.Context, *ClientSession, *PromptListChangedParams)
@@ -119,6 +125,9 @@ func (c *Client) Connect(ctx context.Context, t Transport) (cs *ClientSession, e
 	if c.opts.CreateMessageHandler != nil {
 		caps.Sampling = &SamplingCapabilities{}
 	}
+	if c.opts.ElicitationHandler != nil {
+		caps.Elicitation = &ElicitationCapabilities{}
+	}
 
 	params := &InitializeParams{
 		ProtocolVersion: latestProtocolVersion,
@@ -161,6 +170,17 @@ type ClientSession struct {
 	initializeResult *InitializeResult
 	keepaliveCancel  context.CancelFunc
 	mcpConn          Connection
+
+	// toolsMu guards the following fields.
+	toolsMu sync.Mutex
+	// outputSchemas maps the names of the server's tools to their resolved
+	// output schemas (nil if the tool has none), as reported by tools/list.
+	// It is loaded on demand by CallToolFor, and cleared when the server
+	// reports that its tool list has changed.
+	outputSchemas map[string]*jsonschema.Resolved
+	// toolsGen is incremented whenever outputSchemas is invalidated, so that a
+	// load that races with an invalidation does not store a stale result.
+	toolsGen int
 }
 
 func (cs *ClientSession) setConn(c Connection) {
@@ -255,6 +275,13 @@ func (c *Client) createMessage(ctx context.Context, cs *ClientSession, params *C
 	return c.opts.CreateMessageHandler(ctx, cs, params)
 }
 
+func (c *Client) elicit(ctx context.Context, cs *ClientSession, params *ElicitParams) (*ElicitResult, error) {
+	if c.opts.ElicitationHandler == nil {
+		return nil, &jsonrpc2.WireError{Code: CodeUnsupportedMethod, Message: "client does not support elicitation"}
+	}
+	return c.opts.ElicitationHandler(ctx, cs, params)
+}
+
 // AddSendingMiddleware wraps the current sending method handler using the provided
 // middleware. Middleware is applied from right to left, so that the first one is
 // executed first.
@@ -295,6 +322,7 @@ var clientMethodInfos = map[string]methodInfo{
 	methodPing:                      newMethodInfo(sessionMethod((*ClientSession).ping), missingParamsOK),
 	methodListRoots:                 newMethodInfo(clientMethod((*Client).listRoots), missingParamsOK),
 	methodCreateMessage:             newMethodInfo(clientMethod((*Client).createMessage), 0),
+	methodElicit:                    newMethodInfo(clientMethod((*Client).elicit), 0),
 	notificationToolListChanged:     newMethodInfo(clientMethod((*Client).callToolChangedHandler), notification|missingParamsOK),
 	notificationPromptListChanged:   newMethodInfo(clientMethod((*Client).callPromptChangedHandler), notification|missingParamsOK),
 	notificationResourceListChanged: newMethodInfo(clientMethod((*Client).callResourceChangedHandler), notification|missingParamsOK),
@@ -368,6 +396,128 @@ func (cs *ClientSession) CallTool(ctx context.Context, params *CallToolParams) (
 	return handleSend[*CallToolResult](ctx, cs, methodCallTool, params)
 }
 
+// CallToolFor calls the tool with the given name and typed arguments, and
+// decodes the structured content of the result into Out.
+//
+// If the tool has an output schema, as reported by tools/list, the structured
+// content is validated against it before decoding. CallToolFor caches the tool
+// list for the session. It discards the cache when the server notifies the
+// client that the list has changed, and loads the list again when the tool is
+// not in the cache.
+//
+// If the result's structured content is missing, does not validate, or cannot be
+// decoded into Out, CallToolFor returns a *[ToolOutputError].
+// Results with IsError set are returned without examining their structured content.
+func CallToolFor[In, Out any](ctx context.Context, cs *ClientSession, params *CallToolParamsFor[In]) (*CallToolResultFor[Out], error) {
+	if params == nil {
+		params = new(CallToolParamsFor[In])
+	}
+	raw, err := cs.CallTool(ctx, &CallToolParams{
+		Meta:      params.Meta,
+		Name:      params.Name,
+		Arguments: params.Arguments,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &CallToolResultFor[Out]{
+		Meta:    raw.Meta,
+		Content: raw.Content,
+		IsError: raw.IsError,
+	}
+	if raw.IsError {
+		return res, nil
+	}
+	resolved, err := cs.toolOutputSchema(ctx, params.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := decodeStructuredContent(raw.StructuredContent, resolved, &res.StructuredContent); err != nil {
+		return nil, &ToolOutputError{Tool: params.Name, Err: err}
+	}
+	return res, nil
+}
+
+// A ToolOutputError is returned by [CallToolFor] when the structured content of
+// a tool result does not conform to the tool's output schema, or cannot be
+// decoded into the requested type.
+type ToolOutputError struct {
+	Tool string // name of the tool
+	Err  error
+}
+
+func (e *ToolOutputError) Error() string {
+	return fmt.Sprintf("tool %q: invalid structured content: %v", e.Tool, e.Err)
+}
+
+func (e *ToolOutputError) Unwrap() error { return e.Err }
+
+// decodeStructuredContent validates content against resolved, if non-nil, and
+// unmarshals it into out.
+func decodeStructuredContent(content any, resolved *jsonschema.Resolved, out any) error {
+	if content == nil {
+		if resolved != nil {
+			return errors.New("missing")
+		}
+		return nil
+	}
+	if resolved != nil {
+		if err := resolved.Validate(content); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decoding: %w", err)
+	}
+	return nil
+}
+
+// toolOutputSchema returns the resolved output schema for the named tool,
+// loading the session's tool list if it is not cached, or if the cached list
+// does not have the tool.
+// It returns nil if the tool has no output schema, or is unknown.
+func (cs *ClientSession) toolOutputSchema(ctx context.Context, name string) (*jsonschema.Resolved, error) {
+	cs.toolsMu.Lock()
+	schemas, gen := cs.outputSchemas, cs.toolsGen
+	cs.toolsMu.Unlock()
+	if resolved, ok := schemas[name]; ok {
+		return resolved, nil
+	}
+	// The tool may have been added since the list was loaded.
+	schemas = map[string]*jsonschema.Resolved{}
+	for tool, err := range cs.Tools(ctx, nil) {
+		if err != nil {
+			return nil, err
+		}
+		var resolved *jsonschema.Resolved
+		if tool.OutputSchema != nil {
+			resolved, err = tool.OutputSchema.Resolve(nil)
+			if err != nil {
+				return nil, fmt.Errorf("resolving output schema of tool %q: %w", tool.Name, err)
+			}
+		}
+		schemas[tool.Name] = resolved
+	}
+	cs.toolsMu.Lock()
+	if cs.toolsGen == gen {
+		cs.outputSchemas = schemas
+	}
+	cs.toolsMu.Unlock()
+	return schemas[name], nil
+}
+
+// invalidateTools discards the cached tool list.
+func (cs *ClientSession) invalidateTools() {
+	cs.toolsMu.Lock()
+	defer cs.toolsMu.Unlock()
+	cs.outputSchemas = nil
+	cs.toolsGen++
+}
+
 func (cs *ClientSession) SetLevel(ctx context.Context, params *SetLevelParams) error {
 	_, err := handleSend[*emptyResult](ctx, cs, methodSetLevel, orZero[Params](params))
 	return err
@@ -407,6 +557,7 @@ func (cs *ClientSession) Unsubscribe(ctx context.Context, params *UnsubscribePar
 }
 
 func (c *Client) callToolChangedHandler(ctx context.Context, s *ClientSession, params *ToolListChangedParams) (Result, error) {
+	s.invalidateTools()
 	return callNotificationHandler(ctx, c.opts.ToolListChangedHandler, s, params)
 }
 
@@ -6,6 +6,8 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -190,3 +192,95 @@ func TestClientPaginateVariousPageSizes(t *testing.T) {
 		})
 	}
 }
+
+func TestCallToolFor(t *testing.T) {
+	type (
+		In struct {
+			X int `json:"x"`
+		}
+		Out struct {
+			Double int `json:"double"`
+		}
+	)
+	double := func(_ context.Context, _ *ServerSession, params *CallToolParamsFor[In]) (*CallToolResultFor[Out], error) {
+		return &CallToolResultFor[Out]{StructuredContent: Out{Double: 2 * params.Arguments.X}}, nil
+	}
+
+	ctx := context.Background()
+	ct, st := NewInMemoryTransports()
+	s := NewServer(testImpl, nil)
+	AddTool(s, &Tool{Name: "double"}, double)
+	AddTool(s, &Tool{Name: "liar"}, double)
+	// Corrupt the structured content of the "liar" tool after the server has
+	// validated it, to simulate a misbehaving server.
+	s.AddReceivingMiddleware(func(next MethodHandler[*ServerSession]) MethodHandler[*ServerSession] {
+		return func(ctx context.Context, ss *ServerSession, method string, params Params) (Result, error) {
+			res, err := next(ctx, ss, method, params)
+			if p, ok := params.(*CallToolParamsFor[json.RawMessage]); ok && p.Name == "liar" {
+				res.(*CallToolResult).StructuredContent = map[string]any{}
+			}
+			return res, err
+		}
+	})
+	ss, err := s.Connect(ctx, st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close()
+
+	toolsChanged := make(chan struct{}, 10)
+	c := NewClient(testImpl, &ClientOptions{
+		ToolListChangedHandler: func(context.Context, *ClientSession, *ToolListChangedParams) { toolsChanged <- struct{}{} },
+	})
+	cs, err := c.Connect(ctx, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	res, err := CallToolFor[In, Out](ctx, cs, &CallToolParamsFor[In]{Name: "double", Arguments: In{X: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(Out{Double: 6}, res.StructuredContent); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = CallToolFor[In, Out](ctx, cs, &CallToolParamsFor[In]{Name: "liar", Arguments: In{X: 3}})
+	var toolErr *ToolOutputError
+	if !errors.As(err, &toolErr) || toolErr.Tool != "liar" {
+		t.Fatalf("got %v, want ToolOutputError for liar", err)
+	}
+
+	// If the cached tool list doesn't have the tool, the client loads it again,
+	// and still validates the result.
+	cs.toolsMu.Lock()
+	cs.outputSchemas = map[string]*jsonschema.Resolved{}
+	cs.toolsMu.Unlock()
+	if _, err = CallToolFor[In, Out](ctx, cs, &CallToolParamsFor[In]{Name: "liar", Arguments: In{X: 3}}); !errors.As(err, &toolErr) {
+		t.Errorf("uncached tool: got %v, want ToolOutputError for liar", err)
+	}
+
+	// Replace the tool with one whose output schema differs from the cached
+	// one: its results are missing the "double" property that the old schema
+	// requires. The client must validate them against the new schema.
+	s.AddTool(&Tool{
+		Name:         "double",
+		InputSchema:  &jsonschema.Schema{},
+		OutputSchema: &jsonschema.Schema{Type: "object", Required: []string{"triple"}},
+	}, func(context.Context, *ServerSession, *CallToolParamsFor[map[string]any]) (*CallToolResult, error) {
+		return &CallToolResult{StructuredContent: map[string]any{"triple": 9}}, nil
+	})
+	<-toolsChanged
+	_, err = CallToolFor[In, Out](ctx, cs, &CallToolParamsFor[In]{Name: "double", Arguments: In{X: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddTool(&Tool{Name: "double", InputSchema: &jsonschema.Schema{}}, func(context.Context, *ServerSession, *CallToolParamsFor[map[string]any]) (*CallToolResult, error) {
+		return &CallToolResult{StructuredContent: map[string]any{"double": 1.5}}, nil
+	})
+	<-toolsChanged
+	if _, err = CallToolFor[In, Out](ctx, cs, &CallToolParamsFor[In]{Name: "double", Arguments: In{X: 3}}); !errors.As(err, &toolErr) {
+		t.Errorf("got %v, want ToolOutputError for undecodable content", err)
+	}
+}
@@ -5,18 +5,97 @@
 package mcp
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
+	"os"
 	"os/exec"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/internal/jsonrpc2"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+// Defaults for CommandTransportOptions.
+const (
+	defaultStderrLimit      = 4096
+	defaultCloseTimeout     = 5 * time.Second
+	defaultRestarts         = 5
+	defaultRestartDelay     = 100 * time.Millisecond
+	defaultMaxRestartDelay  = 10 * time.Second
+	defaultTerminateTimeout = 5 * time.Second
 )
 
 // A CommandTransport is a [Transport] that runs a command and communicates
 // with it over stdin/stdout, using newline-delimited JSON.
 type CommandTransport struct {
-	cmd *exec.Cmd
+	cmd    *exec.Cmd
+	stderr io.Writer // the original cmd.Stderr
+	opts   CommandTransportOptions
+}
+
+// CommandTransportOptions are options for the
+// [NewCommandTransportWithOptions] constructor.
+type CommandTransportOptions struct {
+	// StderrLimit is the number of bytes of the command's most recent output
+	// to stderr that are kept, to be included in the error reported when the
+	// command exits. The output is also written to the command's Stderr, if
+	// it is set.
+	// If zero, it is 4096. If negative, the output is not kept.
+	StderrLimit int
+	// Logger, if non-nil, receives each line that the command writes to
+	// stderr, and a message for each restart.
+	Logger *slog.Logger
+	// CloseTimeout is how long to wait for the command to exit after its
+	// stdin is closed, before sending it SIGTERM.
+	// If zero, it is 5 seconds.
+	CloseTimeout time.Duration
+	// TerminateTimeout is how long to wait for the command to exit after
+	// sending it SIGTERM, before killing it.
+	// If zero, it is 5 seconds.
+	TerminateTimeout time.Duration
+	// Restart, if non-nil, causes the command to be restarted when it exits
+	// unexpectedly.
+	Restart *CommandRestartOptions
+}
+
+// CommandRestartOptions configure how a [CommandTransport] restarts a command
+// that exits unexpectedly.
+//
+// When the command exits, the requests that are awaiting its responses fail.
+// After a delay, a new command is started, and the connection sends it the
+// initialize handshake of the session, the last "logging/setLevel" request,
+// and a "resources/subscribe" request for each resource that is still
+// subscribed, so that the session can continue as before.
+// Restarts are not visible to the [ClientSession] otherwise.
+type CommandRestartOptions struct {
+	// NewCommand returns a new command to run in place of the one that
+	// exited. It is needed because an [exec.Cmd] cannot be reused.
+	// If nil, the new command has the Path, Args, Env, Dir and SysProcAttr of
+	// the original command. Provide NewCommand to restart a command created
+	// by [exec.CommandContext], or to change the command between restarts.
+	NewCommand func() *exec.Cmd
+	// MaxRestarts is the maximum number of restarts in a row. If the command
+	// exits again after that many, the connection fails. The count is
+	// reset when a command has run for longer than MaxDelay.
+	// If zero, it is 5. If negative, there is no limit.
+	MaxRestarts int
+	// InitialDelay is the delay before the first restart in a row.
+	// The delay doubles with each subsequent restart, up to MaxDelay.
+	// If zero, it is 100 milliseconds.
+	InitialDelay time.Duration
+	// MaxDelay is the maximum delay before a restart.
+	// If zero, it is 10 seconds.
+	MaxDelay time.Duration
 }
 
 // NewCommandTransport returns a [CommandTransport] that runs the given command
@@ -25,73 +104,215 @@ type CommandTransport struct {
 // The resulting transport takes ownership of the command, starting it during
 // [CommandTransport.Connect], and stopping it when the connection is closed.
 func NewCommandTransport(cmd *exec.Cmd) *CommandTransport {
-	return &CommandTransport{cmd}
+	return NewCommandTransportWithOptions(cmd, nil)
+}
+
+// NewCommandTransportWithOptions is like [NewCommandTransport], but accepts
+// options. If opts is nil, the default options are used.
+func NewCommandTransportWithOptions(cmd *exec.Cmd, opts *CommandTransportOptions) *CommandTransport {
+	t := &CommandTransport{cmd: cmd, stderr: cmd.Stderr}
+	if opts != nil {
+		t.opts = *opts
+	}
+	if t.opts.StderrLimit == 0 {
+		t.opts.StderrLimit = defaultStderrLimit
+	}
+	if t.opts.CloseTimeout == 0 {
+		t.opts.CloseTimeout = defaultCloseTimeout
+	}
+	if t.opts.TerminateTimeout == 0 {
+		t.opts.TerminateTimeout = defaultTerminateTimeout
+	}
+	if r := t.opts.Restart; r != nil {
+		r2 := *r
+		if r2.MaxRestarts == 0 {
+			r2.MaxRestarts = defaultRestarts
+		}
+		if r2.InitialDelay == 0 {
+			r2.InitialDelay = defaultRestartDelay
+		}
+		if r2.MaxDelay == 0 {
+			r2.MaxDelay = defaultMaxRestartDelay
+		}
+		t.opts.Restart = &r2
+	}
+	return t
 }
 
 // Connect starts the command, and connects to it over stdin/stdout.
 func (t *CommandTransport) Connect(ctx context.Context) (Connection, error) {
-	stdout, err := t.cmd.StdoutPipe()
+	p, err := t.start(t.cmd)
+	if err != nil {
+		return nil, err
+	}
+	if t.opts.Restart == nil {
+		return newIOConn(p), nil
+	}
+	return &restartingConn{
+		t:         t,
+		done:      make(chan struct{}),
+		proc:      p,
+		conn:      newIOConn(p),
+		pending:   make(map[jsonrpc.ID]bool),
+		replayIDs: make(map[jsonrpc.ID]bool),
+	}, nil
+}
+
+// start starts cmd, capturing its stderr according to the options.
+func (t *CommandTransport) start(cmd *exec.Cmd) (*pipeRWC, error) {
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, err
 	}
 	stdout = io.NopCloser(stdout) // close the connection by closing stdin, not stdout
-	stdin, err := t.cmd.StdinPipe()
+	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return nil, err
 	}
-	if err := t.cmd.Start(); err != nil {
+	p := &pipeRWC{
+		cmd:    cmd,
+		stdout: stdout,
+		stdin:  stdin,
+		opts:   &t.opts,
+		done:   make(chan struct{}),
+	}
+	var stderrs []io.Writer
+	if t.stderr != nil {
+		stderrs = append(stderrs, t.stderr)
+	}
+	if t.opts.StderrLimit > 0 {
+		p.stderr = &tailBuffer{limit: t.opts.StderrLimit}
+		stderrs = append(stderrs, p.stderr)
+	}
+	if t.opts.Logger != nil {
+		stderrs = append(stderrs, &lineLogger{logger: t.opts.Logger})
+	}
+	if len(stderrs) > 0 {
+		cmd.Stderr = io.MultiWriter(stderrs...)
+	}
+	if err := cmd.Start(); err != nil {
 		return nil, err
 	}
-	return newIOConn(&pipeRWC{t.cmd, stdout, stdin}), nil
+	p.started = time.Now()
+	return p, nil
+}
+
+// newCommand returns a command to replace the one that exited.
+func (t *CommandTransport) newCommand() *exec.Cmd {
+	if f := t.opts.Restart.NewCommand; f != nil {
+		return f()
+	}
+	return &exec.Cmd{
+		Path:        t.cmd.Path,
+		Args:        slices.Clone(t.cmd.Args),
+		Env:         slices.Clone(t.cmd.Env),
+		Dir:         t.cmd.Dir,
+		SysProcAttr: t.cmd.SysProcAttr,
+	}
 }
 
 // A pipeRWC is an io.ReadWriteCloser that communicates with a subprocess over
 // stdin/stdout pipes.
 type pipeRWC struct {
-	cmd    *exec.Cmd
-	stdout io.ReadCloser
-	stdin  io.WriteCloser
+	cmd     *exec.Cmd
+	stdout  io.ReadCloser
+	stdin   io.WriteCloser
+	stderr  *tailBuffer // the end of the command's stderr; nil if not kept
+	opts    *CommandTransportOptions
+	started time.Time
+
+	closing  atomic.Bool // set when Close is called
+	waitOnce sync.Once
+	done     chan struct{} // closed when the command has exited
+	waitErr  error         // the result of cmd.Wait; set before done is closed
 }
 
+// Read reads from the command's stdout. If the command closes stdout without
+// a call to Close, which usually means that it has exited, Read waits for the
+// command to exit and returns an error that describes the exit.
 func (s *pipeRWC) Read(p []byte) (n int, err error) {
-	return s.stdout.Read(p)
+	n, err = s.stdout.Read(p)
+	if err != nil && !s.closing.Load() {
+		select {
+		case <-s.wait():
+			err = s.exitError(s.waitErr)
+		case <-time.After(s.opts.CloseTimeout):
+			err = s.withStderr(fmt.Errorf("server closed its output: %w", err))
+		}
+	}
+	return n, err
 }
 
 func (s *pipeRWC) Write(p []byte) (n int, err error) {
 	return s.stdin.Write(p)
 }
 
+// wait starts waiting for the command, and returns a channel that is closed
+// when it has exited.
+//
+// It must not be called until reads from stdout are complete, because
+// cmd.Wait closes the pipe.
+func (s *pipeRWC) wait() <-chan struct{} {
+	s.waitOnce.Do(func() {
+		go func() {
+			s.waitErr = s.cmd.Wait()
+			close(s.done)
+		}()
+	})
+	return s.done
+}
+
+// exitError returns an error for the unexpected exit of the command, whose
+// Wait method returned err.
+func (s *pipeRWC) exitError(err error) error {
+	if err == nil {
+		err = errors.New("exit status 0")
+	}
+	return s.withStderr(fmt.Errorf("server exited: %w", err))
+}
+
+// withStderr adds the end of the command's stderr, if any, to a non-nil err.
+func (s *pipeRWC) withStderr(err error) error {
+	if err == nil || s.stderr == nil {
+		return err
+	}
+	if tail := s.stderr.String(); tail != "" {
+		return fmt.Errorf("%w; stderr:\n%s", err, tail)
+	}
+	return err
+}
+
 // Close closes the input stream to the child process, and awaits normal
 // termination of the command. If the command does not exit, it is signalled to
 // terminate, and then eventually killed.
+//
+// If the command exits with an error, the error includes the end of its stderr.
 func (s *pipeRWC) Close() error {
+	s.closing.Store(true)
 	// Spec:
 	// "For the stdio transport, the client SHOULD initiate shutdown by:...
 
 	// "...First, closing the input stream to the child process (the server)"
-	if err := s.stdin.Close(); err != nil {
+	// If the command has already exited, Wait has closed stdin.
+	if err := s.stdin.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
 		return fmt.Errorf("closing stdin: %v", err)
 	}
-	resChan := make(chan error, 1)
-	go func() {
-		resChan <- s.cmd.Wait()
-	}()
 	// "...Waiting for the server to exit, or sending SIGTERM if the server does not exit within a reasonable time"
-	wait := func() (error, bool) {
+	wait := func(d time.Duration) (error, bool) {
 		select {
-		case err := <-resChan:
-			return err, true
-		case <-time.After(5 * time.Second):
+		case <-s.wait():
+			return s.withStderr(s.waitErr), true
+		case <-time.After(d):
 		}
 		return nil, false
 	}
-	if err, ok := wait(); ok {
+	if err, ok := wait(s.opts.CloseTimeout); ok {
 		return err
 	}
 	// Note the condition here: if sending SIGTERM fails, don't wait and just
 	// move on to SIGKILL.
 	if err := s.cmd.Process.Signal(syscall.SIGTERM); err == nil {
-		if err, ok := wait(); ok {
+		if err, ok := wait(s.opts.TerminateTimeout); ok {
 			return err
 		}
 	}
@@ -99,8 +320,342 @@ func (s *pipeRWC) Close() error {
 	if err := s.cmd.Process.Kill(); err != nil {
 		return err
 	}
-	if err, ok := wait(); ok {
+	if err, ok := wait(s.opts.TerminateTimeout); ok {
 		return err
 	}
 	return fmt.Errorf("unresponsive subprocess")
 }
+
+// A tailBuffer is an io.Writer that keeps the last bytes written to it.
+type tailBuffer struct {
+	limit int
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.limit {
+		b.buf = append(b.buf[:0], b.buf[len(b.buf)-b.limit:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
+
+// A lineLogger is an io.Writer that logs each line written to it.
+type lineLogger struct {
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	partial []byte // the incomplete last line
+}
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.partial = append(l.partial, p...)
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i < 0 {
+			break
+		}
+		l.logger.Info("server stderr", "text", string(bytes.TrimSuffix(l.partial[:i], []byte("\r"))))
+		l.partial = l.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// A restartingConn is a [Connection] to a command that is restarted when it
+// exits unexpectedly. See [CommandRestartOptions].
+type restartingConn struct {
+	t    *CommandTransport
+	done chan struct{} // closed by Close
+
+	// writeMu serializes writes to conn. It is held while the session state
+	// is replayed to a new command, so that other writes follow the replay.
+	writeMu sync.Mutex
+
+	mu        sync.Mutex
+	proc      *pipeRWC
+	conn      *ioConn
+	closed    bool
+	state     sessionState
+	pending   map[jsonrpc.ID]bool // calls awaiting responses
+	replayIDs map[jsonrpc.ID]bool // replayed calls awaiting responses
+	nextID    int
+
+	// Accessed only by Read, which is not called concurrently.
+	queue    []jsonrpc.Message // messages to return before reading
+	restarts int               // restarts in a row
+}
+
+func (c *restartingConn) SessionID() string { return "" }
+
+// Read implements jsonrpc2.Reader.
+func (c *restartingConn) Read(ctx context.Context) (jsonrpc.Message, error) {
+	for {
+		if len(c.queue) > 0 {
+			msg := c.queue[0]
+			c.queue = c.queue[1:]
+			return msg, nil
+		}
+		c.mu.Lock()
+		conn := c.conn
+		c.mu.Unlock()
+		msg, err := conn.Read(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			if err := c.restart(ctx, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp, ok := msg.(*jsonrpc.Response); ok {
+			c.mu.Lock()
+			replayed := c.replayIDs[resp.ID]
+			delete(c.replayIDs, resp.ID)
+			delete(c.pending, resp.ID)
+			c.mu.Unlock()
+			if replayed {
+				continue
+			}
+		}
+		return msg, nil
+	}
+}
+
+// Write implements jsonrpc2.Writer.
+func (c *restartingConn) Write(ctx context.Context, msg jsonrpc.Message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.mu.Lock()
+	req, _ := msg.(*jsonrpc.Request)
+	if req != nil {
+		c.state.record(req)
+		if req.IsCall() {
+			c.pending[req.ID] = true
+		}
+	}
+	conn := c.conn
+	c.mu.Unlock()
+	err := conn.Write(ctx, msg)
+	if err != nil && req != nil && req.IsCall() {
+		// The caller learns of the failure from the error, so a restart must not
+		// answer the call too.
+		c.mu.Lock()
+		delete(c.pending, req.ID)
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// Close implements io.Closer, and stops the current command.
+func (c *restartingConn) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	conn := c.conn
+	c.mu.Unlock()
+	return conn.Close()
+}
+
+// restart replaces the command, whose connection failed with cause.
+// If the command cannot be restarted, restart returns an error for Read.
+func (c *restartingConn) restart(ctx context.Context, cause error) error {
+	opts := c.t.opts.Restart
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return cause
+	}
+	old := c.proc
+	c.mu.Unlock()
+	old.Close()
+	if time.Since(old.started) > opts.MaxDelay {
+		c.restarts = 0
+	}
+
+	for {
+		if opts.MaxRestarts >= 0 && c.restarts >= opts.MaxRestarts {
+			return cause
+		}
+		delay := opts.MaxDelay
+		if c.restarts < 32 {
+			delay = min(opts.InitialDelay<<c.restarts, opts.MaxDelay)
+		}
+		c.restarts++
+		if l := c.t.opts.Logger; l != nil {
+			l.Warn("restarting server", "error", cause, "delay", delay)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.done:
+			return cause
+		case <-time.After(delay):
+		}
+
+		p, err := c.t.start(c.t.newCommand())
+		if err != nil {
+			cause = err
+			continue
+		}
+		conn := newIOConn(p)
+		// Hold writeMu until the replay is done, so that no other message
+		// reaches the new command first.
+		c.writeMu.Lock()
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			c.writeMu.Unlock()
+			p.Close()
+			return cause
+		}
+		c.proc, c.conn = p, conn
+		pending := c.pending
+		c.pending = make(map[jsonrpc.ID]bool)
+		state := c.state.clone()
+		c.mu.Unlock()
+
+		// The calls to the old command will never be answered.
+		for id := range pending {
+			c.queue = append(c.queue, &jsonrpc.Response{
+				ID:    id,
+				Error: fmt.Errorf("server restarted after failure: %w", cause),
+			})
+		}
+		err = c.replay(ctx, conn, state)
+		c.writeMu.Unlock()
+		if err != nil {
+			p.Close()
+			cause = err
+			continue
+		}
+		return nil
+	}
+}
+
+// replay sends the requests that establish the session state to a new
+// command. It is called with writeMu held.
+func (c *restartingConn) replay(ctx context.Context, conn *ioConn, state sessionState) error {
+	if state.initialize == nil {
+		// The session was not initialized.
+		return nil
+	}
+	// Wait for the response to initialize before sending anything else.
+	init := *state.initialize
+	init.ID = c.replayID(false)
+	if err := conn.Write(ctx, &init); err != nil {
+		return err
+	}
+	for {
+		msg, err := conn.Read(ctx)
+		if err != nil {
+			return err
+		}
+		if resp, ok := msg.(*jsonrpc.Response); ok && resp.ID == init.ID {
+			if resp.Error != nil {
+				return fmt.Errorf("initializing restarted server: %w", resp.Error)
+			}
+			break
+		}
+		c.queue = append(c.queue, msg)
+	}
+	for _, req := range state.requests() {
+		r := *req
+		if r.IsCall() {
+			r.ID = c.replayID(true)
+		}
+		if err := conn.Write(ctx, &r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replayID returns a new ID for a replayed call. If ignore is true, Read
+// discards the response.
+func (c *restartingConn) replayID(ignore bool) jsonrpc.ID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	// String IDs cannot collide with the integer IDs of the session's calls.
+	id := jsonrpc2.StringID(fmt.Sprintf("replay-%d", c.nextID))
+	if ignore {
+		c.replayIDs[id] = true
+	}
+	return id
+}
+
+// A sessionState records the requests from a client that establish the state
+// of its session, so that they can be sent to a new server.
+type sessionState struct {
+	initialize  *jsonrpc.Request
+	initialized *jsonrpc.Request
+	setLevel    *jsonrpc.Request
+	subscribes  map[string]*jsonrpc.Request // by resource URI
+}
+
+// record updates the state with an outgoing request.
+func (s *sessionState) record(req *jsonrpc.Request) {
+	switch req.Method {
+	case methodInitialize:
+		s.initialize = req
+	case notificationInitialized:
+		s.initialized = req
+	case methodSetLevel:
+		s.setLevel = req
+	case methodSubscribe, methodUnsubscribe:
+		var params struct {
+			URI string `json:"uri"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return
+		}
+		if req.Method == methodUnsubscribe {
+			delete(s.subscribes, params.URI)
+			return
+		}
+		if s.subscribes == nil {
+			s.subscribes = make(map[string]*jsonrpc.Request)
+		}
+		s.subscribes[params.URI] = req
+	}
+}
+
+func (s *sessionState) clone() sessionState {
+	s2 := *s
+	s2.subscribes = maps.Clone(s.subscribes)
+	return s2
+}
+
+// requests returns the requests to send after the initialize request, in
+// order.
+func (s *sessionState) requests() []*jsonrpc.Request {
+	var reqs []*jsonrpc.Request
+	if s.initialized != nil {
+		reqs = append(reqs, s.initialized)
+	}
+	if s.setLevel != nil {
+		reqs = append(reqs, s.setLevel)
+	}
+	for _, uri := range slices.Sorted(maps.Keys(s.subscribes)) {
+		reqs = append(reqs, s.subscribes[uri])
+	}
+	return reqs
+}
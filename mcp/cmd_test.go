@@ -5,12 +5,19 @@
 package mcp_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"log/slog"
+	"maps"
 	"os"
 	"os/exec"
 	"runtime"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -32,8 +39,41 @@ func TestMain(m *testing.M) {
 func runServer() {
 	ctx := context.Background()
 
-	server := mcp.NewServer(testImpl, nil)
+	var (
+		mu         sync.Mutex
+		subscribed = make(map[string]bool)
+	)
+	server := mcp.NewServer(testImpl, &mcp.ServerOptions{
+		SubscribeHandler: func(_ context.Context, params *mcp.SubscribeParams) error {
+			mu.Lock()
+			defer mu.Unlock()
+			subscribed[params.URI] = true
+			return nil
+		},
+		UnsubscribeHandler: func(_ context.Context, params *mcp.UnsubscribeParams) error {
+			mu.Lock()
+			defer mu.Unlock()
+			delete(subscribed, params.URI)
+			return nil
+		},
+	})
 	mcp.AddTool(server, &mcp.Tool{Name: "greet", Description: "say hi"}, SayHi)
+	mcp.AddTool(server, &mcp.Tool{Name: "crash", Description: "exit with an error"},
+		func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[struct{}]) (*mcp.CallToolResultFor[any], error) {
+			fmt.Fprintln(os.Stderr, "boom")
+			os.Exit(1)
+			return nil, nil
+		})
+	mcp.AddTool(server, &mcp.Tool{Name: "state", Description: "log, and report subscriptions"},
+		func(ctx context.Context, ss *mcp.ServerSession, _ *mcp.CallToolParamsFor[struct{}]) (*mcp.CallToolResultFor[any], error) {
+			if err := ss.Log(ctx, &mcp.LoggingMessageParams{Level: "info", Data: "state"}); err != nil {
+				return nil, err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			uris := slices.Sorted(maps.Keys(subscribed))
+			return &mcp.CallToolResultFor[any]{Content: []mcp.Content{&mcp.TextContent{Text: strings.Join(uris, ",")}}}, nil
+		})
 	if err := server.Run(ctx, mcp.NewStdioTransport()); err != nil {
 		log.Fatal(err)
 	}
@@ -150,6 +190,95 @@ func TestCmdTransport(t *testing.T) {
 	}
 }
 
+func TestCmdTransportStderr(t *testing.T) {
+	requireExec(t)
+
+	ctx := context.Background()
+	session, err := mcp.NewClient(testImpl, nil).Connect(ctx, mcp.NewCommandTransport(createServerCommand(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "crash"}); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("crash: got error %v, want one containing the server's stderr", err)
+	}
+	if err := session.Wait(); err == nil || !strings.Contains(err.Error(), "exit status 1") || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Wait: got %v, want exit status and stderr", err)
+	}
+}
+
+func TestCmdTransportRestart(t *testing.T) {
+	requireExec(t)
+
+	ctx := context.Background()
+	var logBuf bytes.Buffer
+	var logMu sync.Mutex
+	logger := slog.New(slog.NewTextHandler(writerFunc(func(p []byte) (int, error) {
+		logMu.Lock()
+		defer logMu.Unlock()
+		return logBuf.Write(p)
+	}), nil))
+	transport := mcp.NewCommandTransportWithOptions(createServerCommand(t), &mcp.CommandTransportOptions{
+		Logger:  logger,
+		Restart: &mcp.CommandRestartOptions{InitialDelay: time.Millisecond},
+	})
+	logs := make(chan any, 10)
+	client := mcp.NewClient(testImpl, &mcp.ClientOptions{
+		LoggingMessageHandler: func(_ context.Context, _ *mcp.ClientSession, params *mcp.LoggingMessageParams) {
+			logs <- params.Data
+		},
+	})
+	session, err := client.Connect(ctx, transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	if err := session.SetLevel(ctx, &mcp.SetLevelParams{Level: "info"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, uri := range []string{"file:///a", "file:///b"} {
+		if err := session.Subscribe(ctx, &mcp.SubscribeParams{URI: uri}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := session.Unsubscribe(ctx, &mcp.UnsubscribeParams{URI: "file:///b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The call in progress when the server crashes fails.
+	if _, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "crash"}); err == nil || !strings.Contains(err.Error(), "restarted") {
+		t.Errorf("crash: got error %v, want one about the restart", err)
+	}
+
+	// The restarted server has the session's log level and subscriptions.
+	res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "state"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := res.Content[0].(*mcp.TextContent).Text, "file:///a"; got != want {
+		t.Errorf("subscriptions: got %q, want %q", got, want)
+	}
+	select {
+	case got := <-logs:
+		if got != "state" {
+			t.Errorf("got log message %v, want %q", got, "state")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("no log message from the restarted server")
+	}
+
+	logMu.Lock()
+	defer logMu.Unlock()
+	for _, want := range []string{"text=boom", "restarting server"} {
+		if !strings.Contains(logBuf.String(), want) {
+			t.Errorf("log does not contain %q:\n%s", want, logBuf.String())
+		}
+	}
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
 func createServerCommand(t *testing.T) *exec.Cmd {
 	t.Helper()
 
@@ -152,6 +152,15 @@ func scanEvents(r io.Reader) iter.Seq2[Event, error] {
 // Such a store is able to bound resource usage for the entire process.
 //
 // All of an EventStore's methods must be safe for use by multiple goroutines.
+//
+// An EventStore may also have a method
+//
+//	StreamClosed(_ context.Context, sessionID string, _ StreamID) error
+//
+// A server calls it to inform the store that the given stream will no longer
+// be replayed, so its data can be discarded. A server closes a stream once the
+// stream's final response has been sent and its retention period has elapsed.
+// Like SessionClosed, it may never be called.
 type EventStore interface {
 	// Append appends data for an outgoing event to given stream, which is part of the
 	// given session.
@@ -170,9 +179,11 @@ type EventStore interface {
 	// additional mechanisms, such as timeouts, to reclaim storage.
 	//
 	SessionClosed(_ context.Context, sessionID string) error
+}
 
-	// There is no StreamClosed method. A server doesn't know when a stream is finished, because
-	// the client can always send a GET with a Last-Event-ID referring to the stream.
+// streamCloser is the optional StreamClosed method of an [EventStore].
+type streamCloser interface {
+	StreamClosed(_ context.Context, sessionID string, _ StreamID) error
 }
 
 // A dataList is a list of []byte.
@@ -332,6 +343,20 @@ func (s *MemoryEventStore) SessionClosed(_ context.Context, sessionID string) er
 	return nil
 }
 
+// StreamClosed informs the store that the given stream will no longer be
+// replayed, and discards its data.
+func (s *MemoryEventStore) StreamClosed(_ context.Context, sessionID string, streamID StreamID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	streamMap := s.store[sessionID]
+	if dl, ok := streamMap[streamID]; ok {
+		s.nBytes -= dl.size
+		delete(streamMap, streamID)
+	}
+	s.validate()
+	return nil
+}
+
 // purge removes data until no more than s.maxBytes bytes are in use.
 // It must be called with s.mu held.
 func (s *MemoryEventStore) purge() {
@@ -139,6 +139,19 @@ func TestMemoryEventStoreState(t *testing.T) {
 			"S2 8 first=0 d4",
 			2,
 		},
+		{
+			"stream close",
+			func(s *MemoryEventStore) {
+				appendEvent(s, "S1", 1, "d1")
+				appendEvent(s, "S1", 2, "d2")
+				appendEvent(s, "S1", 1, "d3")
+				appendEvent(s, "S2", 8, "d4")
+				s.StreamClosed(ctx, "S1", 1)
+				s.StreamClosed(ctx, "S3", 1) // unknown session: no-op
+			},
+			"S1 2 first=0 d2; S2 8 first=0 d4",
+			4,
+		},
 		{
 			"purge",
 			func(s *MemoryEventStore) {
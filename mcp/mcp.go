@@ -32,7 +32,9 @@
 //
 // Some transports may hide more complicated details, such as an
 // [SSEClientTransport], which reads messages via server-sent events on a
-// hanging GET request, and writes them to a POST endpoint. Users of this SDK
+// hanging GET request, and writes them to a POST endpoint. A
+// [WebSocketHandler] and [WebSocketClientTransport] carry sessions over
+// WebSockets, an extension to the transports of the spec. Users of this SDK
 // may define their own custom Transports by implementing the [Transport]
 // interface.
 package mcp
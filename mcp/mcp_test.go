@@ -127,6 +127,15 @@ func TestEndToEnd(t *testing.T) {
 		CreateMessageHandler: func(context.Context, *ClientSession, *CreateMessageParams) (*CreateMessageResult, error) {
 			return &CreateMessageResult{Model: "aModel", Content: &TextContent{}}, nil
 		},
+		ElicitationHandler: func(_ context.Context, _ *ClientSession, params *ElicitParams) (*ElicitResult, error) {
+			switch params.Message {
+			case "decline":
+				return &ElicitResult{Action: "decline"}, nil
+			case "bad content":
+				return &ElicitResult{Action: "accept", Content: map[string]any{"name": 3}}, nil
+			}
+			return &ElicitResult{Action: "accept", Content: map[string]any{"name": "Pat", "age": 42}}, nil
+		},
 		ToolListChangedHandler:     func(context.Context, *ClientSession, *ToolListChangedParams) { notificationChans["tools"] <- 0 },
 		PromptListChangedHandler:   func(context.Context, *ClientSession, *PromptListChangedParams) { notificationChans["prompts"] <- 0 },
 		ResourceListChangedHandler: func(context.Context, *ClientSession, *ResourceListChangedParams) { notificationChans["resources"] <- 0 },
@@ -336,6 +345,34 @@ func TestEndToEnd(t *testing.T) {
 			t.Errorf("got %q, want %q", g, w)
 		}
 	})
+	t.Run("elicitation", func(t *testing.T) {
+		schema := &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"name": {Type: "string", MinLength: jsonschema.Ptr(1)},
+				"age":  {Type: "integer", Minimum: jsonschema.Ptr(0.0)},
+			},
+			Required: []string{"name"},
+		}
+		res, err := ss.Elicit(ctx, &ElicitParams{Message: "who are you?", RequestedSchema: schema})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &ElicitResult{Action: "accept", Content: map[string]any{"name": "Pat", "age": 42.0}}
+		if diff := cmp.Diff(want, res); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+		res, err = ss.Elicit(ctx, &ElicitParams{Message: "decline", RequestedSchema: schema})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g, w := res.Action, "decline"; g != w {
+			t.Errorf("got action %q, want %q", g, w)
+		}
+		if _, err := ss.Elicit(ctx, &ElicitParams{Message: "bad content", RequestedSchema: schema}); err == nil {
+			t.Error("got nil error for content that does not match the schema")
+		}
+	})
 	t.Run("logging", func(t *testing.T) {
 		want := []*LoggingMessageParams{
 			{
@@ -562,6 +599,77 @@ func basicConnection(t *testing.T, config func(*Server)) (*ServerSession, *Clien
 	return ss, cs
 }
 
+func TestElicitUnsupported(t *testing.T) {
+	ss, cs := basicConnection(t, nil)
+	defer cs.Close()
+
+	schema := &jsonschema.Schema{Type: "object"}
+	_, err := ss.Elicit(context.Background(), &ElicitParams{Message: "hi", RequestedSchema: schema})
+	if err == nil || !strings.Contains(err.Error(), "does not support elicitation") {
+		t.Errorf("got %v, want error about unsupported elicitation", err)
+	}
+}
+
+func TestCheckElicitSchema(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		schema  *jsonschema.Schema
+		wantErr string
+	}{
+		{"empty object", &jsonschema.Schema{Type: "object"}, ""},
+		{
+			"primitives",
+			&jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"s": {Type: "string", Format: "email", MaxLength: jsonschema.Ptr(10)},
+					"e": {Type: "string", Enum: []any{"a", "b"}, Extra: map[string]any{"enumNames": []any{"A", "B"}}},
+					"n": {Type: "number", Maximum: jsonschema.Ptr(1.0)},
+					"b": {Type: "boolean", Description: "b"},
+				},
+				Required: []string{"s"},
+			},
+			"",
+		},
+		{"nil", nil, "missing schema"},
+		{"not object", &jsonschema.Schema{Type: "string"}, "want \"object\""},
+		{"extra top-level", &jsonschema.Schema{Type: "object", MinProperties: jsonschema.Ptr(1)}, "top level"},
+		{
+			"nested",
+			&jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{"o": {Type: "object"}}},
+			"not a primitive",
+		},
+		{
+			"bad format",
+			&jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{"s": {Type: "string", Format: "ipv4"}}},
+			"unsupported format",
+		},
+		{
+			"bad keyword",
+			&jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{"n": {Type: "integer", MinLength: jsonschema.Ptr(1)}}},
+			"unsupported keywords",
+		},
+		{
+			"undefined required",
+			&jsonschema.Schema{Type: "object", Required: []string{"x"}},
+			"not defined",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkElicitSchema(tt.schema)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("got %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestServerClosing(t *testing.T) {
 	cc, cs := basicConnection(t, func(s *Server) {
 		AddTool(s, greetTool(), sayHi)
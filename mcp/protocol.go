@@ -281,6 +281,39 @@ func (r *CreateMessageResult) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// A request from the server to elicit additional information from the user via
+// the client.
+type ElicitParams struct {
+	// This property is reserved by the protocol to allow clients and servers to
+	// attach additional metadata to their responses.
+	Meta `json:"_meta,omitempty"`
+	// The message to present to the user.
+	Message string `json:"message"`
+	// A restricted subset of JSON Schema.
+	// Only top-level properties are allowed, without nesting, and each property
+	// must be a primitive: a string, number, integer or boolean, or an enum of
+	// strings.
+	RequestedSchema *jsonschema.Schema `json:"requestedSchema"`
+}
+
+func (x *ElicitParams) GetProgressToken() any  { return getProgressToken(x) }
+func (x *ElicitParams) SetProgressToken(t any) { setProgressToken(x, t) }
+
+// The client's response to an elicitation/create request from the server.
+type ElicitResult struct {
+	// This property is reserved by the protocol to allow clients and servers to
+	// attach additional metadata to their responses.
+	Meta `json:"_meta,omitempty"`
+	// The user action in response to the elicitation.
+	//   - "accept": User submitted the form/confirmed the action
+	//   - "decline": User explicitly declined the action
+	//   - "cancel": User dismissed without making an explicit choice
+	Action string `json:"action"`
+	// The submitted form data, only present when action is "accept".
+	// Contains values matching the requested schema.
+	Content map[string]any `json:"content,omitempty"`
+}
+
 type GetPromptParams struct {
 	// This property is reserved by the protocol to allow clients and servers to
 	// attach additional metadata to their responses.
@@ -910,8 +943,6 @@ type ResourceUpdatedNotificationParams struct {
 
 // TODO(jba): add CompleteRequest and related types.
 
-// TODO(jba): add ElicitRequest and related types.
-
 // An Implementation describes the name and version of an MCP implementation, with an optional
 // title for UI representation.
 type Implementation struct {
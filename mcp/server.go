@@ -10,18 +10,25 @@ import (
 	"encoding/base64"
 	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"iter"
+	"log"
 	"maps"
+	"net"
 	"net/url"
 	"path/filepath"
+	"reflect"
 	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/modelcontextprotocol/go-sdk/auth"
 	"github.com/modelcontextprotocol/go-sdk/internal/jsonrpc2"
 	"github.com/modelcontextprotocol/go-sdk/internal/util"
 	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 )
 
 const DefaultPageSize = 1000
@@ -29,7 +36,8 @@ const DefaultPageSize = 1000
 // A Server is an instance of an MCP server.
 //
 // Servers expose server-side MCP features, which can serve one or more MCP
-// sessions by using [Server.Run].
+// sessions by using [Server.Run], or [Server.Serve] for sessions over a
+// network listener.
 type Server struct {
 	// fixed at creation
 	impl *Implementation
@@ -78,6 +86,23 @@ type ServerOptions struct {
 	// If true, advertises the tools capability during initialization,
 	// even if no tools have been registered.
 	HasTools bool
+	// If non-nil, called when [Server.AddTool] or [AddTool] replaces a tool
+	// with one whose schemas are incompatible with those of the old tool, as
+	// reported by [jsonschema.Compare]. The changes are those that may cause
+	// the input schema to reject arguments that it used to accept, or the
+	// output schema to accept results that it used to reject. Their locations
+	// begin with "/inputSchema" or "/outputSchema".
+	// The function can log the changes and return nil to allow the replacement,
+	// or return an error to reject it. In the latter case the old tool is
+	// kept, and AddTool panics with the error.
+	IncompatibleToolHandler func(old, new *Tool, changes []*jsonschema.Change) error
+	// If positive, the maximum number of sessions that [Server.Serve] serves
+	// at once. While that many sessions are active, new connections are not
+	// accepted.
+	MaxConnections int
+	// If positive, [Server.Serve] returns after it has had no sessions for
+	// this long.
+	IdleTimeout time.Duration
 }
 
 // NewServer creates a new MCP server. The resulting server has no features:
@@ -175,6 +200,18 @@ func addToolErr[In, Out any](s *Server, t *Tool, h ToolHandlerFor[In, Out]) (err
 	if err != nil {
 		return err
 	}
+	if s.opts.IncompatibleToolHandler != nil {
+		s.mu.Lock()
+		old, ok := s.tools.get(t.Name)
+		s.mu.Unlock()
+		if ok {
+			if changes := incompatibleToolChanges(old.tool, t); len(changes) > 0 {
+				if err := s.opts.IncompatibleToolHandler(old.tool, t, changes); err != nil {
+					return err
+				}
+			}
+		}
+	}
 	// Assume there was a change, since add replaces existing tools.
 	// (It's possible a tool was replaced with an identical one, but not worth checking.)
 	// TODO: Batch these changes by size and time? The typescript SDK doesn't.
@@ -184,6 +221,25 @@ func addToolErr[In, Out any](s *Server, t *Tool, h ToolHandlerFor[In, Out]) (err
 	return nil
 }
 
+// incompatibleToolChanges returns the changes from old to new that may break
+// clients of the tool: changes to the input schema that break backward
+// compatibility, and changes to the output schema that break forward
+// compatibility.
+func incompatibleToolChanges(old, new *Tool) []*jsonschema.Change {
+	var changes []*jsonschema.Change
+	add := func(prefix string, old, new *jsonschema.Schema, breaks jsonschema.Direction) {
+		for _, c := range jsonschema.Compare(old, new) {
+			if c.Breaks&breaks != 0 {
+				c.Location = prefix + c.Location
+				changes = append(changes, c)
+			}
+		}
+	}
+	add("/inputSchema", old.InputSchema, new.InputSchema, jsonschema.Backward)
+	add("/outputSchema", old.OutputSchema, new.OutputSchema, jsonschema.Forward)
+	return changes
+}
+
 // RemoveTools removes the tools with the given names.
 // It is not an error to remove a nonexistent tool.
 func (s *Server) RemoveTools(names ...string) {
@@ -472,13 +528,12 @@ func (s *Server) subscribe(ctx context.Context, ss *ServerSession, params *Subsc
 		return nil, err
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if s.resourceSubscriptions[params.URI] == nil {
-		s.resourceSubscriptions[params.URI] = make(map[*ServerSession]bool)
+	was := s.setSubscribed(ss, params.URI, true)
+	if err := ss.stateChanged(ctx); err != nil {
+		// Keep the subscriptions in memory consistent with the saved state.
+		s.setSubscribed(ss, params.URI, was)
+		return nil, err
 	}
-	s.resourceSubscriptions[params.URI][ss] = true
-
 	return &emptyResult{}, nil
 }
 
@@ -491,16 +546,33 @@ func (s *Server) unsubscribe(ctx context.Context, ss *ServerSession, params *Uns
 		return nil, err
 	}
 
+	was := s.setSubscribed(ss, params.URI, false)
+	if err := ss.stateChanged(ctx); err != nil {
+		// Keep the subscriptions in memory consistent with the saved state.
+		s.setSubscribed(ss, params.URI, was)
+		return nil, err
+	}
+	return &emptyResult{}, nil
+}
+
+// setSubscribed subscribes ss to the resource with the given URI, or
+// unsubscribes it, and reports whether it was subscribed before.
+func (s *Server) setSubscribed(ss *ServerSession, uri string, subscribed bool) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if subscribedSessions, ok := s.resourceSubscriptions[params.URI]; ok {
+	was := s.resourceSubscriptions[uri][ss]
+	if subscribed {
+		if s.resourceSubscriptions[uri] == nil {
+			s.resourceSubscriptions[uri] = make(map[*ServerSession]bool)
+		}
+		s.resourceSubscriptions[uri][ss] = true
+	} else if subscribedSessions, ok := s.resourceSubscriptions[uri]; ok {
 		delete(subscribedSessions, ss)
 		if len(subscribedSessions) == 0 {
-			delete(s.resourceSubscriptions, params.URI)
+			delete(s.resourceSubscriptions, uri)
 		}
 	}
-
-	return &emptyResult{}, nil
+	return was
 }
 
 // Run runs the server over the given transport, which must be persistent.
@@ -532,6 +604,117 @@ func (s *Server) Run(ctx context.Context, t Transport) error {
 	}
 }
 
+// Serve accepts connections on the listener and serves a session over each
+// of them, exchanging newline-delimited JSON messages. Use it to serve many
+// clients at once over TCP or Unix domain sockets. Clients can connect with a
+// [DialTransport].
+//
+// The number of concurrent sessions is limited by [ServerOptions.MaxConnections].
+//
+// Serve blocks until the context is cancelled, the server is idle for
+// [ServerOptions.IdleTimeout], or the listener is closed. Other errors from
+// accepting a connection, such as running out of file descriptors, are retried
+// after a delay, as [net/http.Server.Serve] does. Serve then closes the
+// listener and closes all of its sessions, each of which waits for its
+// in-flight requests to complete, and returns when they are closed.
+// If the context was cancelled, Serve returns the context's error; if the
+// server was idle, it returns nil; otherwise it returns the error from Accept.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	listener := jsonrpc2.NewNetListener(l)
+	if s.opts.IdleTimeout > 0 {
+		listener = jsonrpc2.NewIdleListener(s.opts.IdleTimeout, listener)
+	}
+	// Unblock Accept when the context is cancelled.
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
+
+	var slots chan struct{} // one per active session, if limited
+	if s.opts.MaxConnections > 0 {
+		slots = make(chan struct{}, s.opts.MaxConnections)
+	}
+	var (
+		mu        sync.Mutex
+		sessions  = make(map[*ServerSession]bool)
+		wg        sync.WaitGroup
+		err       error
+		tempDelay time.Duration // how long to sleep on accept failure
+	)
+	for {
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			break
+		}
+		rwc, aerr := listener.Accept(ctx)
+		if aerr != nil {
+			switch {
+			case ctx.Err() != nil:
+				err = ctx.Err()
+			case errors.Is(aerr, jsonrpc2.ErrIdleTimeout):
+			case errors.Is(aerr, net.ErrClosed):
+				err = aerr
+			default:
+				if slots != nil {
+					<-slots
+				}
+				if tempDelay == 0 {
+					tempDelay = 5 * time.Millisecond
+				} else {
+					tempDelay = min(2*tempDelay, time.Second)
+				}
+				log.Printf("mcp: accept error: %v; retrying in %v", aerr, tempDelay)
+				timer := time.NewTimer(tempDelay)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+				}
+				continue
+			}
+			break
+		}
+		tempDelay = 0
+		ss, cerr := s.Connect(ctx, &ioTransport{rwc})
+		if cerr != nil {
+			// Connecting an ioTransport does not fail, but be careful anyway.
+			rwc.Close()
+			if slots != nil {
+				<-slots
+			}
+			continue
+		}
+		mu.Lock()
+		sessions[ss] = true
+		mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ss.Wait()
+			mu.Lock()
+			delete(sessions, ss)
+			mu.Unlock()
+			if slots != nil {
+				<-slots
+			}
+		}()
+	}
+	listener.Close()
+
+	mu.Lock()
+	active := slices.Collect(maps.Keys(sessions))
+	mu.Unlock()
+	for _, ss := range active {
+		go ss.Close()
+	}
+	wg.Wait()
+	return err
+}
+
 // bind implements the binder[*ServerSession] interface, so that Servers can
 // be connected using [connect].
 func (s *Server) bind(conn *jsonrpc2.Connection) *ServerSession {
@@ -604,9 +787,21 @@ type ServerSession struct {
 	initializeParams *InitializeParams
 	initialized      bool
 	keepaliveCancel  context.CancelFunc
+	// stateless reports whether the session serves a single stateless request,
+	// and so cannot make requests of the client.
+	stateless bool
+	// protocolVersion is the protocol version negotiated during initialization.
+	protocolVersion string
+	// If non-nil, saveState is called with the session's state whenever it
+	// changes. See [SessionStore].
+	saveState func(context.Context, *SessionState) error
 }
 
 func (ss *ServerSession) setConn(c Connection) {
+	// Guard with mu, because with some transports, such as SSE, incoming
+	// messages can be handled before setConn is called.
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
 	ss.mcpConn = c
 }
 
@@ -633,6 +828,117 @@ func (ss *ServerSession) CreateMessage(ctx context.Context, params *CreateMessag
 	return handleSend[*CreateMessageResult](ctx, ss, methodCreateMessage, orZero[Params](params))
 }
 
+// Elicit sends an elicitation request to the client, asking for additional
+// information from the user.
+//
+// Elicit returns an error without contacting the client if the client did not
+// advertise the elicitation capability, or if params.RequestedSchema is not of
+// the restricted form allowed by the spec.
+// If the user accepted the request, the returned content is validated against
+// the requested schema.
+func (ss *ServerSession) Elicit(ctx context.Context, params *ElicitParams) (*ElicitResult, error) {
+	ss.mu.Lock()
+	initParams := ss.initializeParams
+	ss.mu.Unlock()
+	if initParams == nil || initParams.Capabilities == nil || initParams.Capabilities.Elicitation == nil {
+		return nil, errors.New("client does not support elicitation")
+	}
+	if params == nil {
+		return nil, errors.New("elicit: missing params")
+	}
+	if err := checkElicitSchema(params.RequestedSchema); err != nil {
+		return nil, fmt.Errorf("elicit: invalid requested schema: %w", err)
+	}
+	resolved, err := params.RequestedSchema.Resolve(nil)
+	if err != nil {
+		return nil, fmt.Errorf("elicit: resolving requested schema: %w", err)
+	}
+	res, err := handleSend[*ElicitResult](ctx, ss, methodElicit, params)
+	if err != nil {
+		return nil, err
+	}
+	switch res.Action {
+	case "accept":
+		content := res.Content
+		if content == nil {
+			content = map[string]any{}
+		}
+		if err := resolved.Validate(content); err != nil {
+			return nil, fmt.Errorf("elicit: content does not match requested schema: %w", err)
+		}
+	case "decline", "cancel":
+	default:
+		return nil, fmt.Errorf("elicit: invalid action %q", res.Action)
+	}
+	return res, nil
+}
+
+// elicitStringFormats are the values of the "format" keyword allowed for string
+// properties of an elicitation schema.
+var elicitStringFormats = map[string]bool{"email": true, "uri": true, "date": true, "date-time": true}
+
+// checkElicitSchema reports whether s is of the restricted form allowed for
+// the requestedSchema of an elicitation request: an object whose properties are
+// all primitives.
+func checkElicitSchema(s *jsonschema.Schema) error {
+	if s == nil {
+		return errors.New("missing schema")
+	}
+	if s.Type != "object" {
+		return fmt.Errorf(`type is %q, want "object"`, s.Type)
+	}
+	top := *s
+	top.Type, top.Properties, top.Required = "", nil, nil
+	if !reflect.ValueOf(top).IsZero() {
+		return errors.New("only type, properties and required are allowed at top level")
+	}
+	for name, ps := range s.Properties {
+		if err := checkElicitProperty(ps); err != nil {
+			return fmt.Errorf("property %q: %w", name, err)
+		}
+	}
+	for _, r := range s.Required {
+		if _, ok := s.Properties[r]; !ok {
+			return fmt.Errorf("required property %q is not defined", r)
+		}
+	}
+	return nil
+}
+
+// checkElicitProperty checks that s is a primitive schema definition.
+func checkElicitProperty(s *jsonschema.Schema) error {
+	if s == nil {
+		return errors.New("missing schema")
+	}
+	// Clear every keyword we allow; anything left over is an error.
+	c := *s
+	c.Type, c.Title, c.Description, c.Default = "", "", "", nil
+	switch s.Type {
+	case "string":
+		if s.Format != "" && !elicitStringFormats[s.Format] {
+			return fmt.Errorf("unsupported format %q", s.Format)
+		}
+		for _, e := range s.Enum {
+			if _, ok := e.(string); !ok {
+				return fmt.Errorf("enum value %v is not a string", e)
+			}
+		}
+		c.MinLength, c.MaxLength, c.Format, c.Enum = nil, nil, "", nil
+		if _, ok := c.Extra["enumNames"]; ok && len(c.Extra) == 1 {
+			c.Extra = nil
+		}
+	case "number", "integer":
+		c.Minimum, c.Maximum = nil, nil
+	case "boolean":
+	default:
+		return fmt.Errorf("type %q is not a primitive type", s.Type)
+	}
+	if !reflect.ValueOf(c).IsZero() {
+		return fmt.Errorf("unsupported keywords for type %q", s.Type)
+	}
+	return nil
+}
+
 // Log sends a log message to the client.
 // The message is not sent if the client has not called SetLevel, or if its level
 // is below that of the last SetLevel.
@@ -712,8 +1018,22 @@ func (ss *ServerSession) receivingMethodInfos() map[string]methodInfo { return s
 
 func (ss *ServerSession) sendingMethodHandler() methodHandler {
 	ss.server.mu.Lock()
-	defer ss.server.mu.Unlock()
-	return ss.server.sendingMethodHandler_
+	mh := ss.server.sendingMethodHandler_
+	ss.server.mu.Unlock()
+	ss.mu.Lock()
+	stateless := ss.stateless
+	ss.mu.Unlock()
+	if !stateless {
+		return mh
+	}
+	// A stateless session has no way to receive a response from the client,
+	// so reject requests before they reach the wire.
+	return MethodHandler[*ServerSession](func(ctx context.Context, ss *ServerSession, method string, params Params) (Result, error) {
+		if !strings.HasPrefix(method, "notifications/") {
+			return nil, fmt.Errorf("cannot send %q: stateless sessions do not support server-to-client requests", method)
+		}
+		return mh(ctx, ss, method, params)
+	})
 }
 
 func (ss *ServerSession) receivingMethodHandler() methodHandler {
@@ -729,6 +1049,7 @@ func (ss *ServerSession) getConn() *jsonrpc2.Connection { return ss.conn }
 func (ss *ServerSession) handle(ctx context.Context, req *jsonrpc.Request) (any, error) {
 	ss.mu.Lock()
 	initialized := ss.initialized
+	mcpConn := ss.mcpConn
 	ss.mu.Unlock()
 	// From the spec:
 	// "The client SHOULD NOT send requests other than pings before the server
@@ -744,6 +1065,12 @@ func (ss *ServerSession) handle(ctx context.Context, req *jsonrpc.Request) (any,
 	// server->client calls and notifications to the incoming request from which
 	// they originated. See [idContextKey] for details.
 	ctx = context.WithValue(ctx, idContextKey{}, req.ID)
+	// Make the caller's identity available to handlers.
+	if c, ok := mcpConn.(tokenInfoConn); ok && req.ID.IsValid() {
+		if info := c.tokenInfo(req.ID); info != nil {
+			ctx = auth.ContextWithTokenInfo(ctx, info)
+		}
+	}
 	return handleReceive(ctx, ss, req)
 }
 
@@ -751,9 +1078,20 @@ func (ss *ServerSession) initialize(ctx context.Context, params *InitializeParam
 	if params == nil {
 		return nil, fmt.Errorf("%w: \"params\" must be be provided", jsonrpc2.ErrInvalidParams)
 	}
+	// If we support the client's version, reply with it. Otherwise, reply with our
+	// latest version.
+	version := params.ProtocolVersion
+	if !slices.Contains(supportedProtocolVersions, params.ProtocolVersion) {
+		version = latestProtocolVersion
+	}
+
 	ss.mu.Lock()
 	ss.initializeParams = params
+	ss.protocolVersion = version
 	ss.mu.Unlock()
+	if err := ss.stateChanged(ctx); err != nil {
+		return nil, err
+	}
 
 	// Mark the connection as initialized when this method exits.
 	// TODO: Technically, the server should not be considered initialized until it has
@@ -766,13 +1104,6 @@ func (ss *ServerSession) initialize(ctx context.Context, params *InitializeParam
 		ss.mu.Unlock()
 	}()
 
-	// If we support the client's version, reply with it. Otherwise, reply with our
-	// latest version.
-	version := params.ProtocolVersion
-	if !slices.Contains(supportedProtocolVersions, params.ProtocolVersion) {
-		version = latestProtocolVersion
-	}
-
 	return &InitializeResult{
 		// TODO(rfindley): alter behavior when falling back to an older version:
 		// reject unsupported features.
@@ -783,17 +1114,100 @@ func (ss *ServerSession) initialize(ctx context.Context, params *InitializeParam
 	}, nil
 }
 
+// initializeStateless marks the session as initialized without an initialize
+// handshake, for transports that serve each request with a new session.
+// The session cannot make requests of the client.
+func (ss *ServerSession) initializeStateless(version string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.initializeParams = &InitializeParams{
+		ProtocolVersion: version,
+		Capabilities:    &ClientCapabilities{},
+	}
+	ss.protocolVersion = version
+	ss.initialized = true
+	ss.stateless = true
+}
+
 func (ss *ServerSession) ping(context.Context, *PingParams) (*emptyResult, error) {
 	return &emptyResult{}, nil
 }
 
-func (ss *ServerSession) setLevel(_ context.Context, params *SetLevelParams) (*emptyResult, error) {
+func (ss *ServerSession) setLevel(ctx context.Context, params *SetLevelParams) (*emptyResult, error) {
 	ss.mu.Lock()
-	defer ss.mu.Unlock()
+	old := ss.logLevel
 	ss.logLevel = params.Level
+	ss.mu.Unlock()
+	if err := ss.stateChanged(ctx); err != nil {
+		ss.mu.Lock()
+		ss.logLevel = old
+		ss.mu.Unlock()
+		return nil, err
+	}
 	return &emptyResult{}, nil
 }
 
+// state returns a snapshot of the session's persistent state.
+func (ss *ServerSession) state() *SessionState {
+	ss.mu.Lock()
+	st := &SessionState{
+		InitializeParams: ss.initializeParams,
+		ProtocolVersion:  ss.protocolVersion,
+		LogLevel:         ss.logLevel,
+	}
+	ss.mu.Unlock()
+
+	s := ss.server
+	s.mu.Lock()
+	for uri, sessions := range s.resourceSubscriptions {
+		if sessions[ss] {
+			st.Subscriptions = append(st.Subscriptions, uri)
+		}
+	}
+	s.mu.Unlock()
+	slices.Sort(st.Subscriptions)
+	return st
+}
+
+// stateChanged saves the session's state, if the session is persistent.
+func (ss *ServerSession) stateChanged(ctx context.Context) error {
+	ss.mu.Lock()
+	save := ss.saveState
+	ss.mu.Unlock()
+	if save == nil {
+		return nil
+	}
+	if err := save(ctx, ss.state()); err != nil {
+		return fmt.Errorf("saving session state: %w", err)
+	}
+	return nil
+}
+
+// restoreState restores a session from state saved by another ServerSession,
+// perhaps in another process. The session is considered initialized.
+//
+// Resource subscriptions are restored without calling
+// [ServerOptions.SubscribeHandler], since it was called when the client
+// originally subscribed.
+func (ss *ServerSession) restoreState(st *SessionState) {
+	ss.mu.Lock()
+	ss.initializeParams = st.InitializeParams
+	ss.protocolVersion = st.ProtocolVersion
+	ss.logLevel = st.LogLevel
+	ss.initialized = true
+	ss.mu.Unlock()
+
+	s := ss.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, uri := range st.Subscriptions {
+		if s.resourceSubscriptions[uri] == nil {
+			s.resourceSubscriptions[uri] = make(map[*ServerSession]bool)
+		}
+		s.resourceSubscriptions[uri][ss] = true
+	}
+}
+
 // Close performs a graceful shutdown of the connection, preventing new
 // requests from being handled, and waiting for ongoing requests to return.
 // Close then terminates the connection.
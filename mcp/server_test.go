@@ -6,9 +6,14 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net"
+	"path/filepath"
 	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/modelcontextprotocol/go-sdk/jsonschema"
@@ -362,3 +367,186 @@ func TestServerCapabilities(t *testing.T) {
 		})
 	}
 }
+
+func TestIncompatibleToolHandler(t *testing.T) {
+	var gotChanges []*jsonschema.Change
+	errIncompatible := errors.New("incompatible")
+	server := NewServer(testImpl, &ServerOptions{
+		IncompatibleToolHandler: func(old, new *Tool, changes []*jsonschema.Change) error {
+			gotChanges = changes
+			if new.Description == "reject" {
+				return errIncompatible
+			}
+			return nil
+		},
+	})
+	handler := func(context.Context, *ServerSession, *CallToolParamsFor[map[string]any]) (*CallToolResult, error) {
+		return &CallToolResult{}, nil
+	}
+	input := func(required ...string) *jsonschema.Schema {
+		return &jsonschema.Schema{
+			Type:       "object",
+			Properties: map[string]*jsonschema.Schema{"x": {Type: "string"}, "y": {Type: "string"}},
+			Required:   required,
+		}
+	}
+	AddTool(server, &Tool{Name: "t", InputSchema: input("x")}, handler)
+	if gotChanges != nil {
+		t.Fatalf("handler called for a new tool with %v", gotChanges)
+	}
+
+	// A compatible replacement does not call the handler.
+	AddTool(server, &Tool{Name: "t", InputSchema: input()}, handler)
+	if gotChanges != nil {
+		t.Fatalf("handler called for a compatible tool with %v", gotChanges)
+	}
+
+	// An incompatible replacement calls the handler, which allows it.
+	AddTool(server, &Tool{Name: "t", InputSchema: input("y")}, handler)
+	want := []*jsonschema.Change{{Location: "/inputSchema/required", Breaks: jsonschema.Backward, Message: `property "y" is now required`}}
+	if diff := cmp.Diff(want, gotChanges); diff != "" {
+		t.Errorf("changes mismatch (-want +got):\n%s", diff)
+	}
+
+	// The handler rejects the replacement.
+	func() {
+		defer func() {
+			if r := recover(); r == nil || !errors.Is(r.(error), errIncompatible) {
+				t.Errorf("AddTool: got panic %v, want %v", r, errIncompatible)
+			}
+		}()
+		AddTool(server, &Tool{Name: "t", Description: "reject", InputSchema: input("x", "y")}, handler)
+	}()
+	if st, _ := server.tools.get("t"); st.tool.Description == "reject" {
+		t.Error("rejected tool was added")
+	}
+}
+
+func TestServe(t *testing.T) {
+	serve := func(t *testing.T, ctx context.Context, opts *ServerOptions) (addr net.Addr, errc <-chan error) {
+		t.Helper()
+		l, err := net.Listen("unix", filepath.Join(t.TempDir(), "mcp.sock"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		server := NewServer(testImpl, opts)
+		AddTool(server, &Tool{Name: "greet"}, sayHi)
+		c := make(chan error, 1)
+		go func() { c <- server.Serve(ctx, l) }()
+		return l.Addr(), c
+	}
+	connect := func(t *testing.T, ctx context.Context, addr net.Addr) *ClientSession {
+		t.Helper()
+		cs, err := NewClient(testImpl, nil).Connect(ctx, NewDialTransport(addr.Network(), addr.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cs
+	}
+
+	t.Run("sessions", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		addr, errc := serve(t, ctx, nil)
+		var sessions []*ClientSession
+		for i := range 3 {
+			cs := connect(t, ctx, addr)
+			sessions = append(sessions, cs)
+			if _, err := cs.CallTool(ctx, &CallToolParams{Name: "greet", Arguments: map[string]any{"Name": "user"}}); err != nil {
+				t.Fatalf("session %d: %v", i, err)
+			}
+		}
+		// Cancelling the context closes the sessions.
+		cancel()
+		if err := <-errc; !errors.Is(err, context.Canceled) {
+			t.Errorf("Serve returned %v, want context.Canceled", err)
+		}
+		for _, cs := range sessions {
+			cs.Wait()
+		}
+	})
+
+	t.Run("max connections", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		addr, _ := serve(t, ctx, &ServerOptions{MaxConnections: 1})
+		cs1 := connect(t, ctx, addr)
+		connected := make(chan error)
+		var cs2 *ClientSession
+		go func() {
+			var err error
+			cs2, err = NewClient(testImpl, nil).Connect(ctx, NewDialTransport(addr.Network(), addr.String()))
+			connected <- err
+		}()
+		select {
+		case <-connected:
+			t.Fatal("second session connected while the first was active")
+		case <-time.After(50 * time.Millisecond):
+		}
+		cs1.Close()
+		if err := <-connected; err != nil {
+			t.Fatal(err)
+		}
+		if err := cs2.Ping(ctx, nil); err != nil {
+			t.Fatal(err)
+		}
+		cs2.Close()
+	})
+
+	t.Run("accept errors", func(t *testing.T) {
+		ctx := context.Background()
+		l, err := net.Listen("unix", filepath.Join(t.TempDir(), "mcp.sock"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fl := &flakyListener{Listener: l, failures: 3}
+		errc := make(chan error, 1)
+		go func() { errc <- NewServer(testImpl, nil).Serve(ctx, fl) }()
+		// Serve keeps accepting after Accept fails.
+		cs := connect(t, ctx, l.Addr())
+		if err := cs.Ping(ctx, nil); err != nil {
+			t.Fatal(err)
+		}
+		cs.Close()
+		// Closing the listener stops it.
+		l.Close()
+		if err := <-errc; !errors.Is(err, net.ErrClosed) {
+			t.Errorf("Serve returned %v, want net.ErrClosed", err)
+		}
+	})
+
+	t.Run("idle", func(t *testing.T) {
+		ctx := context.Background()
+		addr, errc := serve(t, ctx, &ServerOptions{IdleTimeout: 20 * time.Millisecond})
+		cs := connect(t, ctx, addr)
+		time.Sleep(50 * time.Millisecond)
+		select {
+		case err := <-errc:
+			t.Fatalf("Serve returned %v with an active session", err)
+		default:
+		}
+		cs.Close()
+		if err := <-errc; err != nil {
+			t.Errorf("Serve returned %v, want nil", err)
+		}
+	})
+}
+
+// A flakyListener fails its first Accept calls.
+type flakyListener struct {
+	net.Listener
+	mu       sync.Mutex
+	failures int
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	fail := l.failures > 0
+	if fail {
+		l.failures--
+	}
+	l.mu.Unlock()
+	if fail {
+		return nil, errors.New("too many open files")
+	}
+	return l.Listener.Accept()
+}
@@ -0,0 +1,206 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// This file is for persisting server sessions.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// SessionState is the state of a [ServerSession] that persists across
+// requests. It is saved to a [SessionStore] so that a session can be resumed
+// by a different process.
+type SessionState struct {
+	// InitializeParams are the parameters of the client's initialize request,
+	// including its capabilities.
+	InitializeParams *InitializeParams `json:"initializeParams,omitempty"`
+	// ProtocolVersion is the protocol version negotiated during initialization.
+	ProtocolVersion string `json:"protocolVersion,omitempty"`
+	// LogLevel is the log level most recently set by the client.
+	LogLevel LoggingLevel `json:"logLevel,omitempty"`
+	// Subscriptions are the URIs of the resources the client has subscribed to.
+	Subscriptions []string `json:"subscriptions,omitempty"`
+	// Incarnation counts the times the session has been restored from a
+	// [SessionStore]. A [StreamableHTTPHandler] increments and saves it before
+	// serving a restored session, and numbers the streams of each incarnation
+	// in a separate range, so that they cannot collide in an [EventStore] with
+	// the streams of earlier incarnations. Only processes that restore the
+	// same session at the same moment can get the same incarnation.
+	Incarnation int64 `json:"incarnation,omitempty"`
+	// Subject is the subject of the bearer token that created the session, if
+	// any. A [StreamableHTTPHandler] serves requests for the session only if
+	// their tokens have the same subject.
+	Subject string `json:"subject,omitempty"`
+}
+
+// A SessionStore saves the state of server sessions, so that they can be
+// resumed by another process, or after a restart.
+// A [StreamableHTTPHandler] consults its SessionStore when it receives a
+// request for a session it does not know about.
+//
+// Like an [EventStore], a single SessionStore suffices for all servers,
+// since session IDs are globally unique.
+//
+// All of a SessionStore's methods must be safe for use by multiple goroutines.
+type SessionStore interface {
+	// Load returns the state of the session with the given ID.
+	// If there is no such session, Load returns an error wrapping [ErrSessionNotFound].
+	Load(_ context.Context, sessionID string) (*SessionState, error)
+
+	// Store saves the state of the given session, creating or replacing it.
+	Store(_ context.Context, sessionID string, _ *SessionState) error
+
+	// Delete removes the session with the given ID.
+	// It is not an error to delete a nonexistent session.
+	Delete(_ context.Context, sessionID string) error
+}
+
+// ErrSessionNotFound is the error that [SessionStore.Load] should return if
+// there is no session with the given ID.
+var ErrSessionNotFound = errors.New("session not found")
+
+// A MemorySessionStore is a [SessionStore] backed by memory.
+// Its sessions do not survive the process.
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string][]byte // session ID -> JSON-encoded SessionState
+}
+
+// NewMemorySessionStore creates a [MemorySessionStore].
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: map[string][]byte{}}
+}
+
+// Load implements [SessionStore.Load].
+func (s *MemorySessionStore) Load(_ context.Context, sessionID string) (*SessionState, error) {
+	s.mu.Lock()
+	data, ok := s.sessions[sessionID]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("MemorySessionStore.Load: %q: %w", sessionID, ErrSessionNotFound)
+	}
+	// Decode a fresh copy, so callers cannot modify the stored state.
+	var state SessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Store implements [SessionStore.Store].
+func (s *MemorySessionStore) Store(_ context.Context, sessionID string, state *SessionState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[sessionID] = data
+	return nil
+}
+
+// Delete implements [SessionStore.Delete].
+func (s *MemorySessionStore) Delete(_ context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionID)
+	return nil
+}
+
+// A FileSessionStore is a [SessionStore] that saves each session as a JSON
+// file in a directory.
+// Processes that share the directory share sessions.
+type FileSessionStore struct {
+	dir string
+}
+
+// NewFileSessionStore returns a [FileSessionStore] that keeps its files in dir,
+// creating the directory if necessary.
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileSessionStore{dir: dir}, nil
+}
+
+// filename returns the name of the file for the given session.
+func (s *FileSessionStore) filename(sessionID string) (string, error) {
+	// Session IDs come from the client, so guard against path traversal.
+	if sessionID == "" || !filepath.IsLocal(sessionID) || strings.ContainsAny(sessionID, `/\`) {
+		return "", fmt.Errorf("invalid session ID %q", sessionID)
+	}
+	return filepath.Join(s.dir, sessionID+".json"), nil
+}
+
+// Load implements [SessionStore.Load].
+func (s *FileSessionStore) Load(_ context.Context, sessionID string) (*SessionState, error) {
+	name, err := s.filename(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("FileSessionStore.Load: %w: %w", err, ErrSessionNotFound)
+	}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("FileSessionStore.Load: %q: %w", sessionID, ErrSessionNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state SessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("FileSessionStore.Load: %s: %w", name, err)
+	}
+	return &state, nil
+}
+
+// Store implements [SessionStore.Store].
+func (s *FileSessionStore) Store(_ context.Context, sessionID string, state *SessionState) error {
+	name, err := s.filename(sessionID)
+	if err != nil {
+		return fmt.Errorf("FileSessionStore.Store: %w", err)
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file and rename it, so that readers never see a
+	// partially written file.
+	f, err := os.CreateTemp(s.dir, sessionID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("FileSessionStore.Store: %w", err)
+	}
+	return nil
+}
+
+// Delete implements [SessionStore.Delete].
+func (s *FileSessionStore) Delete(_ context.Context, sessionID string) error {
+	name, err := s.filename(sessionID)
+	if err != nil {
+		return fmt.Errorf("FileSessionStore.Delete: %w", err)
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
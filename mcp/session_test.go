@@ -0,0 +1,86 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSessionStores(t *testing.T) {
+	ctx := context.Background()
+	fileStore, err := NewFileSessionStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name  string
+		store SessionStore
+	}{
+		{"memory", NewMemorySessionStore()},
+		{"file", fileStore},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			store := test.store
+			if _, err := store.Load(ctx, "S1"); !errors.Is(err, ErrSessionNotFound) {
+				t.Fatalf("Load before Store: got %v, want ErrSessionNotFound", err)
+			}
+			want := &SessionState{
+				InitializeParams: &InitializeParams{
+					ProtocolVersion: latestProtocolVersion,
+					ClientInfo:      testImpl,
+					Capabilities:    &ClientCapabilities{Sampling: &SamplingCapabilities{}},
+				},
+				ProtocolVersion: latestProtocolVersion,
+				LogLevel:        "debug",
+				Subscriptions:   []string{"file:///a", "file:///b"},
+			}
+			if err := store.Store(ctx, "S1", want); err != nil {
+				t.Fatal(err)
+			}
+			got, err := store.Load(ctx, "S1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Load mismatch (-want +got):\n%s", diff)
+			}
+			// Store replaces.
+			want.LogLevel = "error"
+			if err := store.Store(ctx, "S1", want); err != nil {
+				t.Fatal(err)
+			}
+			got, err = store.Load(ctx, "S1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g, w := got.LogLevel, want.LogLevel; g != w {
+				t.Errorf("after replacement: got log level %q, want %q", g, w)
+			}
+			if err := store.Delete(ctx, "S1"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Load(ctx, "S1"); !errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("Load after Delete: got %v, want ErrSessionNotFound", err)
+			}
+			if err := store.Delete(ctx, "S1"); err != nil {
+				t.Errorf("deleting a nonexistent session: %v", err)
+			}
+		})
+	}
+
+	// Session IDs that aren't simple file names are not found.
+	for _, id := range []string{"../x", "a/b", ""} {
+		if _, err := fileStore.Load(ctx, id); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("Load(%q): got %v, want ErrSessionNotFound", id, err)
+		}
+		if err := fileStore.Store(ctx, id, &SessionState{}); err == nil {
+			t.Errorf("Store(%q) succeeded, want error", id)
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"net/url"
 	"sync"
 
+	"github.com/modelcontextprotocol/go-sdk/auth"
 	"github.com/modelcontextprotocol/go-sdk/internal/jsonrpc2"
 	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
 )
@@ -91,6 +92,15 @@ type SSEServerTransport struct {
 	w      http.ResponseWriter // the hanging response body
 	closed bool                // set when the stream is closed
 	done   chan struct{}       // closed when the connection is closed
+
+	// tokens holds the bearer token information of the POSTs carrying
+	// incoming requests, until the requests are handled or answered.
+	tokens map[jsonrpc.ID]*auth.TokenInfo
+
+	// subject is the subject of the bearer token of the GET that created the
+	// session, if any. POSTs to the session must have tokens for the same
+	// subject.
+	subject string
 }
 
 // NewSSEServerTransport creates a new SSE transport for the given messages
@@ -129,11 +139,21 @@ func (t *SSEServerTransport) ServeHTTP(w http.ResponseWriter, req *http.Request)
 		http.Error(w, "failed to parse body", http.StatusBadRequest)
 		return
 	}
-	if req, ok := msg.(*jsonrpc.Request); ok {
-		if _, err := checkRequest(req, serverMethodInfos); err != nil {
+	if jreq, ok := msg.(*jsonrpc.Request); ok {
+		if _, err := checkRequest(jreq, serverMethodInfos); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		if info := auth.TokenInfoFromContext(req.Context()); info != nil && jreq.ID.IsValid() {
+			t.mu.Lock()
+			if !t.closed {
+				if t.tokens == nil {
+					t.tokens = make(map[jsonrpc.ID]*auth.TokenInfo)
+				}
+				t.tokens[jreq.ID] = info
+			}
+			t.mu.Unlock()
+		}
 	}
 	select {
 	case t.incoming <- msg:
@@ -177,6 +197,11 @@ func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			http.Error(w, "session not found", http.StatusNotFound)
 			return
 		}
+		if session.subject != "" && tokenSubject(req) != session.subject {
+			// Don't let one user's token drive another user's session.
+			http.Error(w, "session belongs to another user", http.StatusForbidden)
+			return
+		}
 
 		session.ServeHTTP(w, req)
 		return
@@ -204,6 +229,7 @@ func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	transport := NewSSEServerTransport(endpoint.RequestURI(), w)
+	transport.subject = tokenSubject(req)
 
 	// The session is terminated when the request exits.
 	h.mu.Lock()
@@ -246,6 +272,15 @@ type sseServerConn struct {
 // TODO(jba): get the session ID. (Not urgent because SSE transports have been removed from the spec.)
 func (s sseServerConn) SessionID() string { return "" }
 
+// tokenInfo implements [tokenInfoConn].
+func (s sseServerConn) tokenInfo(id jsonrpc.ID) *auth.TokenInfo {
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+	info := s.t.tokens[id]
+	delete(s.t.tokens, id)
+	return info
+}
+
 // Read implements jsonrpc2.Reader.
 func (s sseServerConn) Read(ctx context.Context) (jsonrpc.Message, error) {
 	select {
@@ -278,6 +313,10 @@ func (s sseServerConn) Write(ctx context.Context, msg jsonrpc.Message) error {
 	if s.t.closed {
 		return io.EOF
 	}
+	if resp, ok := msg.(*jsonrpc.Response); ok {
+		// The request has been answered, whether or not it was handled.
+		delete(s.t.tokens, resp.ID)
+	}
 
 	_, err = writeEvent(s.t.w, Event{Name: "message", Data: data})
 	return err
@@ -293,6 +332,7 @@ func (s sseServerConn) Close() error {
 	defer s.t.mu.Unlock()
 	if !s.t.closed {
 		s.t.closed = true
+		s.t.tokens = nil
 		close(s.t.done)
 	}
 	return nil
@@ -314,6 +354,10 @@ type SSEClientTransportOptions struct {
 	// HTTPClient is the client to use for making HTTP requests. If nil,
 	// http.DefaultClient is used.
 	HTTPClient *http.Client
+	// OAuth, if non-nil, configures how the transport obtains access tokens
+	// when the server requires authorization. See [auth.NewOAuthTransport].
+	// If OAuth.Resource is empty, the SSE endpoint URL is used.
+	OAuth *auth.OAuthConfig
 }
 
 // NewSSEClientTransport returns a new client transport that connects to the
@@ -344,6 +388,7 @@ func (c *SSEClientTransport) Connect(ctx context.Context) (Connection, error) {
 	if httpClient == nil {
 		httpClient = http.DefaultClient
 	}
+	httpClient = oauthClient(httpClient, c.opts.OAuth, c.sseEndpoint.String())
 	req.Header.Set("Accept", "text/event-stream")
 	resp, err := httpClient.Do(req)
 	if err != nil {
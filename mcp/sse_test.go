@@ -7,14 +7,18 @@ package mcp
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync/atomic"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/internal/oauthtest"
 )
 
 func TestSSEServer(t *testing.T) {
@@ -124,6 +128,80 @@ func TestSSEServer(t *testing.T) {
 	}
 }
 
+func TestSSEBearerToken(t *testing.T) {
+	ctx := context.Background()
+	issuer := oauthtest.NewIssuer("https://auth.example.com")
+	keys, err := auth.ParseKeySet(issuer.JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(testImpl, nil)
+	AddTool(server, &Tool{Name: "whoami"}, func(ctx context.Context, _ *ServerSession, _ *CallToolParamsFor[map[string]any]) (*CallToolResultFor[any], error) {
+		info := auth.TokenInfoFromContext(ctx)
+		if info == nil {
+			return nil, errors.New("no token info")
+		}
+		return &CallToolResultFor[any]{Content: []Content{&TextContent{Text: info.Subject}}}, nil
+	})
+	const resource = "https://example.com/mcp"
+	verifier := auth.NewJWTVerifier(keys, &auth.JWTVerifierOptions{Issuer: issuer.URL, Audience: resource})
+	sseHandler := NewSSEHandler(func(*http.Request) *Server { return server })
+	httpServer := httptest.NewServer(auth.RequireBearerToken(verifier, nil)(sseHandler))
+	defer httpServer.Close()
+
+	token := issuer.Token(map[string]any{"sub": "alice", "aud": resource})
+	httpClient := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+token)
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	cs, err := NewClient(testImpl, nil).Connect(ctx, NewSSEClientTransport(httpServer.URL, &SSEClientTransportOptions{HTTPClient: httpClient}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+	res, err := cs.CallTool(ctx, &CallToolParams{Name: "whoami"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("tool failed: %v", res.Content[0].(*TextContent).Text)
+	}
+	if got := res.Content[0].(*TextContent).Text; got != "alice" {
+		t.Errorf("got subject %q, want %q", got, "alice")
+	}
+
+	sseHandler.mu.Lock()
+	var transport *SSEServerTransport
+	for _, tr := range sseHandler.sessions {
+		transport = tr
+	}
+	sseHandler.mu.Unlock()
+	transport.mu.Lock()
+	if n := len(transport.tokens); n != 0 {
+		t.Errorf("after responses: %d token infos retained, want 0", n)
+	}
+	transport.mu.Unlock()
+
+	// Another user's token can't be used with the session.
+	msgEndpoint := cs.mcpConn.(*sseClientConn).msgEndpoint.String()
+	req, err := http.NewRequest(http.MethodPost, msgEndpoint, strings.NewReader(`{"jsonrpc":"2.0","id":100,"method":"ping"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+issuer.Token(map[string]any{"sub": "mallory", "aud": resource}))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusForbidden; got != want {
+		t.Errorf("other user's token: got status %d, want %d", got, want)
+	}
+}
+
 // roundTripperFunc is a helper to create a custom RoundTripper
 type roundTripperFunc func(*http.Request) (*http.Response, error)
 
@@ -6,19 +6,23 @@ package mcp
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"math"
 	"math/rand/v2"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/modelcontextprotocol/go-sdk/auth"
 	"github.com/modelcontextprotocol/go-sdk/internal/jsonrpc2"
 	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
 )
@@ -34,16 +38,90 @@ const (
 // [MCP spec]: https://modelcontextprotocol.io/2025/03/26/streamable-http-transport.html
 type StreamableHTTPHandler struct {
 	getServer func(*http.Request) *Server
+	opts      StreamableHTTPOptions
 
+	// sessionsMu guards sessions, restoring and the mutable fields of their
+	// values.
 	sessionsMu sync.Mutex
-	sessions   map[string]*StreamableServerTransport // keyed by IDs (from Mcp-Session-Id header)
+	// sessions holds the live sessions in this process. It is a cache of
+	// the sessions in opts.SessionStore.
+	sessions map[string]*handlerSession // keyed by IDs (from Mcp-Session-Id header)
+	// restoring holds a channel for each session being restored from the
+	// session store, which is closed when the restoration completes.
+	restoring map[string]chan struct{}
+	// reserved is the number of sessions being created or restored, which
+	// count against opts.MaxSessions.
+	reserved int
 }
 
-// StreamableHTTPOptions is a placeholder options struct for future
-// configuration of the StreamableHTTP handler.
+// A handlerSession is a session that is live in a [StreamableHTTPHandler].
+type handlerSession struct {
+	transport *StreamableServerTransport
+	session   *ServerSession
+	// subject is the subject of the bearer token that created the session,
+	// if any. Requests for the session must have tokens for the same subject.
+	// See [auth.RequireBearerToken].
+	subject string
+
+	// The following fields are guarded by StreamableHTTPHandler.sessionsMu.
+
+	// activeRequests is the number of HTTP requests being served for the session.
+	activeRequests int
+	// idleTimer expires the session after a period with no active requests.
+	// It is nil if there is no idle timeout.
+	idleTimer *time.Timer
+	// lifetimeTimer expires the session after its maximum lifetime.
+	// It is nil if there is no maximum lifetime.
+	lifetimeTimer *time.Timer
+}
+
+// StreamableHTTPOptions configures the StreamableHTTP handler.
 type StreamableHTTPOptions struct {
+	// Stateless controls whether the handler keeps sessions across requests.
+	//
+	// If true, each POST is served by a new [ServerSession] that is treated as
+	// already initialized and is closed when the request completes, so that any
+	// instance of a replicated server can handle any request.
+	// No Mcp-Session-Id header is issued, GET and DELETE requests are rejected
+	// with 405 Method Not Allowed, and the server cannot make requests of the
+	// client (for example, [ServerSession.CreateMessage] returns an error).
+	// Notifications related to a request, such as progress notifications, are
+	// still delivered in the response to that request.
+	Stateless bool
+
+	// SessionStore saves the state of sessions, so that a session created by
+	// one process can be resumed by another, or by the same process after a
+	// restart. When the handler receives a request for a session it is not
+	// serving, it loads the session from the store.
+	// If nil, a [MemorySessionStore] is used.
+	SessionStore SessionStore
+
+	// EventStore is used by every session served by the handler, to enable
+	// stream resumption. Sharing a store between processes allows a client to
+	// resume a stream from a different process.
+	// If nil, each session uses its own [MemoryEventStore].
+	EventStore EventStore
+
+	// StreamRetention is passed to each session's [StreamableServerTransport].
+	// See [StreamableServerTransportOptions.StreamRetention].
+	StreamRetention time.Duration
+
+	// SessionIdleTimeout, if positive, is how long a session may go without
+	// any HTTP requests in progress before it expires.
+	// An open GET stream counts as a request in progress.
+	SessionIdleTimeout time.Duration
+
+	// SessionMaxLifetime, if positive, is the maximum time a session may be
+	// served by the handler, regardless of activity, before it expires.
+	SessionMaxLifetime time.Duration
+
+	// MaxSessions, if positive, limits the number of sessions the handler serves
+	// at once, including sessions restored from the SessionStore. Requests that
+	// would create or restore a session beyond the limit are rejected with
+	// 503 Service Unavailable and a Retry-After header.
+	MaxSessions int
+
 	// TODO: support configurable session ID generation (?)
-	// TODO: support session retention (?)
 }
 
 // NewStreamableHTTPHandler returns a new [StreamableHTTPHandler].
@@ -51,25 +129,37 @@ type StreamableHTTPOptions struct {
 // The getServer function is used to create or look up servers for new
 // sessions. It is OK for getServer to return the same server multiple times.
 // If getServer returns nil, a 400 Bad Request will be served.
+//
+// Sessions are closed when the client sends a DELETE request, or when
+// they expire according to the options.
+// When a session is closed, its [ServerSession] is closed, which calls
+// [EventStore.SessionClosed], and it is deleted from the [SessionStore], so
+// that no process can restore it.
 func NewStreamableHTTPHandler(getServer func(*http.Request) *Server, opts *StreamableHTTPOptions) *StreamableHTTPHandler {
-	return &StreamableHTTPHandler{
+	h := &StreamableHTTPHandler{
 		getServer: getServer,
-		sessions:  make(map[string]*StreamableServerTransport),
+		sessions:  make(map[string]*handlerSession),
+		restoring: make(map[string]chan struct{}),
 	}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.SessionStore == nil {
+		h.opts.SessionStore = NewMemorySessionStore()
+	}
+	return h
 }
 
 // closeAll closes all ongoing sessions.
 //
 // TODO(rfindley): investigate the best API for callers to configure their
 // session lifecycle. (?)
-//
-// Should we allow passing in a session store? That would allow the handler to
-// be stateless.
 func (h *StreamableHTTPHandler) closeAll() {
 	h.sessionsMu.Lock()
 	defer h.sessionsMu.Unlock()
-	for _, s := range h.sessions {
-		s.Close()
+	for _, hs := range h.sessions {
+		hs.stopTimers()
+		hs.transport.Close()
 	}
 	h.sessions = nil
 }
@@ -98,29 +188,48 @@ func (h *StreamableHTTPHandler) ServeHTTP(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
-	var session *StreamableServerTransport
+	if h.opts.Stateless {
+		h.serveStateless(w, req)
+		return
+	}
+
+	var hs *handlerSession
 	if id := req.Header.Get(sessionIDHeader); id != "" {
-		h.sessionsMu.Lock()
-		session, _ = h.sessions[id]
-		h.sessionsMu.Unlock()
-		if session == nil {
+		var err error
+		hs, err = h.lookupSession(req, id)
+		if errors.Is(err, ErrSessionNotFound) {
 			http.Error(w, "session not found", http.StatusNotFound)
 			return
 		}
+		if err == errTooManySessions {
+			w.Header().Set("Retry-After", h.retryAfter())
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			// Don't reveal the details of the session store to clients.
+			http.Error(w, "failed to restore session", http.StatusInternalServerError)
+			return
+		}
+		if hs.subject != "" && tokenSubject(req) != hs.subject {
+			// Don't let one user's token drive another user's session.
+			http.Error(w, "session belongs to another user", http.StatusForbidden)
+			return
+		}
 	}
 
 	// TODO(rfindley): simplify the locking so that each request has only one
 	// critical section.
 	if req.Method == http.MethodDelete {
-		if session == nil {
+		if hs == nil {
 			// => Mcp-Session-Id was not set; else we'd have returned NotFound above.
 			http.Error(w, "DELETE requires an Mcp-Session-Id header", http.StatusBadRequest)
 			return
 		}
-		h.sessionsMu.Lock()
-		delete(h.sessions, session.sessionID)
-		h.sessionsMu.Unlock()
-		session.Close()
+		if err := h.closeSession(req.Context(), hs); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
@@ -133,36 +242,308 @@ func (h *StreamableHTTPHandler) ServeHTTP(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
-	if session == nil {
-		s := NewStreamableServerTransport(randText(), nil)
+	if hs == nil {
 		server := h.getServer(req)
 		if server == nil {
 			// The getServer argument to NewStreamableHTTPHandler returned nil.
 			http.Error(w, "no server available", http.StatusBadRequest)
 			return
 		}
-		// Pass req.Context() here, to allow middleware to add context values.
-		// The context is detached in the jsonrpc2 library when handling the
-		// long-running stream.
-		if _, err := server.Connect(req.Context(), s); err != nil {
+		h.sessionsMu.Lock()
+		reserved := h.reserveLocked()
+		h.sessionsMu.Unlock()
+		if !reserved {
+			w.Header().Set("Retry-After", h.retryAfter())
+			http.Error(w, errTooManySessions.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		var err error
+		hs, err = h.connect(req, server, randText())
+		h.sessionsMu.Lock()
+		h.reserved--
+		if err == nil {
+			h.addSessionLocked(hs)
+		}
+		h.sessionsMu.Unlock()
+		if err != nil {
 			http.Error(w, "failed connection", http.StatusInternalServerError)
 			return
 		}
+	}
+
+	h.beginRequest(hs)
+	defer h.endRequest(hs)
+	hs.transport.ServeHTTP(w, req)
+}
+
+// connect creates a transport for the session with the given ID, and connects
+// server to it. The session's state is saved to the session store whenever
+// it changes.
+func (h *StreamableHTTPHandler) connect(req *http.Request, server *Server, id string) (*handlerSession, error) {
+	t := NewStreamableServerTransport(id, &StreamableServerTransportOptions{
+		EventStore:      h.opts.EventStore,
+		StreamRetention: h.opts.StreamRetention,
+	})
+	// Pass req.Context() here, to allow middleware to add context values.
+	// The context is detached in the jsonrpc2 library when handling the
+	// long-running stream.
+	ss, err := server.Connect(req.Context(), t)
+	if err != nil {
+		return nil, err
+	}
+	hs := &handlerSession{transport: t, session: ss, subject: tokenSubject(req)}
+	ss.mu.Lock()
+	ss.saveState = func(ctx context.Context, state *SessionState) error {
+		state.Incarnation = t.incarnation
+		state.Subject = hs.subject
+		return h.opts.SessionStore.Store(ctx, id, state)
+	}
+	ss.mu.Unlock()
+	return hs, nil
+}
+
+// tokenSubject returns the subject of the bearer token of req, or "" if
+// there is none.
+func tokenSubject(req *http.Request) string {
+	if info := auth.TokenInfoFromContext(req.Context()); info != nil {
+		return info.Subject
+	}
+	return ""
+}
+
+// lookupSession returns the session with the given ID, restoring it from the
+// session store if it is not live in this process.
+// If the session does not exist, lookupSession returns an error wrapping
+// [ErrSessionNotFound].
+func (h *StreamableHTTPHandler) lookupSession(req *http.Request, id string) (*handlerSession, error) {
+	for {
 		h.sessionsMu.Lock()
-		h.sessions[s.sessionID] = s
+		if hs := h.sessions[id]; hs != nil {
+			h.sessionsMu.Unlock()
+			return hs, nil
+		}
+		if done := h.restoring[id]; done != nil {
+			// Another request is restoring the session. Wait for it, then look again.
+			h.sessionsMu.Unlock()
+			select {
+			case <-done:
+				continue
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			}
+		}
+		if !h.reserveLocked() {
+			h.sessionsMu.Unlock()
+			return nil, errTooManySessions
+		}
+		done := make(chan struct{})
+		h.restoring[id] = done
+		h.sessionsMu.Unlock()
+
+		// Restore without holding the lock, since the session store may be slow.
+		hs, err := h.restore(req, id)
+
+		h.sessionsMu.Lock()
+		h.reserved--
+		delete(h.restoring, id)
+		close(done)
+		if err == nil {
+			h.addSessionLocked(hs)
+		}
 		h.sessionsMu.Unlock()
-		session = s
+		return hs, err
 	}
+}
+
+// restore loads the session with the given ID from the session store, and
+// connects it to a server.
+func (h *StreamableHTTPHandler) restore(req *http.Request, id string) (*handlerSession, error) {
+	state, err := h.opts.SessionStore.Load(req.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	server := h.getServer(req)
+	if server == nil {
+		return nil, errors.New("no server available")
+	}
+	hs, err := h.connect(req, server, id)
+	if err != nil {
+		return nil, err
+	}
+	// Record the new incarnation before serving any requests, so that the next
+	// one has distinct stream IDs.
+	hs.subject = state.Subject
+	hs.transport.restore(state.Incarnation + 1)
+	hs.session.restoreState(state)
+	if err := hs.session.stateChanged(req.Context()); err != nil {
+		hs.session.Close()
+		return nil, err
+	}
+	return hs, nil
+}
+
+// addSessionLocked adds hs to the live sessions and starts its expiration timers.
+// h.sessionsMu must be held.
+func (h *StreamableHTTPHandler) addSessionLocked(hs *handlerSession) {
+	h.sessions[hs.transport.sessionID] = hs
+	if d := h.opts.SessionIdleTimeout; d > 0 {
+		hs.idleTimer = time.AfterFunc(d, func() { h.expireSession(hs, true) })
+	}
+	if d := h.opts.SessionMaxLifetime; d > 0 {
+		hs.lifetimeTimer = time.AfterFunc(d, func() { h.expireSession(hs, false) })
+	}
+}
+
+// errTooManySessions is returned by lookupSession when restoring a session
+// would exceed [StreamableHTTPOptions.MaxSessions].
+var errTooManySessions = errors.New("too many sessions")
 
-	session.ServeHTTP(w, req)
+// reserveLocked reserves a place for a new session, and reports whether
+// there was room for it. The caller must decrement h.reserved when the
+// session has been added or has failed.
+// h.sessionsMu must be held.
+func (h *StreamableHTTPHandler) reserveLocked() bool {
+	if h.opts.MaxSessions > 0 && len(h.sessions)+h.reserved >= h.opts.MaxSessions {
+		return false
+	}
+	h.reserved++
+	return true
+}
+
+// retryAfter returns the value of the Retry-After header to send when the
+// handler is at capacity, in seconds.
+func (h *StreamableHTTPHandler) retryAfter() string {
+	// Idle sessions will be reclaimed within the idle timeout, so that is a
+	// reasonable time to wait. Without one, we have no better guess than a minute.
+	d := h.opts.SessionIdleTimeout
+	if d <= 0 {
+		d = time.Minute
+	}
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// beginRequest records that a request for hs has started.
+func (h *StreamableHTTPHandler) beginRequest(hs *handlerSession) {
+	h.sessionsMu.Lock()
+	defer h.sessionsMu.Unlock()
+	hs.activeRequests++
+	if hs.idleTimer != nil {
+		hs.idleTimer.Stop()
+	}
+}
+
+// endRequest records that a request for hs has finished, and restarts the idle
+// timer if there are no more requests in progress.
+func (h *StreamableHTTPHandler) endRequest(hs *handlerSession) {
+	h.sessionsMu.Lock()
+	defer h.sessionsMu.Unlock()
+	hs.activeRequests--
+	if hs.activeRequests == 0 && hs.idleTimer != nil && h.sessions[hs.transport.sessionID] == hs {
+		hs.idleTimer.Reset(h.opts.SessionIdleTimeout)
+	}
+}
+
+// expireSession closes hs because its idle timeout or lifetime has elapsed.
+func (h *StreamableHTTPHandler) expireSession(hs *handlerSession, idle bool) {
+	id := hs.transport.sessionID
+	h.sessionsMu.Lock()
+	// The idle timer may have fired just as a request began.
+	if idle && hs.activeRequests > 0 || h.sessions[id] != hs {
+		h.sessionsMu.Unlock()
+		return
+	}
+	delete(h.sessions, id)
+	hs.stopTimers()
+	h.sessionsMu.Unlock()
+
+	// There is no client request to provide a context.
+	// TODO(#170): log errors when we add server-side logging.
+	_ = h.opts.SessionStore.Delete(context.Background(), id)
+	// Closing the session closes the transport, which informs the event store.
+	hs.session.Close()
+}
+
+// closeSession removes hs from the handler and the session store, and closes it.
+// It is a no-op if hs has already been removed.
+func (h *StreamableHTTPHandler) closeSession(ctx context.Context, hs *handlerSession) error {
+	id := hs.transport.sessionID
+	h.sessionsMu.Lock()
+	if h.sessions[id] != hs {
+		h.sessionsMu.Unlock()
+		return nil
+	}
+	delete(h.sessions, id)
+	hs.stopTimers()
+	h.sessionsMu.Unlock()
+
+	err := h.opts.SessionStore.Delete(ctx, id)
+	// Closing the session closes the transport, which informs the event store.
+	if cerr := hs.session.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// stopTimers stops the expiration timers of hs.
+func (hs *handlerSession) stopTimers() {
+	if hs.idleTimer != nil {
+		hs.idleTimer.Stop()
+	}
+	if hs.lifetimeTimer != nil {
+		hs.lifetimeTimer.Stop()
+	}
+}
+
+// serveStateless serves req with a new session that is closed when the request
+// completes. See [StreamableHTTPOptions.Stateless].
+func (h *StreamableHTTPHandler) serveStateless(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "stateless server: only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	server := h.getServer(req)
+	if server == nil {
+		// The getServer argument to NewStreamableHTTPHandler returned nil.
+		http.Error(w, "no server available", http.StatusBadRequest)
+		return
+	}
+	version := req.Header.Get(protocolVersionHeader)
+	if !slices.Contains(supportedProtocolVersions, version) {
+		version = latestProtocolVersion
+	}
+	t := NewStreamableServerTransport("", nil)
+	ss, err := server.Connect(req.Context(), t)
+	if err != nil {
+		http.Error(w, "failed connection", http.StatusInternalServerError)
+		return
+	}
+	defer ss.Close()
+	// There is no initialization handshake in stateless mode: every request is
+	// served as though the session were already initialized.
+	ss.initializeStateless(version)
+	t.ServeHTTP(w, req)
 }
 
 type StreamableServerTransportOptions struct {
 	// Storage for events, to enable stream resumption.
 	// If nil, a [MemoryEventStore] with the default maximum size will be used.
 	EventStore EventStore
+
+	// StreamRetention is how long a stream is retained for replay after its
+	// final response has been stored in the event store. After that, the
+	// stream is forgotten, its data is discarded from the event store, if it
+	// has a StreamClosed method, and requests to resume it fail with an error
+	// wrapping [ErrEventsPurged].
+	// If zero, [DefaultStreamRetention] is used. If negative, streams are
+	// retained until the session is closed.
+	StreamRetention time.Duration
 }
 
+// DefaultStreamRetention is the default value of
+// [StreamableServerTransportOptions.StreamRetention].
+const DefaultStreamRetention = 5 * time.Minute
+
 // NewStreamableServerTransport returns a new [StreamableServerTransport] with
 // the given session ID and options.
 // The session ID must be globally unique, that is, different from any other
@@ -189,6 +570,9 @@ func NewStreamableServerTransport(sessionID string, opts *StreamableServerTransp
 	if t.opts.EventStore == nil {
 		t.opts.EventStore = NewMemoryEventStore(nil)
 	}
+	if t.opts.StreamRetention == 0 {
+		t.opts.StreamRetention = DefaultStreamRetention
+	}
 	return t
 }
 
@@ -210,6 +594,20 @@ type StreamableServerTransport struct {
 	// Sessions are closed exactly once.
 	isDone bool
 
+	// restored reports whether the transport serves a session that was
+	// restored from a [SessionStore]. GET requests for streams unknown to
+	// a restored transport are served from the event store.
+	restored bool
+
+	// incarnation counts the times the session has been restored from a
+	// [SessionStore]. See [SessionState.Incarnation].
+	incarnation int64
+
+	// firstStreamID is the stream ID before the first one allocated by this
+	// transport. Together with nextStreamID, it identifies the streams that
+	// have been purged.
+	firstStreamID StreamID
+
 	// Sessions can have multiple logical connections, corresponding to HTTP
 	// requests. Additionally, logical sessions may be resumed by subsequent HTTP
 	// requests, when the session is terminated unexpectedly.
@@ -219,27 +617,24 @@ type StreamableServerTransport struct {
 	// handled.
 
 	// streams holds the logical streams for this session, keyed by their ID.
-	// TODO: streams are never deleted, so the memory for a connection grows without
-	// bound. If we deleted a stream when the response is sent, we would lose the ability
-	// to replay if there was a cut just before the response was transmitted.
-	// Perhaps we could have a TTL for streams that starts just after the response.
+	// A stream is deleted after its final response has been stored and
+	// opts.StreamRetention has elapsed. We don't delete it immediately, so that
+	// the client can still replay if there was a cut just before the response
+	// was transmitted.
 	streams map[StreamID]*stream
 
 	// requestStreams maps incoming requests to their logical stream ID.
 	//
-	// Lifecycle: requestStreams persists for the duration of the session.
-	//
-	// TODO(rfindley): clean up once requests are handled. See the TODO for streams
-	// above.
+	// Lifecycle: requestStreams entries persist until the request is replied to.
 	requestStreams map[jsonrpc.ID]StreamID
 }
 
 // A stream is a single logical stream of SSE events within a server session.
 // A stream begins with a client request, or with a client GET that has
 // no Last-Event-ID header.
-// A stream ends only when its session ends; we cannot determine its end otherwise,
-// since a client may send a GET with a Last-Event-ID that references the stream
-// at any time.
+// A client may send a GET with a Last-Event-ID that references the stream at any
+// time, so a stream is retained for a while after its final response.
+// The stream for the GET without Last-Event-ID ends only when its session ends.
 type stream struct {
 	// id is the logical ID for the stream, unique within a session.
 	// ID 0 is used for messages that don't correlate with an incoming request.
@@ -271,6 +666,18 @@ type stream struct {
 	// replied to by the server. Notably, NOT until they are sent to an HTTP
 	// response, as delivery is not guaranteed.
 	requests map[jsonrpc.ID]struct{}
+
+	// replayOnly reports whether the stream was created by another incarnation
+	// of the session, and so can only replay stored events.
+	replayOnly bool
+
+	// tokenInfo is the bearer token information of the POST that created the
+	// stream, if any. See [auth.RequireBearerToken].
+	tokenInfo *auth.TokenInfo
+
+	// retired reports whether the stream's final response has been stored,
+	// and the stream is scheduled to be purged.
+	retired bool
 }
 
 func newStream(id StreamID) *stream {
@@ -285,6 +692,22 @@ func signalChanPtr() *chan struct{} {
 	return &c
 }
 
+// restore prepares t to serve the given incarnation of a session restored
+// from a [SessionStore].
+func (t *StreamableServerTransport) restore(incarnation int64) {
+	t.restored = true
+	t.incarnation = incarnation
+	// Streams created by earlier incarnations of this session may still have
+	// events in a shared event store. Each incarnation numbers its streams in
+	// its own range, so that their IDs don't collide with those streams.
+	t.firstStreamID = StreamID(incarnation << streamIDBits)
+	t.nextStreamID.Store(int64(t.firstStreamID))
+}
+
+// streamIDBits is the number of low-order bits of a stream ID that number the
+// streams within one incarnation of a session.
+const streamIDBits = 32
+
 // A StreamID identifies a stream of SSE events. It is unique within the stream's
 // [ServerSession].
 type StreamID int64
@@ -357,6 +780,20 @@ func (t *StreamableServerTransport) serveGET(w http.ResponseWriter, req *http.Re
 
 	t.mu.Lock()
 	stream, ok := t.streams[id]
+	if !ok && id > t.firstStreamID && id <= StreamID(t.nextStreamID.Load()) {
+		// We created the stream, so it must have been purged.
+		t.mu.Unlock()
+		// Use the same status as for events purged from the event store.
+		return http.StatusInsufficientStorage, fmt.Sprintf("stream %d: %v", id, ErrEventsPurged)
+	}
+	if !ok && t.restored && lastIdx >= 0 {
+		// The stream may have been created by a previous incarnation of the
+		// session. Replay what the event store has for it.
+		stream = newStream(id)
+		stream.replayOnly = true
+		t.streams[id] = stream
+		ok = true
+	}
 	t.mu.Unlock()
 	if !ok {
 		return http.StatusBadRequest, "unknown stream"
@@ -365,6 +802,15 @@ func (t *StreamableServerTransport) serveGET(w http.ResponseWriter, req *http.Re
 		// The CAS returned false, meaning that the comparison failed: stream.signal is not nil.
 		return http.StatusBadRequest, "stream ID conflicts with ongoing stream"
 	}
+	if stream.replayOnly {
+		// The event store is the source of truth for the stream, so there is
+		// no need to keep it after the replay.
+		defer func() {
+			t.mu.Lock()
+			delete(t.streams, id)
+			t.mu.Unlock()
+		}()
+	}
 	return t.streamResponse(stream, w, req, lastIdx)
 }
 
@@ -402,10 +848,12 @@ func (t *StreamableServerTransport) servePOST(w http.ResponseWriter, req *http.R
 
 	// Update accounting for this request.
 	stream := newStream(StreamID(t.nextStreamID.Add(1)))
+	stream.tokenInfo = auth.TokenInfoFromContext(req.Context())
 	t.mu.Lock()
-	t.streams[stream.id] = stream
+	// A stream without requests will never have any events, so there is
+	// nothing to retain.
 	if len(requests) > 0 {
-		stream.requests = make(map[jsonrpc.ID]struct{})
+		t.streams[stream.id] = stream
 	}
 	for reqID := range requests {
 		t.requestStreams[reqID] = stream.id
@@ -450,7 +898,9 @@ func (t *StreamableServerTransport) streamResponse(stream *stream, w http.Respon
 		return true
 	}
 
-	w.Header().Set(sessionIDHeader, t.sessionID)
+	if t.sessionID != "" {
+		w.Header().Set(sessionIDHeader, t.sessionID)
+	}
 	w.Header().Set("Content-Type", "text/event-stream") // Accept checked in [StreamableHTTPHandler]
 	w.Header().Set("Cache-Control", "no-cache, no-transform")
 	w.Header().Set("Connection", "keep-alive")
@@ -474,6 +924,10 @@ func (t *StreamableServerTransport) streamResponse(stream *stream, w http.Respon
 				return 0, ""
 			}
 		}
+		if stream.replayOnly {
+			// No new events will arrive on this stream.
+			return 0, ""
+		}
 	}
 
 stream:
@@ -484,18 +938,28 @@ stream:
 		stream.outgoing = nil
 		t.mu.Unlock()
 
+		broken := false
 		for _, data := range outgoing {
 			if err := t.opts.EventStore.Append(req.Context(), t.SessionID(), stream.id, data); err != nil {
 				return http.StatusInternalServerError, err.Error()
 			}
-			if !write(data) {
-				return 0, ""
+			// If the connection breaks, keep storing the messages, so that the
+			// client can replay them.
+			if !broken && !write(data) {
+				broken = true
 			}
 		}
 
 		t.mu.Lock()
 		nOutstanding := len(stream.requests)
+		if nOutstanding == 0 && len(stream.outgoing) == 0 {
+			// The final response is in the event store.
+			t.retireStreamLocked(stream)
+		}
 		t.mu.Unlock()
+		if broken {
+			return 0, ""
+		}
 		// If all requests have been handled and replied to, we should terminate this connection.
 		// "After the JSON-RPC response has been sent, the server SHOULD close the SSE stream."
 		// §6.4, https://modelcontextprotocol.io/specification/2025-06-18/basic/transports#sending-messages-to-the-server
@@ -633,6 +1097,7 @@ func (t *StreamableServerTransport) Write(ctx context.Context, msg jsonrpc.Messa
 	if isResponse {
 		// Once we've put the reply on the queue, it's no longer outstanding.
 		delete(stream.requests, forRequest)
+		delete(t.requestStreams, forRequest)
 	}
 
 	// Signal streamResponse that new work is available.
@@ -646,6 +1111,49 @@ func (t *StreamableServerTransport) Write(ctx context.Context, msg jsonrpc.Messa
 	return nil
 }
 
+// tokenInfo implements [tokenInfoConn].
+func (t *StreamableServerTransport) tokenInfo(id jsonrpc.ID) *auth.TokenInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sid, ok := t.requestStreams[id]
+	if !ok {
+		return nil
+	}
+	if s := t.streams[sid]; s != nil {
+		return s.tokenInfo
+	}
+	return nil
+}
+
+// retireStreamLocked arranges for stream to be purged after the retention
+// period, once its final response has been added to the event store.
+// t.mu must be held.
+func (t *StreamableServerTransport) retireStreamLocked(stream *stream) {
+	if stream.id == 0 || stream.retired || t.opts.StreamRetention <= 0 || t.streams[stream.id] != stream {
+		// The stream is permanent, already retired, or was never retained,
+		// like the stream of a POST of notifications.
+		return
+	}
+	stream.retired = true
+	time.AfterFunc(t.opts.StreamRetention, func() { t.purgeStream(stream.id) })
+}
+
+// purgeStream forgets the stream with the given ID, and informs the event store.
+func (t *StreamableServerTransport) purgeStream(id StreamID) {
+	t.mu.Lock()
+	if t.isDone {
+		// The event store has already been informed that the session is closed.
+		t.mu.Unlock()
+		return
+	}
+	delete(t.streams, id)
+	t.mu.Unlock()
+	// TODO(#170): log errors when we add server-side logging.
+	if sc, ok := t.opts.EventStore.(streamCloser); ok {
+		_ = sc.StreamClosed(context.Background(), t.sessionID, id)
+	}
+}
+
 // Close implements the [Connection] interface.
 func (t *StreamableServerTransport) Close() error {
 	t.mu.Lock()
@@ -669,29 +1177,36 @@ type StreamableClientTransport struct {
 }
 
 // StreamableReconnectOptions defines parameters for client reconnect attempts.
+// They apply both to the standalone SSE stream and to the SSE streams that
+// carry responses to POST requests.
 type StreamableReconnectOptions struct {
 	// MaxRetries is the maximum number of times to attempt a reconnect before giving up.
 	// A value of 0 or less means never retry.
 	MaxRetries int
 
-	// growFactor is the multiplicative factor by which the delay increases after each attempt.
+	// GrowFactor is the multiplicative factor by which the delay increases after each attempt.
 	// A value of 1.0 results in a constant delay, while a value of 2.0 would double it each time.
-	// It must be 1.0 or greater if MaxRetries is greater than 0.
-	growFactor float64
-
-	// initialDelay is the base delay for the first reconnect attempt.
-	initialDelay time.Duration
-
-	// maxDelay caps the backoff delay, preventing it from growing indefinitely.
-	maxDelay time.Duration
+	// It must be 1.0 or greater.
+	// If zero, the value from [DefaultReconnectOptions] is used.
+	GrowFactor float64
+
+	// InitialDelay is the base delay for the first reconnect attempt.
+	// It must not be negative.
+	// If zero, the value from [DefaultReconnectOptions] is used.
+	InitialDelay time.Duration
+
+	// MaxDelay caps the backoff delay, preventing it from growing indefinitely.
+	// It must not be negative.
+	// If zero, the value from [DefaultReconnectOptions] is used.
+	MaxDelay time.Duration
 }
 
 // DefaultReconnectOptions provides sensible defaults for reconnect logic.
 var DefaultReconnectOptions = &StreamableReconnectOptions{
 	MaxRetries:   5,
-	growFactor:   1.5,
-	initialDelay: 1 * time.Second,
-	maxDelay:     30 * time.Second,
+	GrowFactor:   1.5,
+	InitialDelay: 1 * time.Second,
+	MaxDelay:     30 * time.Second,
 }
 
 // StreamableClientTransportOptions provides options for the
@@ -701,6 +1216,39 @@ type StreamableClientTransportOptions struct {
 	// http.DefaultClient is used.
 	HTTPClient       *http.Client
 	ReconnectOptions *StreamableReconnectOptions
+	// OAuth, if non-nil, configures how the transport obtains access tokens
+	// when the server requires authorization. See [auth.NewOAuthTransport].
+	// If OAuth.Resource is empty, the transport URL is used.
+	OAuth *auth.OAuthConfig
+	// SessionRecovery, if non-nil, causes the connection to start a new
+	// session when the server responds with 404 Not Found to a request for the
+	// current session, as a server does after it restarts and forgets its
+	// sessions. Without it, the connection fails.
+	SessionRecovery *StreamableSessionRecoveryOptions
+}
+
+// StreamableSessionRecoveryOptions configure how a streamable client
+// connection replaces a session that the server no longer knows.
+//
+// The connection starts the new session with the initialize request of the
+// old one, so it has the same client capabilities. Then it sends the last
+// "logging/setLevel" request, and a "resources/subscribe" request for each
+// resource that is still subscribed. Finally it sends again the requests
+// that were awaiting responses in the old session, if they are idempotent:
+// list requests, "resources/read", "prompts/get", "completion/complete",
+// "ping", and "tools/call" for tools whose annotations have ReadOnlyHint or
+// IdempotentHint set, according to the last "tools/list" response.
+// Other requests that were awaiting responses fail.
+//
+// The [ClientSession] continues to work, and its ID becomes that of the new
+// session.
+type StreamableSessionRecoveryOptions struct {
+	// RecoveredHandler, if non-nil, is called in its own goroutine after a new
+	// session replaces the old one, with the ID of the new session and the
+	// server's response to its initialize request. Since the server may have
+	// changed, the handler can refresh cached lists of tools, prompts and
+	// resources.
+	RecoveredHandler func(sessionID string, res *InitializeResult)
 }
 
 // NewStreamableClientTransport returns a new client transport that connects to
@@ -726,9 +1274,21 @@ func (t *StreamableClientTransport) Connect(ctx context.Context) (Connection, er
 	if client == nil {
 		client = http.DefaultClient
 	}
-	reconnOpts := t.opts.ReconnectOptions
-	if reconnOpts == nil {
-		reconnOpts = DefaultReconnectOptions
+	client = oauthClient(client, t.opts.OAuth, t.url)
+	reconnOpts := DefaultReconnectOptions
+	if o := t.opts.ReconnectOptions; o != nil {
+		reconnOpts = &StreamableReconnectOptions{
+			MaxRetries:   o.MaxRetries,
+			GrowFactor:   cmp.Or(o.GrowFactor, DefaultReconnectOptions.GrowFactor),
+			InitialDelay: cmp.Or(o.InitialDelay, DefaultReconnectOptions.InitialDelay),
+			MaxDelay:     cmp.Or(o.MaxDelay, DefaultReconnectOptions.MaxDelay),
+		}
+		if !(reconnOpts.GrowFactor >= 1) {
+			return nil, fmt.Errorf("ReconnectOptions.GrowFactor is %v; it must be at least 1", reconnOpts.GrowFactor)
+		}
+		if reconnOpts.InitialDelay < 0 || reconnOpts.MaxDelay < 0 {
+			return nil, errors.New("ReconnectOptions delays must not be negative")
+		}
 	}
 	// Create a new cancellable context that will manage the connection's lifecycle.
 	// This is crucial for cleanly shutting down the background SSE listener by
@@ -743,11 +1303,17 @@ func (t *StreamableClientTransport) Connect(ctx context.Context) (Connection, er
 		ctx:              connCtx,
 		cancel:           cancel,
 	}
+	if t.opts.SessionRecovery != nil {
+		conn.recovery = t.opts.SessionRecovery
+		conn.pending = make(map[jsonrpc.ID]*jsonrpc.Request)
+		conn.replays = make(map[jsonrpc.ID]chan *jsonrpc.Response)
+		conn.idempotentTools = make(map[string]bool)
+	}
 	// Start the persistent SSE listener right away.
 	// Section 2.2: The client MAY issue an HTTP GET to the MCP endpoint.
 	// This can be used to open an SSE stream, allowing the server to
 	// communicate to the client, without the client first sending data via HTTP POST.
-	go conn.handleSSE(nil, true)
+	go conn.handleSSE(nil, &sseStream{persistent: true})
 
 	return conn, nil
 }
@@ -768,6 +1334,16 @@ type streamableClientConn struct {
 	protocolVersion string
 	_sessionID      string
 	err             error
+
+	// Session recovery, if recovery is non-nil.
+	// The fields after recoverMu are guarded by mu.
+	recovery        *StreamableSessionRecoveryOptions
+	recoverMu       sync.RWMutex // held for writing while a session is replaced
+	state           sessionState
+	pending         map[jsonrpc.ID]*jsonrpc.Request       // calls awaiting responses
+	replays         map[jsonrpc.ID]chan *jsonrpc.Response // replayed calls; a nil channel discards the response
+	idempotentTools map[string]bool
+	nextReplayID    int
 }
 
 func (c *streamableClientConn) setProtocolVersion(s string) {
@@ -784,24 +1360,85 @@ func (c *streamableClientConn) SessionID() string {
 
 // Read implements the [Connection] interface.
 func (s *streamableClientConn) Read(ctx context.Context) (jsonrpc.Message, error) {
-	s.mu.Lock()
-	err := s.err
-	s.mu.Unlock()
-	if err != nil {
-		return nil, err
-	}
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case <-s.done:
-		return nil, io.EOF
-	case data := <-s.incoming:
-		return jsonrpc2.DecodeMessage(data)
+	for {
+		s.mu.Lock()
+		err := s.err
+		s.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-s.done:
+			return nil, io.EOF
+		case data := <-s.incoming:
+			msg, err := jsonrpc2.DecodeMessage(data)
+			if err != nil || s.recovery == nil {
+				return msg, err
+			}
+			if resp, ok := msg.(*jsonrpc.Response); ok && !s.observeResponse(resp) {
+				continue
+			}
+			return msg, nil
+		}
 	}
 }
 
 // Write implements the [Connection] interface.
+//
+// If session recovery is enabled and the server no longer knows the session,
+// Write replaces the session, and then writes msg to the new session if it is
+// a notification or an idempotent call. Other calls receive error responses,
+// since an error from Write would break the connection.
 func (s *streamableClientConn) Write(ctx context.Context, msg jsonrpc.Message) error {
+	if s.recovery == nil {
+		return s.write(ctx, msg)
+	}
+	req, _ := msg.(*jsonrpc.Request)
+	if req != nil {
+		s.mu.Lock()
+		s.state.record(req)
+		if req.IsCall() {
+			s.pending[req.ID] = req
+		} else if id, ok := cancelledID(req); ok {
+			// The cancelled call won't be retried, and may never be answered.
+			delete(s.pending, id)
+		}
+		s.mu.Unlock()
+	}
+	s.recoverMu.RLock()
+	err := s.write(ctx, msg)
+	s.recoverMu.RUnlock()
+
+	var nf *sessionNotFoundError
+	if req == nil || !errors.As(err, &nf) {
+		return err
+	}
+	if req.IsCall() {
+		// This call is retried below, not by recoverSession.
+		s.mu.Lock()
+		delete(s.pending, req.ID)
+		s.mu.Unlock()
+	}
+	if err := s.recoverSession(nf.sessionID); err != nil {
+		return err
+	}
+	if req.IsCall() {
+		if !s.idempotent(req) {
+			return s.failCall(req.ID, fmt.Errorf("session %s expired; %s was not retried because it may not be idempotent", nf.sessionID, req.Method))
+		}
+		s.mu.Lock()
+		s.pending[req.ID] = req
+		s.mu.Unlock()
+	}
+	s.recoverMu.RLock()
+	defer s.recoverMu.RUnlock()
+	return s.write(ctx, msg)
+}
+
+// write writes msg to the current session.
+func (s *streamableClientConn) write(ctx context.Context, msg jsonrpc.Message) error {
 	s.mu.Lock()
 	if s.err != nil {
 		s.mu.Unlock()
@@ -866,6 +1503,9 @@ func (s *streamableClientConn) postMessage(ctx context.Context, sessionID string
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		// TODO: do a best effort read of the body here, and format it in the error.
 		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound && sessionID != "" {
+			return "", &sessionNotFoundError{sessionID, resp.Status}
+		}
 		return "", fmt.Errorf("broken session: %v", resp.Status)
 	}
 
@@ -873,7 +1513,11 @@ func (s *streamableClientConn) postMessage(ctx context.Context, sessionID string
 	switch ct := resp.Header.Get("Content-Type"); ct {
 	case "text/event-stream":
 		// Section 2.1: The SSE stream is initiated after a POST.
-		go s.handleSSE(resp, false)
+		stream := &sseStream{}
+		if req, ok := msg.(*jsonrpc.Request); ok {
+			stream.callID = req.ID
+		}
+		go s.handleSSE(resp, stream)
 	case "application/json":
 		body, err := io.ReadAll(resp.Body)
 		resp.Body.Close()
@@ -893,27 +1537,71 @@ func (s *streamableClientConn) postMessage(ctx context.Context, sessionID string
 	return sessionID, nil
 }
 
+// An sseStream is the state of an SSE stream as seen by the client, which
+// may span several HTTP responses if the stream is resumed.
+type sseStream struct {
+	persistent  bool       // the standalone stream, rather than one initiated by a POST
+	callID      jsonrpc.ID // for a POST stream, the call whose response it carries
+	lastEventID string     // ID of the last event received, for resumption
+	responded   bool       // the response to callID was received
+}
+
 // handleSSE manages the lifecycle of an SSE connection. It can be either
 // persistent (for the main GET listener) or temporary (for a POST response).
-func (s *streamableClientConn) handleSSE(initialResp *http.Response, persistent bool) {
+//
+// A temporary stream is resumed until it delivers the response to its call,
+// so that a long-running call survives a broken network connection.
+func (s *streamableClientConn) handleSSE(initialResp *http.Response, stream *sseStream) {
 	resp := initialResp
-	var lastEventID string
 	for {
-		eventID, clientClosed := s.processStream(resp)
-		lastEventID = eventID
+		interrupted, clientClosed := s.processStream(resp, stream)
 
 		// If the connection was closed by the client, we're done.
 		if clientClosed {
 			return
 		}
-		// If the stream has ended, then do not reconnect if the stream is
-		// temporary (POST initiated SSE).
-		if lastEventID == "" && !persistent {
-			return
+		if !stream.persistent {
+			if stream.responded || !stream.callID.IsValid() {
+				return
+			}
+			if stream.lastEventID == "" {
+				// There is nothing to resume from.
+				s.failCall(stream.callID, errors.New("response stream ended before the response"))
+				return
+			}
+		} else if !interrupted {
+			// The server ended the stream. Start a new one.
+			stream.lastEventID = ""
 		}
 
 		// The stream was interrupted or ended by the server. Attempt to reconnect.
-		newResp, err := s.reconnect(lastEventID)
+		newResp, err := s.reconnect(stream.lastEventID)
+		var nf *sessionNotFoundError
+		if s.recovery != nil && errors.As(err, &nf) {
+			if err := s.recoverSession(nf.sessionID); err != nil {
+				s.Close()
+				return
+			}
+			if !stream.persistent {
+				// The calls whose responses were on this stream have been
+				// retried or have failed.
+				return
+			}
+			// Listen to the new session.
+			stream.lastEventID = ""
+			resp = nil
+			continue
+		}
+		if stream.persistent && errors.Is(err, errNoSSEStream) {
+			// The server does not offer a standalone SSE stream, as in stateless
+			// mode. That's fine: we'll get responses from POSTs.
+			return
+		}
+		if err != nil && !stream.persistent {
+			// Only the call is lost.
+			s.failCall(stream.callID, fmt.Errorf("resuming response stream: %w", err))
+			return
+		}
 		if err != nil {
 			// All reconnection attempts failed. Set the final error, close the
 			// connection, and exit the goroutine.
@@ -930,33 +1618,41 @@ func (s *streamableClientConn) handleSSE(initialResp *http.Response, persistent
 }
 
 // processStream reads from a single response body, sending events to the
-// incoming channel. It returns the ID of the last processed event, any error
-// that occurred, and a flag indicating if the connection was closed by the client.
-// If resp is nil, it returns "", false.
-func (s *streamableClientConn) processStream(resp *http.Response) (lastEventID string, clientClosed bool) {
+// incoming channel and updating stream. It reports whether reading was
+// interrupted by an error rather than ended by the server, and whether the
+// connection was closed by the client.
+// If resp is nil, it returns false, false.
+func (s *streamableClientConn) processStream(resp *http.Response, stream *sseStream) (interrupted, clientClosed bool) {
 	if resp == nil {
-		return "", false
+		return false, false
 	}
 
 	defer resp.Body.Close()
 	for evt, err := range scanEvents(resp.Body) {
 		if err != nil {
-			return lastEventID, false
+			return true, false
 		}
 
 		if evt.ID != "" {
-			lastEventID = evt.ID
+			stream.lastEventID = evt.ID
+		}
+		if stream.callID.IsValid() && !stream.responded {
+			if msg, err := jsonrpc2.DecodeMessage(evt.Data); err == nil {
+				if r, ok := msg.(*jsonrpc.Response); ok && r.ID == stream.callID {
+					stream.responded = true
+				}
+			}
 		}
 
 		select {
 		case s.incoming <- evt.Data:
 		case <-s.done:
 			// The connection was closed by the client; exit gracefully.
-			return "", true
+			return false, true
 		}
 	}
 	// The loop finished without an error, indicating the server closed the stream.
-	return "", false
+	return false, false
 }
 
 // reconnect handles the logic of retrying a connection with an exponential
@@ -971,11 +1667,18 @@ func (s *streamableClientConn) reconnect(lastEventID string) (*http.Response, er
 			return nil, fmt.Errorf("connection closed by client during reconnect")
 		case <-time.After(calculateReconnectDelay(s.ReconnectOptions, attempt)):
 			resp, err := s.establishSSE(lastEventID)
+			if nf := (*sessionNotFoundError)(nil); errors.As(err, &nf) {
+				return nil, err
+			}
 			if err != nil {
 				finalErr = err // Store the error and try again.
 				continue
 			}
 
+			if resp.StatusCode == http.StatusMethodNotAllowed {
+				resp.Body.Close()
+				return nil, errNoSSEStream
+			}
 			if !isResumable(resp) {
 				// The server indicated we should not continue.
 				resp.Body.Close()
@@ -992,6 +1695,10 @@ func (s *streamableClientConn) reconnect(lastEventID string) (*http.Response, er
 	return nil, fmt.Errorf("connection failed after %d attempts", s.ReconnectOptions.MaxRetries)
 }
 
+// errNoSSEStream is returned by reconnect when the server responds to a GET
+// with 405 Method Not Allowed, meaning it does not offer an SSE stream.
+var errNoSSEStream = errors.New("server does not support SSE streams at this endpoint")
+
 // isResumable checks if an HTTP response indicates a valid SSE stream that can be processed.
 func isResumable(resp *http.Response) bool {
 	// Per the spec, a 405 response means the server doesn't support SSE streams at this endpoint.
@@ -1009,6 +1716,13 @@ func (s *streamableClientConn) Close() error {
 		s.cancel()
 		close(s.done)
 
+		s.mu.Lock()
+		sessionID := s._sessionID
+		s.mu.Unlock()
+		if sessionID == "" {
+			// There is no session to terminate, as with a stateless server.
+			return
+		}
 		req, err := http.NewRequest(http.MethodDelete, s.url, nil)
 		if err != nil {
 			s.closeErr = err
@@ -1017,7 +1731,7 @@ func (s *streamableClientConn) Close() error {
 			if s.protocolVersion != "" {
 				req.Header.Set(protocolVersionHeader, s.protocolVersion)
 			}
-			req.Header.Set(sessionIDHeader, s._sessionID)
+			req.Header.Set(sessionIDHeader, sessionID)
 			if _, err := s.client.Do(req); err != nil {
 				s.closeErr = err
 			}
@@ -1035,24 +1749,222 @@ func (s *streamableClientConn) establishSSE(lastEventID string) (*http.Response,
 		return nil, err
 	}
 	s.mu.Lock()
-	if s._sessionID != "" {
-		req.Header.Set("Mcp-Session-Id", s._sessionID)
-	}
+	sessionID := s._sessionID
 	s.mu.Unlock()
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
 	if lastEventID != "" {
 		req.Header.Set("Last-Event-ID", lastEventID)
 	}
 	req.Header.Set("Accept", "text/event-stream")
 
-	return s.client.Do(req)
+	resp, err := s.client.Do(req)
+	if err == nil && resp.StatusCode == http.StatusNotFound && sessionID != "" {
+		resp.Body.Close()
+		return nil, &sessionNotFoundError{sessionID, resp.Status}
+	}
+	return resp, err
+}
+
+// A sessionNotFoundError reports that the server responded with 404 Not Found
+// to a request for a session, meaning that it no longer knows the session.
+type sessionNotFoundError struct {
+	sessionID string
+	status    string
+}
+
+func (e *sessionNotFoundError) Error() string {
+	return "broken session: " + e.status
+}
+
+// recoverSession starts a new session to replace the one with the given ID,
+// which the server no longer knows. See [StreamableSessionRecoveryOptions].
+// If the session was already replaced, recoverSession does nothing.
+// If recovery fails, the connection is broken.
+func (s *streamableClientConn) recoverSession(staleID string) error {
+	s.recoverMu.Lock()
+	defer s.recoverMu.Unlock()
+
+	s.mu.Lock()
+	if s._sessionID != staleID {
+		s.mu.Unlock()
+		return nil
+	}
+	state := s.state.clone()
+	pending := s.pending
+	s.pending = make(map[jsonrpc.ID]*jsonrpc.Request)
+	s._sessionID = ""
+	s.mu.Unlock()
+
+	fail := func(err error) error {
+		err = fmt.Errorf("recovering session %s: %w", staleID, err)
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+		return err
+	}
+	if state.initialize == nil {
+		return fail(errors.New("the session was not initialized"))
+	}
+
+	// The connection's context, not that of any request, governs recovery.
+	ctx := s.ctx
+	init := *state.initialize
+	init.ID = s.newReplayID()
+	respc := make(chan *jsonrpc.Response, 1)
+	s.mu.Lock()
+	s.replays[init.ID] = respc
+	s.mu.Unlock()
+	if err := s.write(ctx, &init); err != nil {
+		return fail(err)
+	}
+	var resp *jsonrpc.Response
+	select {
+	case resp = <-respc:
+	case <-s.done:
+		return fail(errors.New("connection closed"))
+	}
+	if resp.Error != nil {
+		return fail(resp.Error)
+	}
+	var res InitializeResult
+	if err := json.Unmarshal(resp.Result, &res); err != nil {
+		return fail(err)
+	}
+	s.setProtocolVersion(res.ProtocolVersion)
+	for _, req := range state.requests() {
+		r := *req
+		if r.IsCall() {
+			r.ID = s.newReplayID()
+			s.mu.Lock()
+			s.replays[r.ID] = nil
+			s.mu.Unlock()
+		}
+		if err := s.write(ctx, &r); err != nil {
+			return fail(err)
+		}
+	}
+
+	// Retry the calls that were awaiting responses, if possible.
+	for _, req := range pending {
+		if s.idempotent(req) {
+			s.mu.Lock()
+			s.pending[req.ID] = req
+			s.mu.Unlock()
+			if s.write(ctx, req) == nil {
+				continue
+			}
+			s.mu.Lock()
+			delete(s.pending, req.ID)
+			s.mu.Unlock()
+		}
+		if err := s.failCall(req.ID, fmt.Errorf("session %s expired before %s completed", staleID, req.Method)); err != nil {
+			return fail(err)
+		}
+	}
+
+	if h := s.recovery.RecoveredHandler; h != nil {
+		go h(s.SessionID(), &res)
+	}
+	return nil
+}
+
+// cancelledID returns the ID of the call that req cancels, if req is a
+// cancellation notification.
+func cancelledID(req *jsonrpc.Request) (jsonrpc.ID, bool) {
+	if req.Method != notificationCancelled {
+		return jsonrpc.ID{}, false
+	}
+	var params CancelledParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return jsonrpc.ID{}, false
+	}
+	id, err := jsonrpc2.MakeID(params.RequestID)
+	if err != nil || !id.IsValid() {
+		return jsonrpc.ID{}, false
+	}
+	return id, true
+}
+
+// failCall delivers an error response to the call with the given ID.
+func (s *streamableClientConn) failCall(id jsonrpc.ID, callErr error) error {
+	data, err := jsonrpc2.EncodeMessage(&jsonrpc.Response{ID: id, Error: callErr})
+	if err != nil {
+		return err
+	}
+	select {
+	case s.incoming <- data:
+		return nil
+	case <-s.done:
+		return errors.New("connection closed")
+	}
+}
+
+// newReplayID returns an ID for a call made during session recovery.
+func (s *streamableClientConn) newReplayID() jsonrpc.ID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextReplayID++
+	// String IDs cannot collide with the integer IDs of the session's calls.
+	return jsonrpc2.StringID(fmt.Sprintf("recover-%d", s.nextReplayID))
+}
+
+// observeResponse updates the recovery state for an incoming response, and
+// reports whether Read should return it.
+func (s *streamableClientConn) observeResponse(resp *jsonrpc.Response) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.replays[resp.ID]; ok {
+		delete(s.replays, resp.ID)
+		if c != nil {
+			c <- resp
+		}
+		return false
+	}
+	req := s.pending[resp.ID]
+	delete(s.pending, resp.ID)
+	if req != nil && req.Method == methodListTools && resp.Error == nil {
+		var res ListToolsResult
+		if err := json.Unmarshal(resp.Result, &res); err == nil {
+			for _, t := range res.Tools {
+				a := t.Annotations
+				s.idempotentTools[t.Name] = a != nil && (a.ReadOnlyHint || a.IdempotentHint)
+			}
+		}
+	}
+	return true
+}
+
+// idempotent reports whether req can safely be sent again.
+func (s *streamableClientConn) idempotent(req *jsonrpc.Request) bool {
+	switch req.Method {
+	case methodListTools, methodListPrompts, methodListResources, methodListResourceTemplates,
+		methodReadResource, methodGetPrompt, methodComplete, methodPing:
+		return true
+	case methodCallTool:
+		var params struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return false
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.idempotentTools[params.Name]
+	}
+	return false
 }
 
 // calculateReconnectDelay calculates a delay using exponential backoff with full jitter.
 func calculateReconnectDelay(opts *StreamableReconnectOptions, attempt int) time.Duration {
-	// Calculate the exponential backoff using the grow factor.
-	backoffDuration := time.Duration(float64(opts.initialDelay) * math.Pow(opts.growFactor, float64(attempt)))
-	// Cap the backoffDuration at maxDelay.
-	backoffDuration = min(backoffDuration, opts.maxDelay)
+	// Calculate the exponential backoff using the grow factor, capped at
+	// MaxDelay. Cap it before converting to a Duration, which would overflow.
+	backoff := float64(opts.InitialDelay) * math.Pow(opts.GrowFactor, float64(attempt))
+	backoffDuration := time.Duration(min(backoff, float64(opts.MaxDelay)))
+	if backoffDuration <= 0 {
+		return 0
+	}
 
 	// Use a full jitter using backoffDuration
 	jitter := rand.N(backoffDuration)
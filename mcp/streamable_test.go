@@ -8,14 +8,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"math"
 	"net"
 	"net/http"
 	"net/http/cookiejar"
 	"net/http/httptest"
 	"net/http/httputil"
 	"net/url"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -24,7 +28,9 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/modelcontextprotocol/go-sdk/auth"
 	"github.com/modelcontextprotocol/go-sdk/internal/jsonrpc2"
+	"github.com/modelcontextprotocol/go-sdk/internal/oauthtest"
 	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
 	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 )
@@ -211,8 +217,115 @@ func TestClientReplay(t *testing.T) {
 	}
 }
 
+func TestClientResumesPOSTStream(t *testing.T) {
+	// Check that a call survives the loss of the connection carrying its
+	// response stream.
+	started := make(chan struct{}, 1)
+	release := make(chan struct{}, 1)
+	server := NewServer(testImpl, nil)
+	AddTool(server, &Tool{Name: "slow"}, func(ctx context.Context, ss *ServerSession, _ *CallToolParamsFor[map[string]any]) (*CallToolResultFor[any], error) {
+		if err := ss.NotifyProgress(ctx, &ProgressNotificationParams{Message: "started"}); err != nil {
+			return nil, err
+		}
+		<-release
+		return &CallToolResultFor[any]{Content: []Content{&TextContent{Text: "done"}}}, nil
+	})
+	handler := NewStreamableHTTPHandler(func(*http.Request) *Server { return server }, nil)
+	defer handler.closeAll()
+	var rejectResume atomic.Bool
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rejectResume.Load() && r.Header.Get("Last-Event-ID") != "" {
+			http.Error(w, "no resumption", http.StatusBadRequest)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer httpServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client := NewClient(testImpl, &ClientOptions{
+		ProgressNotificationHandler: func(context.Context, *ClientSession, *ProgressNotificationParams) {
+			started <- struct{}{}
+		},
+	})
+	transport := NewStreamableClientTransport(httpServer.URL, &StreamableClientTransportOptions{
+		ReconnectOptions: &StreamableReconnectOptions{MaxRetries: 5, InitialDelay: 10 * time.Millisecond},
+	})
+	session, err := client.Connect(ctx, transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	type result struct {
+		res *CallToolResult
+		err error
+	}
+	// callAndBreak calls the slow tool, and breaks the connection carrying
+	// the response while the call is in progress.
+	callAndBreak := func() <-chan result {
+		resc := make(chan result, 1)
+		go func() {
+			res, err := session.CallTool(ctx, &CallToolParams{Name: "slow"})
+			resc <- result{res, err}
+		}()
+		select {
+		case <-started:
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the call to start")
+		}
+		httpServer.CloseClientConnections()
+		return resc
+	}
+
+	resc := callAndBreak()
+	release <- struct{}{}
+	r := <-resc
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	want := &CallToolResult{Content: []Content{&TextContent{Text: "done"}}}
+	if diff := cmp.Diff(want, r.res); diff != "" {
+		t.Errorf("CallTool mismatch (-want +got):\n%s", diff)
+	}
+
+	// If the stream cannot be resumed, only the call fails.
+	rejectResume.Store(true)
+	resc = callAndBreak()
+	if r := <-resc; r.err == nil {
+		t.Error("CallTool with unresumable stream: got nil error")
+	}
+	release <- struct{}{}
+	if err := session.Ping(ctx, nil); err != nil {
+		t.Errorf("Ping after failed call: %v", err)
+	}
+}
+
 // TestServerInitiatedSSE verifies that the persistent SSE connection remains
 // open and can receive server-initiated events.
+func TestReconnectDelay(t *testing.T) {
+	opts := &StreamableReconnectOptions{GrowFactor: 10, InitialDelay: time.Second, MaxDelay: 30 * time.Second}
+	for _, attempt := range []int{0, 1, 10, 100, 1000} {
+		// The delay is the backoff plus up to as much jitter.
+		if d := calculateReconnectDelay(opts, attempt); d < 0 || d > 2*opts.MaxDelay {
+			t.Errorf("attempt %d: got delay %v, want at most %v", attempt, d, 2*opts.MaxDelay)
+		}
+	}
+
+	for _, o := range []*StreamableReconnectOptions{
+		{GrowFactor: 0.5},
+		{GrowFactor: math.NaN()},
+		{InitialDelay: -time.Second},
+		{MaxDelay: -time.Second},
+	} {
+		transport := NewStreamableClientTransport("http://localhost", &StreamableClientTransportOptions{ReconnectOptions: o})
+		if _, err := transport.Connect(context.Background()); err == nil {
+			t.Errorf("%+v: got nil error", o)
+		}
+	}
+}
+
 func TestServerInitiatedSSE(t *testing.T) {
 	notifications := make(chan string)
 	server := NewServer(testImpl, nil)
@@ -809,3 +922,842 @@ func TestEventID(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamableStateless(t *testing.T) {
+	ctx := context.Background()
+
+	server := NewServer(testImpl, nil)
+	AddTool(server, &Tool{Name: "echo"}, func(_ context.Context, _ *ServerSession, params *CallToolParamsFor[hiParams]) (*CallToolResultFor[any], error) {
+		return &CallToolResultFor[any]{Content: []Content{&TextContent{Text: params.Arguments.Name}}}, nil
+	})
+	// sayHi pings the client, which is not possible without a session.
+	AddTool(server, greetTool(), sayHi)
+
+	// Alternate requests between two handlers, simulating replicas behind a
+	// load balancer.
+	opts := &StreamableHTTPOptions{Stateless: true}
+	replicas := []http.Handler{
+		NewStreamableHTTPHandler(func(*http.Request) *Server { return server }, opts),
+		NewStreamableHTTPHandler(func(*http.Request) *Server { return server }, opts),
+	}
+	var count atomic.Int64
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(sessionIDHeader) != "" {
+			t.Errorf("%s request has session ID", r.Method)
+		}
+		replicas[count.Add(1)%2].ServeHTTP(w, r)
+	}))
+	defer httpServer.Close()
+
+	client := NewClient(testImpl, nil)
+	session, err := client.Connect(ctx, NewStreamableClientTransport(httpServer.URL, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	if id := session.ID(); id != "" {
+		t.Errorf("got session ID %q, want none", id)
+	}
+
+	for range 3 {
+		got, err := session.CallTool(ctx, &CallToolParams{Name: "echo", Arguments: map[string]any{"name": "x"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &CallToolResult{Content: []Content{&TextContent{Text: "x"}}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("CallTool mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	got, err := session.CallTool(ctx, &CallToolParams{Name: "greet", Arguments: map[string]any{"name": "x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsError || !strings.Contains(got.Content[0].(*TextContent).Text, "stateless") {
+		t.Errorf("greet: got %+v, want error about stateless sessions", got)
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		req, err := http.NewRequest(method, httpServer.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got, want := resp.StatusCode, http.StatusMethodNotAllowed; got != want {
+			t.Errorf("%s: got status %d, want %d", method, got, want)
+		}
+	}
+}
+
+func TestStreamableSessionStore(t *testing.T) {
+	// Check that a session created by one handler can be resumed by another
+	// that shares its session store, as after a restart.
+	ctx := context.Background()
+	store, err := NewFileSessionStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newServer := func() *Server {
+		s := NewServer(testImpl, &ServerOptions{
+			SubscribeHandler:   func(context.Context, *SubscribeParams) error { return nil },
+			UnsubscribeHandler: func(context.Context, *UnsubscribeParams) error { return nil },
+		})
+		AddTool(s, &Tool{Name: "log"}, func(ctx context.Context, ss *ServerSession, _ *CallToolParamsFor[map[string]any]) (*CallToolResultFor[any], error) {
+			err := ss.Log(ctx, &LoggingMessageParams{Level: "info", Data: "hello"})
+			return &CallToolResultFor[any]{}, err
+		})
+		return s
+	}
+	server1, server2 := newServer(), newServer()
+	opts := &StreamableHTTPOptions{SessionStore: store}
+	handler1 := NewStreamableHTTPHandler(func(*http.Request) *Server { return server1 }, opts)
+	handler2 := NewStreamableHTTPHandler(func(*http.Request) *Server { return server2 }, opts)
+	defer handler1.closeAll()
+	defer handler2.closeAll()
+
+	var current atomic.Pointer[StreamableHTTPHandler]
+	current.Store(handler1)
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current.Load().ServeHTTP(w, r)
+	}))
+	defer httpServer.Close()
+
+	logs := make(chan *LoggingMessageParams, 10)
+	client := NewClient(testImpl, &ClientOptions{
+		LoggingMessageHandler: func(_ context.Context, _ *ClientSession, p *LoggingMessageParams) { logs <- p },
+	})
+	session, err := client.Connect(ctx, NewStreamableClientTransport(httpServer.URL, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	if err := session.SetLevel(ctx, &SetLevelParams{Level: "debug"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Subscribe(ctx, &SubscribeParams{URI: "file:///info.txt"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Switch to the second handler, which knows nothing about the session
+	// except what is in the store.
+	current.Store(handler2)
+	if _, err := session.CallTool(ctx, &CallToolParams{Name: "log"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case lm := <-logs:
+		if lm.Data != "hello" {
+			t.Errorf("got log data %v, want %q", lm.Data, "hello")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for log message; was the log level restored?")
+	}
+	if id := session.ID(); id == "" {
+		t.Fatal("empty session ID")
+	}
+	server2.mu.Lock()
+	nsubs := len(server2.resourceSubscriptions["file:///info.txt"])
+	server2.mu.Unlock()
+	if nsubs != 1 {
+		t.Errorf("got %d restored subscriptions, want 1", nsubs)
+	}
+	// The restoration was recorded, so that a later one uses other stream IDs.
+	state, err := store.Load(ctx, session.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := state.Incarnation, int64(1); got != want {
+		t.Errorf("got incarnation %d, want %d", got, want)
+	}
+
+	// Unknown sessions are still not found.
+	req, err := http.NewRequest(http.MethodPost, httpServer.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set(sessionIDHeader, "unknown")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusNotFound; got != want {
+		t.Errorf("unknown session: got status %d, want %d", got, want)
+	}
+}
+
+// failingSessionStore is a SessionStore whose operations fail when fail is set.
+type failingSessionStore struct {
+	*MemorySessionStore
+	fail atomic.Bool
+}
+
+var errStoreFailed = errors.New("store failed at /secret/path")
+
+func (s *failingSessionStore) Load(ctx context.Context, id string) (*SessionState, error) {
+	if s.fail.Load() {
+		return nil, errStoreFailed
+	}
+	return s.MemorySessionStore.Load(ctx, id)
+}
+
+func (s *failingSessionStore) Store(ctx context.Context, id string, state *SessionState) error {
+	if s.fail.Load() {
+		return errStoreFailed
+	}
+	return s.MemorySessionStore.Store(ctx, id, state)
+}
+
+func TestStreamableSessionStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	store := &failingSessionStore{MemorySessionStore: NewMemorySessionStore()}
+	server := NewServer(testImpl, &ServerOptions{
+		SubscribeHandler:   func(context.Context, *SubscribeParams) error { return nil },
+		UnsubscribeHandler: func(context.Context, *UnsubscribeParams) error { return nil },
+	})
+	opts := &StreamableHTTPOptions{SessionStore: store}
+	handler1 := NewStreamableHTTPHandler(func(*http.Request) *Server { return server }, opts)
+	handler2 := NewStreamableHTTPHandler(func(*http.Request) *Server { return server }, opts)
+	defer handler1.closeAll()
+	defer handler2.closeAll()
+	httpServer1 := httptest.NewServer(handler1)
+	defer httpServer1.Close()
+	httpServer2 := httptest.NewServer(handler2)
+	defer httpServer2.Close()
+
+	session, err := NewClient(testImpl, nil).Connect(ctx, NewStreamableClientTransport(httpServer1.URL, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	const uri = "file:///info.txt"
+	nsubs := func() int {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return len(server.resourceSubscriptions[uri])
+	}
+
+	// A change that cannot be saved is not kept.
+	store.fail.Store(true)
+	if err := session.Subscribe(ctx, &SubscribeParams{URI: uri}); err == nil {
+		t.Error("Subscribe: got nil error, want a store failure")
+	}
+	if n := nsubs(); n != 0 {
+		t.Errorf("after failed Subscribe: got %d subscriptions, want 0", n)
+	}
+	store.fail.Store(false)
+	if err := session.Subscribe(ctx, &SubscribeParams{URI: uri}); err != nil {
+		t.Fatal(err)
+	}
+	store.fail.Store(true)
+	if err := session.Unsubscribe(ctx, &UnsubscribeParams{URI: uri}); err == nil {
+		t.Error("Unsubscribe: got nil error, want a store failure")
+	}
+	if n := nsubs(); n != 1 {
+		t.Errorf("after failed Unsubscribe: got %d subscriptions, want 1", n)
+	}
+
+	// A failure to load the session is reported without its details.
+	req, err := http.NewRequest(http.MethodPost, httpServer2.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set(sessionIDHeader, session.ID())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError || strings.Contains(string(body), "secret") {
+		t.Errorf("failed load: got status %d, body %q; want 500 without the store error", resp.StatusCode, body)
+	}
+}
+
+func TestStreamableSessionRecovery(t *testing.T) {
+	// Check that the client replaces a session that the server forgets, as
+	// after a restart without a session store.
+	ctx := context.Background()
+	newServer := func() *Server {
+		s := NewServer(testImpl, &ServerOptions{
+			SubscribeHandler:   func(context.Context, *SubscribeParams) error { return nil },
+			UnsubscribeHandler: func(context.Context, *UnsubscribeParams) error { return nil },
+		})
+		AddTool(s, &Tool{Name: "log"}, func(ctx context.Context, ss *ServerSession, _ *CallToolParamsFor[map[string]any]) (*CallToolResultFor[any], error) {
+			err := ss.Log(ctx, &LoggingMessageParams{Level: "info", Data: "hello"})
+			return &CallToolResultFor[any]{}, err
+		})
+		AddTool(s, &Tool{Name: "echo", Annotations: &ToolAnnotations{ReadOnlyHint: true}}, func(_ context.Context, _ *ServerSession, params *CallToolParamsFor[hiParams]) (*CallToolResultFor[any], error) {
+			return &CallToolResultFor[any]{Content: []Content{&TextContent{Text: params.Arguments.Name}}}, nil
+		})
+		AddTool(s, &Tool{Name: "wait"}, func(ctx context.Context, _ *ServerSession, _ *CallToolParamsFor[map[string]any]) (*CallToolResultFor[any], error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		return s
+	}
+	var (
+		current  atomic.Pointer[StreamableHTTPHandler]
+		handlers []*StreamableHTTPHandler
+	)
+	restart := func() *Server {
+		s := newServer()
+		h := NewStreamableHTTPHandler(func(*http.Request) *Server { return s }, nil)
+		handlers = append(handlers, h)
+		current.Store(h)
+		return s
+	}
+	defer func() {
+		for _, h := range handlers {
+			h.closeAll()
+		}
+	}()
+	restart()
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current.Load().ServeHTTP(w, r)
+	}))
+	defer httpServer.Close()
+
+	logs := make(chan *LoggingMessageParams, 10)
+	client := NewClient(testImpl, &ClientOptions{
+		LoggingMessageHandler: func(_ context.Context, _ *ClientSession, p *LoggingMessageParams) { logs <- p },
+	})
+	recovered := make(chan string, 2)
+	transport := NewStreamableClientTransport(httpServer.URL, &StreamableClientTransportOptions{
+		SessionRecovery: &StreamableSessionRecoveryOptions{
+			RecoveredHandler: func(sessionID string, res *InitializeResult) { recovered <- sessionID },
+		},
+	})
+	session, err := client.Connect(ctx, transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	if err := session.SetLevel(ctx, &SetLevelParams{Level: "debug"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Subscribe(ctx, &SubscribeParams{URI: "file:///info.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.ListTools(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	waitRecovered := func(oldID string) {
+		t.Helper()
+		select {
+		case id := <-recovered:
+			if id == "" || id == oldID {
+				t.Errorf("recovered with session ID %q, want a new one", id)
+			}
+			if got := session.ID(); got != id {
+				t.Errorf("session ID is %q, want %q", got, id)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for recovery")
+		}
+	}
+
+	// A call that may not be idempotent fails, but the session is replaced.
+	oldID := session.ID()
+	server2 := restart()
+	if _, err := session.CallTool(ctx, &CallToolParams{Name: "log"}); err == nil {
+		t.Error("log after restart: got nil error, want failure")
+	}
+	waitRecovered(oldID)
+	if _, err := session.CallTool(ctx, &CallToolParams{Name: "log"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case lm := <-logs:
+		if lm.Data != "hello" {
+			t.Errorf("got log data %v, want %q", lm.Data, "hello")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for log message; was the log level restored?")
+	}
+	server2.mu.Lock()
+	nsubs := len(server2.resourceSubscriptions["file:///info.txt"])
+	server2.mu.Unlock()
+	if nsubs != 1 {
+		t.Errorf("got %d restored subscriptions, want 1", nsubs)
+	}
+
+	// A call to a read-only tool is retried in the new session.
+	oldID = session.ID()
+	restart()
+	got, err := session.CallTool(ctx, &CallToolParams{Name: "echo", Arguments: map[string]any{"name": "x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &CallToolResult{Content: []Content{&TextContent{Text: "x"}}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("CallTool mismatch (-want +got):\n%s", diff)
+	}
+	waitRecovered(oldID)
+
+	// A cancelled call is forgotten, so it is not failed or retried later.
+	cctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := session.CallTool(cctx, &CallToolParams{Name: "wait"}); err == nil {
+		t.Fatal("cancelled call: got nil error")
+	}
+	conn := session.mcpConn.(*streamableClientConn)
+	conn.mu.Lock()
+	npending := len(conn.pending)
+	conn.mu.Unlock()
+	if npending != 0 {
+		t.Errorf("after cancellation: got %d pending calls, want 0", npending)
+	}
+}
+
+// closeRecordingEventStore is an EventStore that reports closed sessions
+// and streams.
+type closeRecordingEventStore struct {
+	*MemoryEventStore
+	closed        chan string
+	closedStreams chan StreamID
+}
+
+func (s *closeRecordingEventStore) SessionClosed(ctx context.Context, sessionID string) error {
+	s.closed <- sessionID
+	return s.MemoryEventStore.SessionClosed(ctx, sessionID)
+}
+
+func (s *closeRecordingEventStore) StreamClosed(ctx context.Context, sessionID string, streamID StreamID) error {
+	s.closedStreams <- streamID
+	return s.MemoryEventStore.StreamClosed(ctx, sessionID, streamID)
+}
+
+func TestStreamableSessionLimits(t *testing.T) {
+	// initialize sends an initialize request to url, returning the response
+	// status, session ID and Retry-After header.
+	initialize := func(t *testing.T, url string) (status int, sessionID, retryAfter string) {
+		t.Helper()
+		body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`
+		req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode, resp.Header.Get(sessionIDHeader), resp.Header.Get("Retry-After")
+	}
+
+	for _, test := range []struct {
+		name string
+		opts StreamableHTTPOptions
+	}{
+		{"idle", StreamableHTTPOptions{SessionIdleTimeout: 50 * time.Millisecond}},
+		{"lifetime", StreamableHTTPOptions{SessionMaxLifetime: 50 * time.Millisecond}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			events := &closeRecordingEventStore{NewMemoryEventStore(nil), make(chan string, 1), make(chan StreamID, 10)}
+			sessions := NewMemorySessionStore()
+			test.opts.EventStore = events
+			test.opts.SessionStore = sessions
+			server := NewServer(testImpl, nil)
+			handler := NewStreamableHTTPHandler(func(*http.Request) *Server { return server }, &test.opts)
+			defer handler.closeAll()
+			httpServer := httptest.NewServer(handler)
+			defer httpServer.Close()
+
+			status, id, _ := initialize(t, httpServer.URL)
+			if status != http.StatusOK || id == "" {
+				t.Fatalf("initialize: got status %d, session ID %q", status, id)
+			}
+			// The session is closed, and deleted from the stores, so that no
+			// process can restore it.
+			select {
+			case got := <-events.closed:
+				if got != id {
+					t.Errorf("event store informed that session %q is closed, want %q", got, id)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for session to expire")
+			}
+			if _, err := sessions.Load(context.Background(), id); !errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("loading expired session: got %v, want ErrSessionNotFound", err)
+			}
+			status, err := ping(httpServer.URL, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != http.StatusNotFound {
+				t.Errorf("ping after expiry: got status %d, want %d", status, http.StatusNotFound)
+			}
+		})
+	}
+
+	t.Run("max sessions", func(t *testing.T) {
+		server := NewServer(testImpl, nil)
+		handler := NewStreamableHTTPHandler(func(*http.Request) *Server { return server }, &StreamableHTTPOptions{
+			MaxSessions:        1,
+			SessionIdleTimeout: 90 * time.Second,
+		})
+		defer handler.closeAll()
+		httpServer := httptest.NewServer(handler)
+		defer httpServer.Close()
+
+		if status, _, _ := initialize(t, httpServer.URL); status != http.StatusOK {
+			t.Fatalf("first session: got status %d, want %d", status, http.StatusOK)
+		}
+		status, _, retryAfter := initialize(t, httpServer.URL)
+		if status != http.StatusServiceUnavailable {
+			t.Fatalf("second session: got status %d, want %d", status, http.StatusServiceUnavailable)
+		}
+		if retryAfter != "90" {
+			t.Errorf("got Retry-After %q, want %q", retryAfter, "90")
+		}
+	})
+
+	t.Run("max sessions restored", func(t *testing.T) {
+		ctx := context.Background()
+		sessions := NewMemorySessionStore()
+		if err := sessions.Store(ctx, "stored", &SessionState{ProtocolVersion: "2025-03-26"}); err != nil {
+			t.Fatal(err)
+		}
+		server := NewServer(testImpl, nil)
+		handler := NewStreamableHTTPHandler(func(*http.Request) *Server { return server }, &StreamableHTTPOptions{
+			MaxSessions:  1,
+			SessionStore: sessions,
+		})
+		defer handler.closeAll()
+		httpServer := httptest.NewServer(handler)
+		defer httpServer.Close()
+
+		if status, _, _ := initialize(t, httpServer.URL); status != http.StatusOK {
+			t.Fatalf("first session: got status %d, want %d", status, http.StatusOK)
+		}
+		// Restoring a session counts against the limit.
+		status, err := ping(httpServer.URL, "stored")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != http.StatusServiceUnavailable {
+			t.Errorf("restored session: got status %d, want %d", status, http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// ping sends a ping request for the given session to url, and returns the
+// response status.
+func ping(url, sessionID string) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"ping"}`))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set(sessionIDHeader, sessionID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func TestStreamRetention(t *testing.T) {
+	ctx := context.Background()
+	events := &closeRecordingEventStore{NewMemoryEventStore(nil), make(chan string, 1), make(chan StreamID, 10)}
+	server := NewServer(testImpl, nil)
+	handler := NewStreamableHTTPHandler(func(*http.Request) *Server { return server }, &StreamableHTTPOptions{
+		EventStore:      events,
+		StreamRetention: 50 * time.Millisecond,
+	})
+	defer handler.closeAll()
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	post := func(sessionID, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, httpServer.URL, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if sessionID != "" {
+			req.Header.Set(sessionIDHeader, sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp
+	}
+	resp := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`)
+	sessionID := resp.Header.Get(sessionIDHeader)
+	post(sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	post(sessionID, `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+
+	handler.sessionsMu.Lock()
+	transport := handler.sessions[sessionID].transport
+	handler.sessionsMu.Unlock()
+	liveStreams := func() []StreamID {
+		transport.mu.Lock()
+		defer transport.mu.Unlock()
+		if len(transport.requestStreams) > 0 {
+			t.Errorf("got %d request streams, want 0", len(transport.requestStreams))
+		}
+		return slices.Sorted(maps.Keys(transport.streams))
+	}
+
+	// Both request streams are retained for replay. The notification didn't
+	// create a stream.
+	if diff := cmp.Diff([]StreamID{0, 1, 3}, liveStreams()); diff != "" {
+		t.Errorf("streams before retention period mismatch (-want +got):\n%s", diff)
+	}
+	var closed []StreamID
+	for range 2 {
+		select {
+		case id := <-events.closedStreams:
+			closed = append(closed, id)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for streams to be purged")
+		}
+	}
+	slices.Sort(closed)
+	if diff := cmp.Diff([]StreamID{1, 3}, closed); diff != "" {
+		t.Errorf("closed streams mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]StreamID{0}, liveStreams()); diff != "" {
+		t.Errorf("streams after retention period mismatch (-want +got):\n%s", diff)
+	}
+
+	// Replaying a purged stream fails cleanly.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, httpServer.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(sessionIDHeader, sessionID)
+	req.Header.Set("Last-Event-ID", formatEventID(3, 0))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInsufficientStorage || !strings.Contains(string(body), ErrEventsPurged.Error()) {
+		t.Errorf("replaying purged stream: got status %d, body %q; want %d and %q",
+			resp.StatusCode, body, http.StatusInsufficientStorage, ErrEventsPurged)
+	}
+
+	// A stream is retained until its final response is stored, however long
+	// the client takes to read it.
+	release := make(chan struct{})
+	AddTool(server, &Tool{Name: "slow"}, func(ctx context.Context, ss *ServerSession, _ *CallToolParamsFor[map[string]any]) (*CallToolResultFor[any], error) {
+		if err := ss.NotifyProgress(ctx, &ProgressNotificationParams{ProgressToken: "p", Progress: 1}); err != nil {
+			return nil, err
+		}
+		<-release
+		return &CallToolResultFor[any]{}, nil
+	})
+	postCtx, cancelPost := context.WithCancel(ctx)
+	req, err = http.NewRequestWithContext(postCtx, http.MethodPost, httpServer.URL,
+		strings.NewReader(`{"jsonrpc":"2.0","id":10,"method":"tools/call","params":{"name":"slow"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set(sessionIDHeader, sessionID)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lastEventID string
+	for evt, err := range scanEvents(resp.Body) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		lastEventID = evt.ID
+		break
+	}
+	// The client goes away before the response is sent.
+	cancelPost()
+	resp.Body.Close()
+	slowID, _, _ := parseEventID(lastEventID)
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		transport.mu.Lock()
+		reading := transport.streams[slowID].signal.Load() != nil
+		transport.mu.Unlock()
+		if !reading {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the POST to end")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(release)
+	time.Sleep(4 * 50 * time.Millisecond)
+
+	getCtx, cancelGet := context.WithCancel(ctx)
+	defer cancelGet()
+	req, err = http.NewRequestWithContext(getCtx, http.MethodGet, httpServer.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(sessionIDHeader, sessionID)
+	req.Header.Set("Last-Event-ID", lastEventID)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("resuming slow stream: got status %d, body %q", resp.StatusCode, body)
+	}
+	for evt, err := range scanEvents(resp.Body) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(evt.Data), `"id":10`) {
+			t.Errorf("resuming slow stream: got %s, want the response", evt.Data)
+		}
+		break
+	}
+}
+
+func TestStreamableBearerToken(t *testing.T) {
+	ctx := context.Background()
+	issuer := oauthtest.NewIssuer("https://auth.example.com")
+	keys, err := auth.ParseKeySet(issuer.JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(testImpl, nil)
+	AddTool(server, &Tool{Name: "whoami"}, func(ctx context.Context, _ *ServerSession, _ *CallToolParamsFor[map[string]any]) (*CallToolResultFor[any], error) {
+		info := auth.TokenInfoFromContext(ctx)
+		if info == nil {
+			return nil, errors.New("no token info")
+		}
+		return &CallToolResultFor[any]{Content: []Content{&TextContent{Text: info.Subject}}}, nil
+	})
+	const resource = "https://example.com/mcp"
+	verifier := auth.NewJWTVerifier(keys, &auth.JWTVerifierOptions{Issuer: issuer.URL, Audience: resource})
+	handler := NewStreamableHTTPHandler(func(*http.Request) *Server { return server }, nil)
+	defer handler.closeAll()
+	httpServer := httptest.NewServer(auth.RequireBearerToken(verifier, nil)(handler))
+	defer httpServer.Close()
+
+	// Add a bearer token to each request.
+	token := issuer.Token(map[string]any{"sub": "alice", "aud": resource})
+	httpClient := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+token)
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	client := NewClient(testImpl, nil)
+	session, err := client.Connect(ctx, NewStreamableClientTransport(httpServer.URL, &StreamableClientTransportOptions{HTTPClient: httpClient}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	res, err := session.CallTool(ctx, &CallToolParams{Name: "whoami"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("tool failed: %v", res.Content[0].(*TextContent).Text)
+	}
+	if got := res.Content[0].(*TextContent).Text; got != "alice" {
+		t.Errorf("got subject %q, want %q", got, "alice")
+	}
+
+	// Without a token, the client can't connect.
+	if _, err := NewClient(testImpl, nil).Connect(ctx, NewStreamableClientTransport(httpServer.URL, nil)); err == nil {
+		t.Error("connecting without a token: got nil error")
+	}
+
+	// Another user's token can't be used with the session.
+	req, err := http.NewRequest(http.MethodPost, httpServer.URL, strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"ping"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set(sessionIDHeader, session.ID())
+	req.Header.Set("Authorization", "Bearer "+issuer.Token(map[string]any{"sub": "mallory", "aud": resource}))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusForbidden; got != want {
+		t.Errorf("other user's token: got status %d, want %d", got, want)
+	}
+}
+
+func TestStreamableOAuth(t *testing.T) {
+	ctx := context.Background()
+	as := oauthtest.NewAuthServer()
+	defer as.Close()
+	keys, err := auth.ParseKeySet(as.JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(testImpl, nil)
+	AddTool(server, &Tool{Name: "whoami"}, func(ctx context.Context, _ *ServerSession, _ *CallToolParamsFor[map[string]any]) (*CallToolResultFor[any], error) {
+		return &CallToolResultFor[any]{Content: []Content{&TextContent{Text: auth.TokenInfoFromContext(ctx).Subject}}}, nil
+	})
+	handler := NewStreamableHTTPHandler(func(*http.Request) *Server { return server }, nil)
+	defer handler.closeAll()
+
+	mux := http.NewServeMux()
+	httpServer := httptest.NewServer(mux)
+	defer httpServer.Close()
+	resource := httpServer.URL + "/mcp"
+	verifier := auth.NewJWTVerifier(keys, &auth.JWTVerifierOptions{Issuer: as.URL, Audience: resource})
+	mux.Handle("/mcp", auth.RequireBearerToken(verifier, &auth.RequireBearerTokenOptions{
+		ResourceMetadataURL: httpServer.URL + auth.ProtectedResourceMetadataPath + "/mcp",
+	})(handler))
+	mux.Handle(auth.ProtectedResourceMetadataPath+"/mcp", auth.ProtectedResourceMetadataHandler(&auth.ProtectedResourceMetadata{
+		Resource:             resource,
+		AuthorizationServers: []string{as.URL},
+	}))
+
+	as.Subject = "alice"
+	client := NewClient(testImpl, nil)
+	session, err := client.Connect(ctx, NewStreamableClientTransport(resource, &StreamableClientTransportOptions{
+		OAuth: &auth.OAuthConfig{
+			Authorize:   as.Authorize,
+			RedirectURL: "http://localhost/callback",
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	res, err := session.CallTool(ctx, &CallToolParams{Name: "whoami"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Content[0].(*TextContent).Text; got != "alice" {
+		t.Errorf("got subject %q, want %q", got, "alice")
+	}
+	if got := as.Stats().Authorizations; got != 1 {
+		t.Errorf("got %d authorizations, want 1", got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 
@@ -46,6 +47,14 @@ func newServerTool[In, Out any](t *Tool, h ToolHandlerFor[In, Out]) (*serverTool
 		if err := setSchema[Out](&t.OutputSchema, &st.outputResolved); err != nil {
 			return nil, err
 		}
+	} else if t.OutputSchema != nil {
+		// The handler is untyped, but the tool author supplied an output schema.
+		// Resolve it so we can validate results against it.
+		var err error
+		st.outputResolved, err = t.OutputSchema.Resolve(&jsonschema.ResolveOptions{ValidateDefaults: true})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	st.handler = func(ctx context.Context, ss *ServerSession, rparams *CallToolParamsFor[json.RawMessage]) (*CallToolResult, error) {
@@ -72,7 +81,6 @@ func newServerTool[In, Out any](t *Tool, h ToolHandlerFor[In, Out]) (*serverTool
 		}
 		var ctr CallToolResult
 		// TODO(jba): What if res == nil? Is that valid?
-		// TODO(jba): if t.OutputSchema != nil, check that StructuredContent is present and validates.
 		if res != nil {
 			// TODO(jba): future-proof this copy.
 			ctr.Meta = res.Meta
@@ -80,12 +88,50 @@ func newServerTool[In, Out any](t *Tool, h ToolHandlerFor[In, Out]) (*serverTool
 			ctr.IsError = res.IsError
 			ctr.StructuredContent = res.StructuredContent
 		}
+		// Results reporting an error need not conform to the output schema.
+		if st.outputResolved != nil && !ctr.IsError {
+			if err := setStructuredContent(&ctr, st.outputResolved); err != nil {
+				return &CallToolResult{
+					Content: []Content{&TextContent{Text: fmt.Sprintf("tool %q: invalid structured content: %v", t.Name, err)}},
+					IsError: true,
+				}, nil
+			}
+		}
 		return &ctr, nil
 	}
 
 	return st, nil
 }
 
+// setStructuredContent validates the structured content of res against resolved,
+// replacing it with its JSON value.
+// If res has no content, setStructuredContent also adds a TextContent holding
+// the serialized structured content, for clients that do not understand
+// structured content.
+func setStructuredContent(res *CallToolResult, resolved *jsonschema.Resolved) error {
+	data, err := json.Marshal(res.StructuredContent)
+	if err != nil {
+		return fmt.Errorf("marshaling: %w", err)
+	}
+	if string(data) == "null" {
+		return errors.New("missing")
+	}
+	// Validate the JSON value rather than the Go value, so that the check
+	// matches what the client will see.
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("unmarshaling: %w", err)
+	}
+	if err := resolved.Validate(v); err != nil {
+		return err
+	}
+	res.StructuredContent = v
+	if len(res.Content) == 0 {
+		res.Content = []Content{&TextContent{Text: string(data)}}
+	}
+	return nil
+}
+
 func setSchema[T any](sfield **jsonschema.Schema, rfield **jsonschema.Resolved) error {
 	var err error
 	if *sfield == nil {
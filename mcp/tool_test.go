@@ -8,6 +8,7 @@ import (
 	"context"
 	"encoding/json"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -132,3 +133,73 @@ func TestUnmarshalSchema(t *testing.T) {
 
 	}
 }
+
+func TestToolStructuredContent(t *testing.T) {
+	type Out struct {
+		Size int `json:"size"`
+	}
+	ctx := context.Background()
+	call := func(st *serverTool) *CallToolResult {
+		t.Helper()
+		res, err := st.handler(ctx, nil, &CallToolParamsFor[json.RawMessage]{Name: st.tool.Name, Arguments: json.RawMessage("{}")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	// A typed handler gets its structured content and a text fallback populated.
+	typed := srvTool(t, &Tool{Name: "typed"}, func(context.Context, *ServerSession, *CallToolParamsFor[map[string]any]) (*CallToolResultFor[Out], error) {
+		return &CallToolResultFor[Out]{StructuredContent: Out{Size: 3}}, nil
+	})
+	want := &CallToolResult{
+		Content:           []Content{&TextContent{Text: `{"size":3}`}},
+		StructuredContent: map[string]any{"size": 3.0},
+	}
+	if diff := cmp.Diff(want, call(typed)); diff != "" {
+		t.Errorf("typed mismatch (-want +got):\n%s", diff)
+	}
+
+	// Existing content is left alone.
+	withContent := srvTool(t, &Tool{Name: "content"}, func(context.Context, *ServerSession, *CallToolParamsFor[map[string]any]) (*CallToolResultFor[Out], error) {
+		return &CallToolResultFor[Out]{Content: []Content{&TextContent{Text: "three"}}, StructuredContent: Out{Size: 3}}, nil
+	})
+	if got := call(withContent).Content; len(got) != 1 || got[0].(*TextContent).Text != "three" {
+		t.Errorf("content: got %v, want original content", got)
+	}
+
+	// Structured content that violates the schema, or is missing, is an error result.
+	schema := &jsonschema.Schema{
+		Type:       "object",
+		Properties: map[string]*jsonschema.Schema{"size": {Type: "integer", Minimum: jsonschema.Ptr(0.0)}},
+		Required:   []string{"size"},
+	}
+	for _, test := range []struct {
+		name    string
+		content any
+		want    string
+	}{
+		{"invalid", map[string]any{"size": -1}, "minimum"},
+		{"missing", nil, "missing"},
+	} {
+		st := srvTool(t, &Tool{Name: test.name, InputSchema: &jsonschema.Schema{}, OutputSchema: schema}, func(context.Context, *ServerSession, *CallToolParamsFor[map[string]any]) (*CallToolResult, error) {
+			return &CallToolResult{StructuredContent: test.content}, nil
+		})
+		res := call(st)
+		if !res.IsError {
+			t.Fatalf("%s: got success, want error result", test.name)
+		}
+		text := res.Content[0].(*TextContent).Text
+		if !strings.Contains(text, "invalid structured content") || !strings.Contains(text, test.want) {
+			t.Errorf("%s: got %q, want invalid structured content mentioning %q", test.name, text, test.want)
+		}
+	}
+
+	// Error results are not validated.
+	isErr := srvTool(t, &Tool{Name: "error"}, func(context.Context, *ServerSession, *CallToolParamsFor[map[string]any]) (*CallToolResultFor[*Out], error) {
+		return &CallToolResultFor[*Out]{IsError: true, Content: []Content{&TextContent{Text: "failed"}}}, nil
+	})
+	if res := call(isErr); !res.IsError || res.Content[0].(*TextContent).Text != "failed" {
+		t.Errorf("error result was modified: %+v", res)
+	}
+}
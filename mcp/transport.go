@@ -12,9 +12,11 @@ import (
 	"io"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"sync"
 
+	"github.com/modelcontextprotocol/go-sdk/auth"
 	"github.com/modelcontextprotocol/go-sdk/internal/jsonrpc2"
 	"github.com/modelcontextprotocol/go-sdk/internal/xcontext"
 	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
@@ -85,6 +87,51 @@ func NewInMemoryTransports() (*InMemoryTransport, *InMemoryTransport) {
 	return &InMemoryTransport{ioTransport{c1}}, &InMemoryTransport{ioTransport{c2}}
 }
 
+// A DialTransport is a [Transport] that dials a network address and
+// communicates over the connection using newline-delimited JSON.
+// Its counterpart is [Server.Serve].
+type DialTransport struct {
+	dialer jsonrpc2.Dialer
+}
+
+// NewDialTransport returns a transport that connects to the address on the
+// named network, as with [net.Dial]. For example, it may dial "tcp" or "unix".
+func NewDialTransport(network, address string) *DialTransport {
+	return &DialTransport{jsonrpc2.NetDialer(network, address, net.Dialer{})}
+}
+
+// Connect dials the address.
+func (t *DialTransport) Connect(ctx context.Context) (Connection, error) {
+	rwc, err := t.dialer.Dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newIOConn(rwc), nil
+}
+
+// A tokenInfoConn is a [Connection] that knows the bearer tokens of the
+// HTTP requests that carried incoming JSON-RPC requests.
+type tokenInfoConn interface {
+	// tokenInfo returns information about the token of the HTTP request that
+	// carried the JSON-RPC request with the given ID, or nil if there is none.
+	tokenInfo(jsonrpc.ID) *auth.TokenInfo
+}
+
+// oauthClient returns a copy of c that authorizes requests to the MCP server
+// at serverURL as configured by config. If config is nil, it returns c.
+func oauthClient(c *http.Client, config *auth.OAuthConfig, serverURL string) *http.Client {
+	if config == nil {
+		return c
+	}
+	cfg := *config
+	if cfg.Resource == "" {
+		cfg.Resource = serverURL
+	}
+	c2 := *c
+	c2.Transport = auth.NewOAuthTransport(c.Transport, &cfg)
+	return &c2
+}
+
 type binder[T handler] interface {
 	bind(*jsonrpc2.Connection) T
 	disconnect(T)
@@ -7,6 +7,7 @@ package mcp
 import (
 	"context"
 	"io"
+	"net"
 	"testing"
 
 	"github.com/modelcontextprotocol/go-sdk/internal/jsonrpc2"
@@ -51,3 +52,20 @@ func TestBatchFraming(t *testing.T) {
 		}
 	}
 }
+
+func TestRestartingConnWriteError(t *testing.T) {
+	// A call whose write fails is not pending: a restart must not answer it.
+	c1, c2 := net.Pipe()
+	c2.Close()
+	c := &restartingConn{conn: newIOConn(c1), pending: make(map[jsonrpc.ID]bool)}
+	req, err := jsonrpc2.NewCall(jsonrpc2.Int64ID(1), "ping", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Write(context.Background(), req); err == nil {
+		t.Fatal("Write to a closed pipe succeeded")
+	}
+	if len(c.pending) != 0 {
+		t.Errorf("got pending calls %v, want none", c.pending)
+	}
+}
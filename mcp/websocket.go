@@ -0,0 +1,271 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/internal/jsonrpc2"
+	"github.com/modelcontextprotocol/go-sdk/internal/websocket"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+// This file implements a transport that carries MCP sessions over
+// WebSockets (RFC 6455). It is not part of the MCP spec.
+//
+// Each session is a single WebSocket connection, which must negotiate the
+// "mcp" subprotocol. Each JSON-RPC message is sent as a single text message.
+// Binary messages are rejected. Either side may send pings to detect a dead
+// peer, and sessions end with a close handshake.
+
+// websocketSubprotocol is the WebSocket subprotocol for MCP.
+const websocketSubprotocol = "mcp"
+
+// Defaults for WebSocket options.
+const (
+	defaultWebSocketPingInterval   = 30 * time.Second
+	defaultWebSocketMaxMessageSize = 32 << 20
+)
+
+// WebSocketHandler is an http.Handler that serves MCP sessions over
+// WebSockets.
+type WebSocketHandler struct {
+	getServer    func(*http.Request) *Server
+	opts         WebSocketHandlerOptions
+	onConnection func(*ServerSession) // for testing; must not block
+}
+
+// WebSocketHandlerOptions are options for the [NewWebSocketHandler]
+// constructor.
+type WebSocketHandlerOptions struct {
+	// PingInterval is the interval between pings sent to the client.
+	// If the client does not reply to a ping before the next one is due, the
+	// session is closed.
+	// If zero, it is 30 seconds. If negative, no pings are sent.
+	PingInterval time.Duration
+	// MaxMessageSize is the maximum size of an incoming message, in bytes.
+	// A larger message closes the session.
+	// If zero, it is 32 MiB. If negative, there is no limit.
+	MaxMessageSize int64
+	// CheckOrigin reports whether a request to upgrade to a WebSocket should
+	// be accepted, to protect against cross-site WebSocket hijacking.
+	// If nil, requests are accepted only if they have no Origin header or the
+	// host of the origin is the host of the request.
+	CheckOrigin func(*http.Request) bool
+}
+
+// NewWebSocketHandler returns a new [WebSocketHandler] that serves a session
+// on each WebSocket connection.
+//
+// For each request to upgrade to a WebSocket, the handler calls getServer
+// and connects the server it returns to the WebSocket. The getServer function
+// may return a distinct [Server] for each new request, or reuse an existing
+// server. If it returns nil, the handler will return a 400 Bad Request.
+//
+// If opts is nil, the default options are used.
+func NewWebSocketHandler(getServer func(*http.Request) *Server, opts *WebSocketHandlerOptions) *WebSocketHandler {
+	h := &WebSocketHandler{getServer: getServer}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.CheckOrigin == nil {
+		h.opts.CheckOrigin = sameOrigin
+	}
+	return h
+}
+
+// sameOrigin reports whether req has no Origin header or an origin whose
+// host is the host of req.
+func sameOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host == req.Host
+}
+
+func (h *WebSocketHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !websocket.IsUpgradeRequest(req) {
+		w.Header().Set("Upgrade", "websocket")
+		http.Error(w, "expected a websocket handshake", http.StatusUpgradeRequired)
+		return
+	}
+	if !h.opts.CheckOrigin(req) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	server := h.getServer(req)
+	if server == nil {
+		// The getServer argument to NewWebSocketHandler returned nil.
+		http.Error(w, "no server available", http.StatusBadRequest)
+		return
+	}
+	ws, err := websocket.Upgrade(w, req, []string{websocketSubprotocol})
+	if err != nil {
+		// Upgrade has written the response.
+		return
+	}
+	conn := newWebSocketConn(ws, h.opts.PingInterval, h.opts.MaxMessageSize)
+	// The request context is not canceled when the connection is hijacked,
+	// so the session lasts until the WebSocket is closed.
+	ss, err := server.Connect(context.WithoutCancel(req.Context()), &websocketTransport{conn})
+	if err != nil {
+		ws.Close(websocket.StatusInternalError, "connection failed")
+		return
+	}
+	if h.onConnection != nil {
+		h.onConnection(ss)
+	}
+	ss.Wait()
+}
+
+// websocketTransport is a [Transport] for an established WebSocket.
+type websocketTransport struct {
+	conn *websocketConn
+}
+
+func (t *websocketTransport) Connect(context.Context) (Connection, error) {
+	return t.conn, nil
+}
+
+// A WebSocketClientTransport is a [Transport] that connects to an MCP
+// server served by a [WebSocketHandler].
+type WebSocketClientTransport struct {
+	url  string
+	opts WebSocketClientTransportOptions
+}
+
+// WebSocketClientTransportOptions provides options for the
+// [NewWebSocketClientTransport] constructor.
+type WebSocketClientTransportOptions struct {
+	// HTTPClient is the client to use for the opening handshake. If nil,
+	// http.DefaultClient is used. It must not have a timeout.
+	HTTPClient *http.Client
+	// Header holds additional headers for the opening handshake, such as
+	// Authorization.
+	Header http.Header
+	// PingInterval is the interval between pings sent to the server.
+	// If the server does not reply to a ping before the next one is due, the
+	// session is closed.
+	// If zero, it is 30 seconds. If negative, no pings are sent.
+	PingInterval time.Duration
+	// MaxMessageSize is the maximum size of an incoming message, in bytes.
+	// A larger message closes the session.
+	// If zero, it is 32 MiB. If negative, there is no limit.
+	MaxMessageSize int64
+}
+
+// NewWebSocketClientTransport returns a new client transport that connects
+// to the WebSocket MCP server at the provided URL, whose scheme may be "ws",
+// "wss", "http" or "https".
+//
+// If opts is nil, the default options are used.
+func NewWebSocketClientTransport(url string, opts *WebSocketClientTransportOptions) *WebSocketClientTransport {
+	t := &WebSocketClientTransport{url: url}
+	if opts != nil {
+		t.opts = *opts
+	}
+	return t
+}
+
+// Connect performs the WebSocket opening handshake.
+func (t *WebSocketClientTransport) Connect(ctx context.Context) (Connection, error) {
+	client := t.opts.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	ws, err := websocket.Dial(ctx, client, t.url, t.opts.Header, []string{websocketSubprotocol})
+	if err != nil {
+		return nil, err
+	}
+	return newWebSocketConn(ws, t.opts.PingInterval, t.opts.MaxMessageSize), nil
+}
+
+// websocketConn is a [Connection] over a WebSocket.
+type websocketConn struct {
+	ws *websocket.Conn
+}
+
+// newWebSocketConn returns a connection over ws, which sends pings at the
+// given interval and limits incoming messages to maxSize bytes, with the
+// defaults described in [WebSocketHandlerOptions].
+func newWebSocketConn(ws *websocket.Conn, pingInterval time.Duration, maxSize int64) *websocketConn {
+	if maxSize == 0 {
+		maxSize = defaultWebSocketMaxMessageSize
+	}
+	if maxSize > 0 {
+		ws.SetReadLimit(maxSize)
+	}
+	if pingInterval == 0 {
+		pingInterval = defaultWebSocketPingInterval
+	}
+	if pingInterval > 0 {
+		go ws.KeepAlive(pingInterval)
+	}
+	return &websocketConn{ws: ws}
+}
+
+func (c *websocketConn) SessionID() string { return "" }
+
+// Read implements jsonrpc2.Reader.
+//
+// Since reads from a WebSocket cannot be interrupted, cancellation of ctx is
+// only observed before a read begins; closing the connection ends a pending
+// read.
+func (c *websocketConn) Read(ctx context.Context) (jsonrpc.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	typ, data, err := c.ws.ReadMessage()
+	if err != nil {
+		var cerr *websocket.CloseError
+		if errors.Is(err, websocket.ErrClosed) ||
+			errors.As(err, &cerr) && (cerr.Code == websocket.StatusNormalClosure || cerr.Code == websocket.StatusGoingAway || cerr.Code == websocket.StatusNoStatus) {
+			// The session ended normally.
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	if typ != websocket.TextMessage {
+		// Don't wait for the peer to acknowledge the close: only this goroutine
+		// could read the acknowledgement.
+		c.ws.Abort(websocket.StatusUnsupportedData, "binary messages are not supported")
+		return nil, fmt.Errorf("received a binary message")
+	}
+	return jsonrpc2.DecodeMessage(data)
+}
+
+// Write implements jsonrpc2.Writer.
+func (c *websocketConn) Write(ctx context.Context, msg jsonrpc.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := jsonrpc2.EncodeMessage(msg)
+	if err != nil {
+		return err
+	}
+	if err := c.ws.WriteMessage(websocket.TextMessage, data); err != nil {
+		if errors.Is(err, websocket.ErrClosed) {
+			return io.EOF
+		}
+		return err
+	}
+	return nil
+}
+
+// Close implements io.Closer, and ends the session with a normal closure.
+func (c *websocketConn) Close() error {
+	return c.ws.Close(websocket.StatusNormalClosure, "")
+}
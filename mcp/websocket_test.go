@@ -0,0 +1,131 @@
+// Copyright 2025 The Go MCP SDK Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modelcontextprotocol/go-sdk/internal/websocket"
+)
+
+func TestWebSocketServer(t *testing.T) {
+	ctx := context.Background()
+	server := NewServer(testImpl, nil)
+	AddTool(server, &Tool{Name: "greet"}, sayHi)
+
+	handler := NewWebSocketHandler(func(*http.Request) *Server { return server }, &WebSocketHandlerOptions{
+		PingInterval: 10 * time.Millisecond,
+	})
+	serverSessions := make(chan *ServerSession, 1)
+	handler.onConnection = func(ss *ServerSession) {
+		select {
+		case serverSessions <- ss:
+		default:
+		}
+	}
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	transport := NewWebSocketClientTransport(httpServer.URL, &WebSocketClientTransportOptions{
+		PingInterval: 10 * time.Millisecond,
+	})
+	c := NewClient(testImpl, nil)
+	cs, err := c.Connect(ctx, transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := <-serverSessions
+	gotHi, err := cs.CallTool(ctx, &CallToolParams{
+		Name:      "greet",
+		Arguments: map[string]any{"Name": "user"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantHi := &CallToolResult{
+		Content: []Content{
+			&TextContent{Text: "hi user"},
+		},
+	}
+	if diff := cmp.Diff(wantHi, gotHi); diff != "" {
+		t.Errorf("tools/call 'greet' mismatch (-want +got):\n%s", diff)
+	}
+
+	// Several keepalive intervals pass without ending the session.
+	time.Sleep(50 * time.Millisecond)
+	if err := cs.Ping(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing the client session ends the server session cleanly.
+	if err := cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ss.Wait(); err != nil {
+		t.Errorf("server session ended with %v, want nil", err)
+	}
+}
+
+func TestWebSocketHandlerRejects(t *testing.T) {
+	server := NewServer(testImpl, nil)
+	handler := NewWebSocketHandler(func(*http.Request) *Server { return server }, nil)
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	// A request that is not a handshake.
+	resp, err := http.Get(httpServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusUpgradeRequired; got != want {
+		t.Errorf("plain GET: got status %d, want %d", got, want)
+	}
+
+	// A handshake from another origin.
+	transport := NewWebSocketClientTransport(httpServer.URL, &WebSocketClientTransportOptions{
+		Header: http.Header{"Origin": {"https://evil.example.com"}},
+	})
+	if _, err := NewClient(testImpl, nil).Connect(context.Background(), transport); err == nil {
+		t.Error("cross-origin handshake: got nil error")
+	}
+}
+
+func TestWebSocketBinaryMessage(t *testing.T) {
+	server := NewServer(testImpl, nil)
+	handler := NewWebSocketHandler(func(*http.Request) *Server { return server }, nil)
+	serverSessions := make(chan *ServerSession, 1)
+	handler.onConnection = func(ss *ServerSession) { serverSessions <- ss }
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	ws, err := websocket.Dial(context.Background(), http.DefaultClient, httpServer.URL, nil, []string{websocketSubprotocol})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close(websocket.StatusNormalClosure, "")
+	ss := <-serverSessions
+	start := time.Now()
+	if err := ws.WriteMessage(websocket.BinaryMessage, []byte{0}); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = ws.ReadMessage()
+	var cerr *websocket.CloseError
+	if !errors.As(err, &cerr) || cerr.Code != websocket.StatusUnsupportedData {
+		t.Errorf("got %v, want close 1003", err)
+	}
+	// The server ends the session without waiting for the close to be
+	// acknowledged.
+	ss.Wait()
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("server session took %v to end", d)
+	}
+}